@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+
+	"bench-hub/internal/engine"
+	"bench-hub/internal/model"
+)
+
+const (
+	jobStatusRunning  = "running"
+	jobStatusFinished = "finished"
+	jobStatusFailed   = "failed"
+	jobStatusStopped  = "stopped"
+
+	roleMaster = engine.RoleMaster
+	roleWorker = engine.RoleWorker
+
+	callbackAttempts = 5
+)
+
+type job struct {
+	ID          string       `json:"job_id"`
+	TaskID      string       `json:"task_id"`
+	RunID       string       `json:"run_id"`
+	Status      string       `json:"status"`
+	StopOutcome string       `json:"stop_outcome,omitempty"`
+	Role        string       `json:"role,omitempty"`
+	MasterPort  int          `json:"master_port,omitempty"`
+	Reports     []reportInfo `json:"reports"`
+	StartedAt   time.Time    `json:"started_at"`
+	FinishedAt  *time.Time   `json:"finished_at"`
+
+	req        runRequest
+	scriptType string
+	reportDir  string
+	// ctx is cancelled to stop the engine. It exists from submission, so a
+	// stop that arrives before the engine starts is not lost.
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped bool
+}
+
+func (rn *runner) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	var req runRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if req.TaskID == "" || req.ScriptContent == "" || req.UsersCount <= 0 || req.SpawnRate <= 0 || req.DurationSeconds <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	scriptType := normalizeScriptType(req.ScriptType)
+	if _, err := rn.engines.Get(scriptType); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	switch req.Role {
+	case "":
+	case roleMaster:
+		if scriptType != "locust" || req.ExpectWorkers <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	case roleWorker:
+		if scriptType != "locust" || req.MasterHost == "" || req.MasterPort <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if rn.maxJobs > 0 && rn.activeJobs() >= rn.maxJobs {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	dirName := fmt.Sprintf("task_%s_%s", req.TaskID, time.Now().Format("20060102150405"))
+	if name := filepath.Base(filepath.Clean(req.ReportDir)); req.ReportDir != "" && name != "." && name != ".." && name != string(filepath.Separator) {
+		dirName = name
+	}
+	reportDir, err := filepath.Abs(filepath.Join(rn.reportsDir, dirName))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if err := os.MkdirAll(reportDir, 0o755); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	j := &job{
+		ID:         uuid.NewString(),
+		TaskID:     req.TaskID,
+		RunID:      req.RunID,
+		Status:     jobStatusRunning,
+		Role:       req.Role,
+		StartedAt:  time.Now(),
+		req:        req,
+		scriptType: scriptType,
+		reportDir:  reportDir,
+	}
+	if req.Role == roleMaster {
+		port, err := freePort()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		j.MasterPort = port
+	}
+
+	j.ctx, j.cancel = context.WithCancel(context.Background())
+	rn.mu.Lock()
+	rn.purgeLocked()
+	rn.jobs[j.ID] = j
+	rn.mu.Unlock()
+
+	go rn.execute(j)
+
+	writeJSON(w, http.StatusAccepted, rn.snapshot(j))
+}
+
+func (rn *runner) handleGet(w http.ResponseWriter, r *http.Request) {
+	rn.mu.Lock()
+	j := rn.jobs[r.PathValue("id")]
+	rn.mu.Unlock()
+	if j == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, rn.snapshot(j))
+}
+
+func (rn *runner) handleStop(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TaskID string `json:"task_id"`
+		JobID  string `json:"job_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.TaskID == "" && req.JobID == "") {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	rn.mu.Lock()
+	var target *job
+	for _, j := range rn.jobs {
+		if j.Status != jobStatusRunning {
+			continue
+		}
+		if (req.JobID != "" && j.ID == req.JobID) || (req.JobID == "" && j.TaskID == req.TaskID) {
+			target = j
+			break
+		}
+	}
+	var cancel context.CancelFunc
+	if target != nil {
+		target.stopped = true
+		cancel = target.cancel
+	}
+	rn.mu.Unlock()
+
+	if cancel == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	// The outcome is reported with the job once the engine has exited.
+	cancel()
+	writeJSON(w, http.StatusOK, map[string]string{"status": "stopped"})
+}
+
+// handleValidate checks a script with its engine, without running it, and
+// reports what went wrong.
+func (rn *runner) handleValidate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ScriptType    string                  `json:"script_type"`
+		ScriptContent string                  `json:"script_content"`
+		ScriptBundle  []byte                  `json:"script_bundle"`
+		Entrypoint    string                  `json:"entrypoint"`
+		Parameters    []model.ScriptParameter `json:"parameters"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ScriptContent == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	eng, err := rn.engines.Get(normalizeScriptType(req.ScriptType))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	issues, err := eng.Check(r.Context(), &model.Script{
+		Type:       eng.Name(),
+		Content:    req.ScriptContent,
+		Bundle:     req.ScriptBundle,
+		Entrypoint: req.Entrypoint,
+		Parameters: req.Parameters,
+	})
+	if err != nil {
+		log.Printf("validate script: %v", err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if issues == nil {
+		issues = []model.ScriptIssue{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"issues": issues})
+}
+
+// snapshot copies the exported job state under the lock so handlers never
+// race with execute.
+func (rn *runner) snapshot(j *job) job {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	return job{
+		ID:          j.ID,
+		TaskID:      j.TaskID,
+		RunID:       j.RunID,
+		Status:      j.Status,
+		StopOutcome: j.StopOutcome,
+		Role:        j.Role,
+		MasterPort:  j.MasterPort,
+		Reports:     append([]reportInfo(nil), j.Reports...),
+		StartedAt:   j.StartedAt,
+		FinishedAt:  j.FinishedAt,
+	}
+}
+
+func (rn *runner) purgeLocked() {
+	cutoff := time.Now().Add(-rn.jobRetention)
+	for id, j := range rn.jobs {
+		if j.FinishedAt != nil && j.FinishedAt.Before(cutoff) {
+			delete(rn.jobs, id)
+		}
+	}
+}
+
+func (rn *runner) execute(j *job) {
+	defer j.cancel()
+	status, stopOutcome, reports := rn.runEngine(j)
+
+	now := time.Now()
+	rn.mu.Lock()
+	j.Status = status
+	j.StopOutcome = stopOutcome
+	j.Reports = reports
+	j.FinishedAt = &now
+	j.cancel = nil
+	// Finished jobs are kept for a while; do not keep secrets or the
+	// bundle archive with them.
+	j.req.Secrets = nil
+	j.req.ScriptBundle = nil
+	rn.mu.Unlock()
+
+	rn.notifyComplete(j)
+}
+
+// runEngine runs the job and returns its final status, how the engine ended
+// if the job was stopped, and its reports.
+func (rn *runner) runEngine(j *job) (string, string, []reportInfo) {
+	req := j.req
+	eng, err := rn.engines.Get(j.scriptType)
+	if err != nil {
+		log.Printf("job %s: %v", j.ID, err)
+		return jobStatusFailed, "", nil
+	}
+
+	targetHost := rn.locustHost
+	if req.TargetHost != "" {
+		targetHost = req.TargetHost
+	}
+	ejob := &engine.Job{
+		Dir:           j.reportDir,
+		TaskName:      req.TaskName,
+		Script:        req.ScriptContent,
+		Bundle:        req.ScriptBundle,
+		Entrypoint:    req.Entrypoint,
+		TargetHost:    targetHost,
+		Users:         req.UsersCount,
+		SpawnRate:     req.SpawnRate,
+		Duration:      req.DurationSeconds,
+		JMeterTPM:     req.JmeterTPM,
+		Stages:        req.Stages,
+		Variables:     req.Variables,
+		Secrets:       req.Secrets,
+		Role:          j.Role,
+		MasterHost:    req.MasterHost,
+		MasterPort:    req.MasterPort,
+		ExpectWorkers: req.ExpectWorkers,
+		LogMaxBytes:   rn.logMaxBytes,
+		StopGrace:     rn.stopGrace,
+	}
+	if j.Role == roleMaster {
+		ejob.MasterPort = j.MasterPort
+	}
+
+	rn.mu.Lock()
+	stoppedEarly := j.stopped
+	rn.mu.Unlock()
+	if stoppedEarly {
+		// Stopped before the engine started: nothing ran, so nothing had
+		// to be killed.
+		return jobStatusStopped, engine.StopGraceful, nil
+	}
+
+	stopLive := streamLive(rn.api, rn.liveInterval, req.RunID, eng.Tail(j.reportDir))
+	result, err := engine.Execute(j.ctx, eng, ejob)
+	stopLive()
+	if err != nil {
+		log.Printf("job %s: %v", j.ID, err)
+		return jobStatusFailed, "", nil
+	}
+
+	reports := make([]reportInfo, 0, len(result.Reports))
+	for _, report := range result.Reports {
+		reports = append(reports, reportInfo{Name: report.Name, Type: report.Type, FilePath: report.File})
+	}
+	rn.mu.Lock()
+	stopped := j.stopped
+	rn.mu.Unlock()
+	switch {
+	case stopped && result.Stopped:
+		return jobStatusStopped, result.StopOutcome, reports
+	case stopped:
+		// The stop came after the engine had finished.
+		return jobStatusStopped, engine.StopExited, reports
+	case result.Failed:
+		return jobStatusFailed, "", reports
+	}
+	return jobStatusFinished, "", reports
+}
+
+// freePort asks the kernel for an unused TCP port for a Locust master.
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// notifyComplete tells the API the job is done so it does not have to wait
+// for its next poll. Failures are only logged; polling remains the source of
+// truth.
+func (rn *runner) notifyComplete(j *job) {
+	if !rn.api.enabled() || j.RunID == "" || j.Role == roleWorker {
+		return
+	}
+	payload := rn.snapshot(j)
+	for attempt := 1; attempt <= callbackAttempts; attempt++ {
+		err := rn.api.post("/api/v1/runner/jobs/"+j.ID+"/complete", payload)
+		if err == nil {
+			return
+		}
+		log.Printf("job %s: completion callback attempt %d: %v", j.ID, attempt, err)
+		time.Sleep(time.Duration(attempt) * 2 * time.Second)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"bench-hub/internal/engine"
+	"bench-hub/internal/model"
+)
+
+const runnerTokenHeader = "X-Runner-Token"
+
+var errAPINotFound = errors.New("api status 404")
+
+type apiClient struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func newAPIClient(baseURL, token string) *apiClient {
+	return &apiClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *apiClient) enabled() bool {
+	return c != nil && c.baseURL != ""
+}
+
+func (c *apiClient) post(path string, payload interface{}) error {
+	return c.call(path, payload, nil)
+}
+
+// call posts payload and, when out is non-nil, decodes the data field of the
+// API response envelope into it.
+func (c *apiClient) call(path string, payload, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(runnerTokenHeader, c.token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return errAPINotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("api status %d", resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	envelope := struct {
+		Data interface{} `json:"data"`
+	}{Data: out}
+	return json.NewDecoder(resp.Body).Decode(&envelope)
+}
+
+// streamLive tails the run directory with tailer and pushes snapshots to the
+// API until the returned func is called.
+func streamLive(api *apiClient, interval time.Duration, runID string, tailer engine.Tailer) func() {
+	if !api.enabled() || runID == "" {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				sample, err := tailer.Poll()
+				if err != nil || sample == nil {
+					continue
+				}
+				payload := struct {
+					Snapshot *model.MetricSample `json:"snapshot"`
+				}{Snapshot: sample}
+				if err := api.post("/api/v1/runner/runs/"+runID+"/snapshots", payload); err != nil {
+					log.Printf("push live snapshot for run %s: %v", runID, err)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type registration struct {
+	Name    string            `json:"name"`
+	URL     string            `json:"url"`
+	Labels  map[string]string `json:"labels"`
+	MaxJobs int               `json:"max_jobs"`
+}
+
+type heartbeat struct {
+	ActiveJobs int     `json:"active_jobs"`
+	CPUPercent float64 `json:"cpu_percent"`
+}
+
+// joinPool registers the runner with the API and keeps sending heartbeats.
+// A heartbeat answered with 404 means the API forgot the runner, so it
+// registers again.
+func (rn *runner) joinPool(reg registration, interval time.Duration) {
+	if !rn.api.enabled() {
+		return
+	}
+	go func() {
+		cpu := &cpuSampler{}
+		cpu.sample()
+		id := ""
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if id == "" {
+				var registered struct {
+					ID string `json:"id"`
+				}
+				if err := rn.api.call("/api/v1/runner/register", reg, &registered); err != nil {
+					log.Printf("register runner %s: %v", reg.Name, err)
+				} else {
+					id = registered.ID
+					log.Printf("registered runner %s as %s", reg.Name, id)
+				}
+			} else {
+				payload := heartbeat{ActiveJobs: rn.activeJobs(), CPUPercent: cpu.sample()}
+				err := rn.api.post("/api/v1/runner/runners/"+id+"/heartbeat", payload)
+				if errors.Is(err, errAPINotFound) {
+					id = ""
+					continue
+				}
+				if err != nil {
+					log.Printf("runner heartbeat: %v", err)
+				}
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+func (rn *runner) activeJobs() int {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	count := 0
+	for _, j := range rn.jobs {
+		if j.Status == jobStatusRunning {
+			count++
+		}
+	}
+	return count
+}
+
+// parseLabels reads "region=eu,zone=a" style label lists.
+func parseLabels(value string) map[string]string {
+	labels := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		key, val, _ := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		labels[key] = strings.TrimSpace(val)
+	}
+	return labels
+}
+
+// cpuSampler reports host CPU usage between two calls from /proc/stat. It
+// returns 0 where that file is not available.
+type cpuSampler struct {
+	idle  uint64
+	total uint64
+}
+
+func (s *cpuSampler) sample() float64 {
+	file, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	line, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0
+	}
+	var idle, total uint64
+	for i, field := range fields[1:] {
+		value, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return 0
+		}
+		total += value
+		// idle and iowait
+		if i == 3 || i == 4 {
+			idle += value
+		}
+	}
+
+	deltaIdle, deltaTotal := idle-s.idle, total-s.total
+	s.idle, s.total = idle, total
+	if deltaTotal == 0 {
+		return 0
+	}
+	return float64(deltaTotal-deltaIdle) / float64(deltaTotal) * 100
+}
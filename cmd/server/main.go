@@ -8,10 +8,16 @@ import (
 
 	"bench-hub/internal/api"
 	"bench-hub/internal/config"
+	"bench-hub/internal/engine"
+	"bench-hub/internal/engine/jmeter"
+	"bench-hub/internal/engine/k6"
+	"bench-hub/internal/engine/locust"
+	"bench-hub/internal/engine/native"
 	"bench-hub/internal/middleware"
 	"bench-hub/internal/migrate"
 	"bench-hub/internal/observability"
 	"bench-hub/internal/repository/postgres"
+	"bench-hub/internal/secret"
 	"bench-hub/internal/service"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -38,24 +44,66 @@ func main() {
 	taskRepo := postgres.NewTaskRepo(pool)
 	reportRepo := postgres.NewReportRepo(pool)
 	settingsRepo := postgres.NewSettingsRepo(pool)
+	metricRepo := postgres.NewMetricRepo(pool)
+	taskRunRepo := postgres.NewTaskRunRepo(pool)
+	runnerRepo := postgres.NewRunnerRepo(pool)
+	instanceRepo := postgres.NewInstanceRepo(pool)
+	scheduleRepo := postgres.NewScheduleRepo(pool)
+	secretRepo := postgres.NewSecretRepo(pool)
+	if cfg.SecretsKey == "" {
+		log.Fatalf("SECRETS_KEY is not set")
+	}
+	secretBox, err := secret.NewBox(cfg.SecretsKey)
+	if err != nil {
+		log.Fatalf("secrets key: %v", err)
+	}
 	authService := service.NewAuthService(userRepo, cfg.JWTSecret, cfg.AccessTokenMinutes, cfg.RefreshTokenDays, cfg.JWTIssuer)
 	userService := service.NewUserService(userRepo)
-	scriptService := service.NewScriptService(scriptRepo)
-	taskService := service.NewTaskService(taskRepo)
+	secretService := service.NewSecretService(secretRepo, secretBox)
+	taskService := service.NewTaskService(taskRepo, scriptRepo, secretService)
 	reportService := service.NewReportService(reportRepo, cfg.ReportsDir)
-	runner := service.NewTaskRunner(taskRepo, scriptRepo, reportRepo, cfg.ReportsDir, cfg.LocustBin, cfg.LocustHost, cfg.RunnerURL)
+	// Engines the server runs itself when no runner takes a run. They also
+	// read the results of runs that ran on runners.
+	engines := engine.NewRegistry(locust.New(cfg.LocustBin), jmeter.New(cfg.JMeterBin), k6.New(cfg.K6Bin), native.New())
+	metricsService := service.NewMetricsService(metricRepo, taskRunRepo, engines, cfg.ReportsDir)
+	runService := service.NewRunService(taskRunRepo, taskRepo, reportRepo, cfg.ReportsDir)
+	compareService := service.NewCompareService(metricsService, runService)
 	settingsService := service.NewSettingsService(settingsRepo)
+	liveService := service.NewLiveService(taskRunRepo)
+	runnerPool := service.NewRunnerPoolService(runnerRepo, cfg.RunnerHeartbeatTTL)
+	scriptService := service.NewScriptService(scriptRepo, engines, service.NewScriptChecker(runnerPool, engines, cfg.RunnerURL, cfg.RunnerToken))
+	runner := service.NewTaskRunner(taskRepo, scriptRepo, taskRunRepo, reportRepo, instanceRepo, metricsService, settingsService, liveService, runnerPool, secretService, engines, cfg.ReportsDir, cfg.LocustHost, cfg.RunnerURL, cfg.RunnerToken, cfg.RunLogMaxBytes, cfg.StopGrace, service.QueueLimits{
+		MaxConcurrent: cfg.MaxConcurrentRuns,
+		MaxPerHost:    cfg.MaxRunsPerHost,
+	})
+	if err := runner.Reconcile(ctx); err != nil {
+		log.Printf("reconcile runs: %v", err)
+	}
+	if err := runner.Resume(ctx); err != nil {
+		log.Printf("resume runs: %v", err)
+	}
+	runner.StartReconciler(ctx, cfg.ReconcileInterval)
+	runner.StartDispatcher(ctx, cfg.DispatchInterval)
+	scheduleService := service.NewScheduleService(scheduleRepo, taskRepo, runner)
+	scheduleService.Start(ctx, cfg.ScheduleInterval)
 	statsService := service.NewStatsService(userRepo, scriptRepo, reportRepo, settingsService)
 
 	services := &service.Services{
-		Auth:     authService,
-		Users:    userService,
-		Scripts:  scriptService,
-		Tasks:    taskService,
-		Reports:  reportService,
-		Runner:   runner,
-		Settings: settingsService,
-		Stats:    statsService,
+		Auth:      authService,
+		Users:     userService,
+		Scripts:   scriptService,
+		Tasks:     taskService,
+		Reports:   reportService,
+		Runner:    runner,
+		Pool:      runnerPool,
+		Secrets:   secretService,
+		Schedules: scheduleService,
+		Runs:      runService,
+		Metrics:   metricsService,
+		Compare:   compareService,
+		Live:      liveService,
+		Settings:  settingsService,
+		Stats:     statsService,
 	}
 
 	router := gin.New()
@@ -66,7 +114,7 @@ func main() {
 
 	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
-	api.RegisterRoutes(router, services)
+	api.RegisterRoutes(router, services, cfg.RunnerToken)
 
 	addr := ":" + cfg.Port
 	log.Printf("listening on %s", addr)
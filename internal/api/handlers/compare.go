@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/service"
+)
+
+type CompareHandler struct {
+	compare *service.CompareService
+}
+
+func NewCompareHandler(compare *service.CompareService) *CompareHandler {
+	return &CompareHandler{compare: compare}
+}
+
+func (h *CompareHandler) Compare(c *gin.Context) {
+	base := c.Query("base")
+	candidate := c.Query("candidate")
+	if base == "" || candidate == "" {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
+	}
+
+	tolerance := service.DefaultRegressionTolerancePct
+	if value := c.Query("tolerance"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+			return
+		}
+		tolerance = parsed
+	}
+
+	comparison, err := h.compare.Compare(c.Request.Context(), base, candidate, tolerance)
+	if err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(comparison))
+}
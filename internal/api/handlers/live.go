@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/service"
+)
+
+const liveHeartbeatInterval = 15 * time.Second
+
+type LiveHandler struct {
+	live  *service.LiveService
+	tasks *service.TaskService
+}
+
+func NewLiveHandler(live *service.LiveService, tasks *service.TaskService) *LiveHandler {
+	return &LiveHandler{live: live, tasks: tasks}
+}
+
+// Stream sends live run snapshots of a task as Server-Sent Events.
+func (h *LiveHandler) Stream(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := h.tasks.Get(c.Request.Context(), id); err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+
+	events, latest, cancel := h.live.Subscribe(id)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	if latest != nil {
+		c.SSEvent(latest.Type, latest)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(liveHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	ctx := c.Request.Context()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case event := <-events:
+			c.SSEvent(event.Type, event)
+		case <-heartbeat.C:
+			c.SSEvent("ping", gin.H{"time": time.Now().Unix()})
+		}
+		return true
+	})
+}
+
+type snapshotRequest struct {
+	Snapshot model.MetricSample `json:"snapshot"`
+}
+
+// PublishSnapshot is called by runners while a run is in progress.
+func (h *LiveHandler) PublishSnapshot(c *gin.Context) {
+	var req snapshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
+	}
+	if err := h.live.PublishSnapshot(c.Request.Context(), c.Param("id"), req.Snapshot); err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		if err == service.ErrRunNotActive {
+			model.JSON(c, http.StatusConflict, model.Fail(2000, "run not active"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(nil))
+}
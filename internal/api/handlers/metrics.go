@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/service"
+)
+
+type MetricsHandler struct {
+	metrics *service.MetricsService
+}
+
+func NewMetricsHandler(metrics *service.MetricsService) *MetricsHandler {
+	return &MetricsHandler{metrics: metrics}
+}
+
+func (h *MetricsHandler) ForTask(c *gin.Context) {
+	id := c.Param("id")
+	var metrics *model.RunMetrics
+	var err error
+	if runID := c.Query("run_id"); runID != "" {
+		metrics, err = h.metrics.ForRun(c.Request.Context(), runID)
+		if err == nil && metrics.TaskID != id {
+			err = service.ErrNotFound
+		}
+	} else {
+		metrics, err = h.metrics.ForTask(c.Request.Context(), id)
+	}
+	if err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(metrics))
+}
+
+func (h *MetricsHandler) ForRun(c *gin.Context) {
+	metrics, err := h.metrics.ForRun(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(metrics))
+}
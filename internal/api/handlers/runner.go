@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/service"
+)
+
+type RunnerHandler struct {
+	pool *service.RunnerPoolService
+}
+
+func NewRunnerHandler(pool *service.RunnerPoolService) *RunnerHandler {
+	return &RunnerHandler{pool: pool}
+}
+
+func (h *RunnerHandler) List(c *gin.Context) {
+	runners, err := h.pool.List(c.Request.Context())
+	if err != nil {
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(gin.H{"items": runners}))
+}
+
+type registerRunnerRequest struct {
+	Name    string            `json:"name" binding:"required"`
+	URL     string            `json:"url" binding:"required"`
+	Labels  map[string]string `json:"labels"`
+	MaxJobs int               `json:"max_jobs"`
+}
+
+// Register is called by a runner on startup and whenever the API no longer
+// knows it.
+func (h *RunnerHandler) Register(c *gin.Context) {
+	var req registerRunnerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
+	}
+	runner := &model.Runner{
+		Name:    req.Name,
+		URL:     req.URL,
+		Labels:  req.Labels,
+		MaxJobs: req.MaxJobs,
+	}
+	if err := h.pool.Register(c.Request.Context(), runner); err != nil {
+		if err == service.ErrInvalidRunner {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(runner))
+}
+
+type heartbeatRequest struct {
+	ActiveJobs int     `json:"active_jobs"`
+	CPUPercent float64 `json:"cpu_percent"`
+}
+
+func (h *RunnerHandler) Heartbeat(c *gin.Context) {
+	var req heartbeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.ActiveJobs < 0 {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
+	}
+	if err := h.pool.Heartbeat(c.Request.Context(), c.Param("id"), req.ActiveJobs, req.CPUPercent); err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(nil))
+}
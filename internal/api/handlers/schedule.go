@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/service"
+)
+
+type ScheduleHandler struct {
+	schedules *service.ScheduleService
+}
+
+func NewScheduleHandler(schedules *service.ScheduleService) *ScheduleHandler {
+	return &ScheduleHandler{schedules: schedules}
+}
+
+type scheduleRequest struct {
+	TaskID          string `json:"task_id" binding:"required"`
+	Name            string `json:"name" binding:"required"`
+	CronExpr        string `json:"cron_expr"`
+	IntervalSeconds int    `json:"interval_seconds"`
+	Timezone        string `json:"timezone"`
+	TargetHost      string `json:"target_host"`
+	Enabled         *bool  `json:"enabled"`
+}
+
+func (req scheduleRequest) input() service.ScheduleInput {
+	var targetHost *string
+	if req.TargetHost != "" {
+		targetHost = &req.TargetHost
+	}
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	return service.ScheduleInput{
+		TaskID:          req.TaskID,
+		Name:            req.Name,
+		CronExpr:        req.CronExpr,
+		IntervalSeconds: req.IntervalSeconds,
+		Timezone:        req.Timezone,
+		TargetHost:      targetHost,
+		Enabled:         enabled,
+	}
+}
+
+func (h *ScheduleHandler) List(c *gin.Context) {
+	page := parseIntDefault(c.Query("page"), 1)
+	pageSize := parseIntDefault(c.Query("page_size"), 20)
+	if page < 1 || pageSize < 1 || pageSize > 100 {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
+	}
+
+	offset := (page - 1) * pageSize
+	schedules, err := h.schedules.List(c.Request.Context(), c.Query("task_id"), pageSize, offset)
+	if err != nil {
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+
+	model.JSON(c, http.StatusOK, model.OK(gin.H{
+		"items": schedules,
+		"page":  page,
+		"size":  pageSize,
+	}))
+}
+
+func (h *ScheduleHandler) Get(c *gin.Context) {
+	schedule, err := h.schedules.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(schedule))
+}
+
+func (h *ScheduleHandler) Create(c *gin.Context) {
+	var req scheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
+	}
+	schedule, err := h.schedules.Create(c.Request.Context(), req.input(), c.GetString("user_id"))
+	if err != nil {
+		if err == service.ErrInvalidSchedule {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+			return
+		}
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(schedule))
+}
+
+func (h *ScheduleHandler) Update(c *gin.Context) {
+	var req scheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
+	}
+	schedule, err := h.schedules.Update(c.Request.Context(), c.Param("id"), req.input())
+	if err != nil {
+		if err == service.ErrInvalidSchedule {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+			return
+		}
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(schedule))
+}
+
+func (h *ScheduleHandler) Delete(c *gin.Context) {
+	if err := h.schedules.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(nil))
+}
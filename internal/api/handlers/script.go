@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
-	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"bench-hub/internal/bundle"
 	"bench-hub/internal/model"
 	"bench-hub/internal/service"
 )
@@ -17,23 +21,33 @@ type ScriptHandler struct {
 }
 
 type scriptCreateRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
-	Type        string `json:"type"`
-	Content     string `json:"content" binding:"required"`
+	Name        string                  `json:"name" binding:"required"`
+	Description string                  `json:"description"`
+	Type        string                  `json:"type"`
+	Content     string                  `json:"content" binding:"required"`
+	Parameters  []model.ScriptParameter `json:"parameters"`
+	Message     string                  `json:"message"`
 }
 
 type scriptUpdateRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Type        string `json:"type"`
-	Content     string `json:"content"`
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	Type        string                  `json:"type"`
+	Content     string                  `json:"content"`
+	Parameters  []model.ScriptParameter `json:"parameters"`
+	Message     string                  `json:"message"`
 }
 
 func NewScriptHandler(scripts *service.ScriptService) *ScriptHandler {
 	return &ScriptHandler{scripts: scripts}
 }
 
+// Types lists the script types and the files each runs, for editors and
+// uploads.
+func (h *ScriptHandler) Types(c *gin.Context) {
+	model.JSON(c, http.StatusOK, model.OK(h.scripts.Formats()))
+}
+
 func (h *ScriptHandler) List(c *gin.Context) {
 	page := parseIntDefault(c.Query("page"), 1)
 	pageSize := parseIntDefault(c.Query("page_size"), 20)
@@ -77,12 +91,24 @@ func (h *ScriptHandler) Create(c *gin.Context) {
 		return
 	}
 
-	script, err := h.scripts.Create(c.Request.Context(), req.Name, req.Description, req.Type, req.Content)
+	script, err := h.scripts.Create(c.Request.Context(), service.ScriptInput{
+		Name:        req.Name,
+		Description: req.Description,
+		Type:        req.Type,
+		Content:     req.Content,
+		Parameters:  req.Parameters,
+		Message:     req.Message,
+	}, c.GetString("user_id"))
 	if err != nil {
-		if err == service.ErrInvalidScriptType {
+		if err == service.ErrInvalidScriptType || err == service.ErrInvalidScriptParameter {
 			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
 			return
 		}
+		var invalid *service.ScriptInvalidError
+		if errors.As(err, &invalid) {
+			model.JSON(c, http.StatusBadRequest, model.Response{Code: 1000, Message: "invalid script", Data: invalid.Validation})
+			return
+		}
 		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
 		return
 	}
@@ -98,16 +124,32 @@ func (h *ScriptHandler) Update(c *gin.Context) {
 		return
 	}
 
-	script, err := h.scripts.Update(c.Request.Context(), id, req.Name, req.Description, req.Type, req.Content)
+	script, err := h.scripts.Update(c.Request.Context(), id, service.ScriptInput{
+		Name:        req.Name,
+		Description: req.Description,
+		Type:        req.Type,
+		Content:     req.Content,
+		Parameters:  req.Parameters,
+		Message:     req.Message,
+	}, c.GetString("user_id"))
 	if err != nil {
 		if err == service.ErrNotFound {
 			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
 			return
 		}
-		if err == service.ErrInvalidScriptType {
+		if err == service.ErrInvalidScriptType || err == service.ErrInvalidScriptParameter {
 			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
 			return
 		}
+		if err == service.ErrInvalidBundle {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid bundle"))
+			return
+		}
+		var invalid *service.ScriptInvalidError
+		if errors.As(err, &invalid) {
+			model.JSON(c, http.StatusBadRequest, model.Response{Code: 1000, Message: "invalid script", Data: invalid.Validation})
+			return
+		}
 		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
 		return
 	}
@@ -129,15 +171,171 @@ func (h *ScriptHandler) Delete(c *gin.Context) {
 	model.JSON(c, http.StatusOK, model.OK(nil))
 }
 
-func scriptTypeFromFilename(name string) string {
-	switch strings.ToLower(filepath.Ext(name)) {
-	case ".jmx":
-		return model.ScriptTypeJMeter
-	case ".py":
-		return model.ScriptTypeLocust
-	default:
-		return ""
+type scriptValidateRequest struct {
+	Type       string                  `json:"type"`
+	Content    string                  `json:"content" binding:"required"`
+	Parameters []model.ScriptParameter `json:"parameters"`
+}
+
+// Validate checks a script without saving it. It takes the same JSON as
+// Create, or a multipart upload like Import for files and bundles.
+func (h *ScriptHandler) Validate(c *gin.Context) {
+	var input service.ScriptInput
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		data, filename, ok := readUpload(c)
+		if !ok {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+			return
+		}
+		input.Type = c.PostForm("type")
+		if isArchiveFilename(filename) {
+			input.Bundle = data
+			input.Entrypoint = c.PostForm("entrypoint")
+		} else {
+			if input.Type == "" {
+				input.Type = h.scripts.TypeOf(filename)
+			}
+			input.Content = string(data)
+		}
+		if raw := c.PostForm("parameters"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &input.Parameters); err != nil {
+				model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+				return
+			}
+		}
+	} else {
+		var req scriptValidateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+			return
+		}
+		input = service.ScriptInput{Type: req.Type, Content: req.Content, Parameters: req.Parameters}
+	}
+
+	result, err := h.scripts.Validate(c.Request.Context(), input)
+	if err != nil {
+		if err == service.ErrInvalidScriptType || err == service.ErrInvalidScriptParameter {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+			return
+		}
+		if err == service.ErrInvalidBundle {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid bundle"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(result))
+}
+
+func (h *ScriptHandler) Versions(c *gin.Context) {
+	versions, err := h.scripts.Versions(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
 	}
+	model.JSON(c, http.StatusOK, model.OK(gin.H{"items": versions}))
+}
+
+func (h *ScriptHandler) Version(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
+	}
+	item, err := h.scripts.Version(c.Request.Context(), c.Param("id"), version)
+	if err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(item))
+}
+
+// Diff compares two versions; to defaults to the current version and from
+// to the one before it.
+func (h *ScriptHandler) Diff(c *gin.Context) {
+	id := c.Param("id")
+	script, err := h.scripts.Get(c.Request.Context(), id)
+	if err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	to := parseIntDefault(c.Query("to"), script.Version)
+	from := parseIntDefault(c.Query("from"), to-1)
+	if from < 1 || to < 1 {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
+	}
+
+	diff, err := h.scripts.Diff(c.Request.Context(), id, from, to)
+	if err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(gin.H{
+		"from": from,
+		"to":   to,
+		"diff": diff,
+	}))
+}
+
+func (h *ScriptHandler) Restore(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
+	}
+	script, err := h.scripts.Restore(c.Request.Context(), c.Param("id"), version, c.GetString("user_id"))
+	if err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(script))
+}
+
+func isArchiveFilename(name string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// readUpload reads the multipart "file" field, refusing anything larger
+// than a bundle may be.
+func readUpload(c *gin.Context) ([]byte, string, bool) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		return nil, "", false
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, bundle.MaxArchiveBytes+1))
+	if err != nil || len(data) > bundle.MaxArchiveBytes {
+		return nil, "", false
+	}
+	return data, header.Filename, true
 }
 
 func (h *ScriptHandler) Import(c *gin.Context) {
@@ -147,34 +345,176 @@ func (h *ScriptHandler) Import(c *gin.Context) {
 		return
 	}
 
-	file, header, err := c.Request.FormFile("file")
+	data, filename, ok := readUpload(c)
+	if !ok {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
+	}
+
+	description := c.PostForm("description")
+	scriptType := c.PostForm("type")
+	input := service.ScriptInput{
+		Name:        name,
+		Description: description,
+		Message:     c.PostForm("message"),
+	}
+	if isArchiveFilename(filename) {
+		input.Bundle = data
+		input.Entrypoint = c.PostForm("entrypoint")
+	} else {
+		if scriptType == "" {
+			scriptType = h.scripts.TypeOf(filename)
+		}
+		input.Content = string(data)
+	}
+	input.Type = scriptType
+	var parameters []model.ScriptParameter
+	if raw := c.PostForm("parameters"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &parameters); err != nil {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+			return
+		}
+	}
+
+	input.Parameters = parameters
+
+	script, err := h.scripts.Create(c.Request.Context(), input, c.GetString("user_id"))
 	if err != nil {
+		if err == service.ErrInvalidScriptType || err == service.ErrInvalidScriptParameter {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+			return
+		}
+		if err == service.ErrInvalidBundle {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid bundle"))
+			return
+		}
+		var invalid *service.ScriptInvalidError
+		if errors.As(err, &invalid) {
+			model.JSON(c, http.StatusBadRequest, model.Response{Code: 1000, Message: "invalid script", Data: invalid.Validation})
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+
+	model.JSON(c, http.StatusOK, model.OK(script))
+}
+
+// Generate creates a Locust script from an uploaded OpenAPI 3 document or
+// HAR file; jmeter=true also creates an equivalent JMeter plan.
+func (h *ScriptHandler) Generate(c *gin.Context) {
+	data, _, ok := readUpload(c)
+	if !ok {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
+	}
+	withJMeter, _ := strconv.ParseBool(c.DefaultPostForm("jmeter", "false"))
+
+	scripts, err := h.scripts.Generate(c.Request.Context(), c.PostForm("name"), c.PostForm("description"), data, withJMeter, c.GetString("user_id"))
+	if err != nil {
+		if err == service.ErrInvalidSource {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "expected an OpenAPI 3 document or a HAR file"))
+			return
+		}
+		if err == service.ErrInvalidScriptParameter {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+			return
+		}
+		var invalid *service.ScriptInvalidError
+		if errors.As(err, &invalid) {
+			model.JSON(c, http.StatusBadRequest, model.Response{Code: 1000, Message: "invalid script", Data: invalid.Validation})
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+
+	model.JSON(c, http.StatusOK, model.OK(scripts))
+}
+
+// Convert translates a script into the other engine's format as a new
+// script; the response lists what could not be translated.
+func (h *ScriptHandler) Convert(c *gin.Context) {
+	to := c.Query("to")
+	if to == "" {
 		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
 		return
 	}
-	defer file.Close()
 
-	data, err := io.ReadAll(file)
+	result, err := h.scripts.Convert(c.Request.Context(), c.Param("id"), to, c.GetString("user_id"))
 	if err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		if err == service.ErrInvalidScriptType || err == service.ErrInvalidScriptParameter {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+			return
+		}
+		if err == service.ErrInvalidConversion {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "script cannot be converted"))
+			return
+		}
+		var invalid *service.ScriptInvalidError
+		if errors.As(err, &invalid) {
+			model.JSON(c, http.StatusBadRequest, model.Response{Code: 1000, Message: "invalid script", Data: invalid.Validation})
+			return
+		}
 		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
 		return
 	}
 
-	description := c.PostForm("description")
-	scriptType := c.PostForm("type")
-	if scriptType == "" {
-		scriptType = scriptTypeFromFilename(header.Filename)
+	model.JSON(c, http.StatusOK, model.OK(result))
+}
+
+// UploadBundle replaces a script's archive, recording a new version.
+func (h *ScriptHandler) UploadBundle(c *gin.Context) {
+	data, filename, ok := readUpload(c)
+	if !ok || !isArchiveFilename(filename) {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
 	}
 
-	script, err := h.scripts.Create(c.Request.Context(), name, description, scriptType, string(data))
+	script, err := h.scripts.Update(c.Request.Context(), c.Param("id"), service.ScriptInput{
+		Type:       c.PostForm("type"),
+		Bundle:     data,
+		Entrypoint: c.PostForm("entrypoint"),
+		Message:    c.PostForm("message"),
+	}, c.GetString("user_id"))
 	if err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
 		if err == service.ErrInvalidScriptType {
 			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
 			return
 		}
+		if err == service.ErrInvalidBundle {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid bundle"))
+			return
+		}
+		var invalid *service.ScriptInvalidError
+		if errors.As(err, &invalid) {
+			model.JSON(c, http.StatusBadRequest, model.Response{Code: 1000, Message: "invalid script", Data: invalid.Validation})
+			return
+		}
 		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
 		return
 	}
-
 	model.JSON(c, http.StatusOK, model.OK(script))
 }
+
+func (h *ScriptHandler) DownloadBundle(c *gin.Context) {
+	script, data, err := h.scripts.Bundle(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("%s-v%d%s", script.Name, script.Version, bundle.Ext(data))))
+	c.Data(http.StatusOK, "application/octet-stream", data)
+}
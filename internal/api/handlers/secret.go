@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/service"
+)
+
+// SecretHandler manages secrets without ever returning their values.
+type SecretHandler struct {
+	secrets *service.SecretService
+}
+
+func NewSecretHandler(secrets *service.SecretService) *SecretHandler {
+	return &SecretHandler{secrets: secrets}
+}
+
+type secretCreateRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Value       string `json:"value" binding:"required"`
+}
+
+type secretUpdateRequest struct {
+	Description string `json:"description"`
+	Value       string `json:"value"`
+}
+
+func (h *SecretHandler) List(c *gin.Context) {
+	secrets, err := h.secrets.List(c.Request.Context())
+	if err != nil {
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(gin.H{"items": secrets}))
+}
+
+func (h *SecretHandler) Create(c *gin.Context) {
+	var req secretCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
+	}
+	secret, err := h.secrets.Create(c.Request.Context(), req.Name, req.Description, req.Value, c.GetString("user_id"))
+	if err != nil {
+		if err == service.ErrInvalidSecret {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+			return
+		}
+		if err == service.ErrSecretExists {
+			model.JSON(c, http.StatusConflict, model.Fail(2000, "secret already exists"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(secret))
+}
+
+func (h *SecretHandler) Update(c *gin.Context) {
+	var req secretUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
+	}
+	secret, err := h.secrets.Update(c.Request.Context(), c.Param("id"), req.Description, req.Value)
+	if err != nil {
+		if err == service.ErrInvalidSecret {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+			return
+		}
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(secret))
+}
+
+func (h *SecretHandler) Delete(c *gin.Context) {
+	if err := h.secrets.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(nil))
+}
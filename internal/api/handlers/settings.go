@@ -17,6 +17,10 @@ type updateP95Request struct {
 	Value string `json:"value"`
 }
 
+type updateSLARulesRequest struct {
+	Rules []model.SLARule `json:"rules"`
+}
+
 func NewSettingsHandler(settings *service.SettingsService) *SettingsHandler {
 	return &SettingsHandler{settings: settings}
 }
@@ -42,3 +46,30 @@ func (h *SettingsHandler) UpdateP95(c *gin.Context) {
 	}
 	model.JSON(c, http.StatusOK, model.OK(nil))
 }
+
+func (h *SettingsHandler) GetSLARules(c *gin.Context) {
+	rules, err := h.settings.GetSLARules(c.Request.Context())
+	if err != nil {
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(gin.H{"rules": rules}))
+}
+
+func (h *SettingsHandler) UpdateSLARules(c *gin.Context) {
+	var req updateSLARulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
+	}
+	rules, err := h.settings.SetSLARules(c.Request.Context(), req.Rules)
+	if err != nil {
+		if err == service.ErrInvalidSLARule {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(gin.H{"rules": rules}))
+}
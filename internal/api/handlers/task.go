@@ -9,29 +9,68 @@ import (
 	"bench-hub/internal/service"
 )
 
+const maxTaskWorkers = 64
+
 type TaskHandler struct {
 	tasks  *service.TaskService
 	runner *service.TaskRunner
 }
 
 type taskCreateRequest struct {
-	Name            string `json:"name" binding:"required"`
-	ScriptID        string `json:"script_id" binding:"required"`
-	UsersCount      int    `json:"users_count" binding:"required"`
-	SpawnRate       int    `json:"spawn_rate" binding:"required"`
-	DurationSeconds int    `json:"duration_seconds" binding:"required"`
-	TargetHost      string `json:"target_host"`
-	JmeterTPM       *int   `json:"jmeter_tpm"`
+	Name            string             `json:"name" binding:"required"`
+	ScriptID        string             `json:"script_id" binding:"required"`
+	ScriptVersion   *int               `json:"script_version"`
+	UsersCount      int                `json:"users_count" binding:"required"`
+	SpawnRate       int                `json:"spawn_rate" binding:"required"`
+	DurationSeconds int                `json:"duration_seconds" binding:"required"`
+	TargetHost      string             `json:"target_host"`
+	JmeterTPM       *int               `json:"jmeter_tpm"`
+	Workers         int                `json:"workers"`
+	LoadProfile     *model.LoadProfile `json:"load_profile"`
+	Variables       map[string]string  `json:"variables"`
+	Secrets         []string           `json:"secrets"`
+	SLARules        []model.SLARule    `json:"sla_rules"`
+	RunnerLabels    map[string]string  `json:"runner_labels"`
 }
 
 type taskUpdateRequest struct {
-	Name            string `json:"name" binding:"required"`
-	ScriptID        string `json:"script_id" binding:"required"`
-	UsersCount      int    `json:"users_count" binding:"required"`
-	SpawnRate       int    `json:"spawn_rate" binding:"required"`
-	DurationSeconds int    `json:"duration_seconds" binding:"required"`
-	TargetHost      string `json:"target_host"`
-	JmeterTPM       *int   `json:"jmeter_tpm"`
+	Name            string             `json:"name" binding:"required"`
+	ScriptID        string             `json:"script_id" binding:"required"`
+	ScriptVersion   *int               `json:"script_version"`
+	UsersCount      int                `json:"users_count" binding:"required"`
+	SpawnRate       int                `json:"spawn_rate" binding:"required"`
+	DurationSeconds int                `json:"duration_seconds" binding:"required"`
+	TargetHost      string             `json:"target_host"`
+	JmeterTPM       *int               `json:"jmeter_tpm"`
+	Workers         int                `json:"workers"`
+	LoadProfile     *model.LoadProfile `json:"load_profile"`
+	Variables       map[string]string  `json:"variables"`
+	Secrets         []string           `json:"secrets"`
+	SLARules        []model.SLARule    `json:"sla_rules"`
+	RunnerLabels    map[string]string  `json:"runner_labels"`
+}
+
+func (req taskCreateRequest) input() service.TaskInput {
+	var targetHost *string
+	if req.TargetHost != "" {
+		targetHost = &req.TargetHost
+	}
+	return service.TaskInput{
+		Name:            req.Name,
+		ScriptID:        req.ScriptID,
+		ScriptVersion:   req.ScriptVersion,
+		UsersCount:      req.UsersCount,
+		SpawnRate:       req.SpawnRate,
+		DurationSeconds: req.DurationSeconds,
+		TargetHost:      targetHost,
+		JmeterTPM:       req.JmeterTPM,
+		Workers:         req.Workers,
+		LoadProfile:     req.LoadProfile,
+		Variables:       req.Variables,
+		Secrets:         req.Secrets,
+		SLARules:        req.SLARules,
+		RunnerLabels:    req.RunnerLabels,
+	}
 }
 
 func NewTaskHandler(tasks *service.TaskService, runner *service.TaskRunner) *TaskHandler {
@@ -89,14 +128,33 @@ func (h *TaskHandler) Create(c *gin.Context) {
 		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
 		return
 	}
-
-	var targetHost *string
-	if req.TargetHost != "" {
-		targetHost = &req.TargetHost
+	if req.Workers < 0 || req.Workers > maxTaskWorkers {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
 	}
 
-	task, err := h.tasks.Create(c.Request.Context(), req.Name, req.ScriptID, req.UsersCount, req.SpawnRate, req.DurationSeconds, targetHost, req.JmeterTPM)
+	task, err := h.tasks.Create(c.Request.Context(), req.input())
 	if err != nil {
+		if err == service.ErrInvalidSLARule || err == service.ErrInvalidLoadProfile {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+			return
+		}
+		if err == service.ErrInvalidVariables {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid variables"))
+			return
+		}
+		if err == service.ErrScriptNotFound {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "script not found"))
+			return
+		}
+		if err == service.ErrScriptVersionNotFound {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "script version not found"))
+			return
+		}
+		if err == service.ErrInvalidSecret {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid secrets"))
+			return
+		}
 		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
 		return
 	}
@@ -120,14 +178,33 @@ func (h *TaskHandler) Update(c *gin.Context) {
 		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
 		return
 	}
-
-	var targetHost *string
-	if req.TargetHost != "" {
-		targetHost = &req.TargetHost
+	if req.Workers < 0 || req.Workers > maxTaskWorkers {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
 	}
 
-	task, err := h.tasks.Update(c.Request.Context(), id, req.Name, req.ScriptID, req.UsersCount, req.SpawnRate, req.DurationSeconds, targetHost, req.JmeterTPM)
+	task, err := h.tasks.Update(c.Request.Context(), id, taskCreateRequest(req).input())
 	if err != nil {
+		if err == service.ErrInvalidSLARule || err == service.ErrInvalidLoadProfile {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+			return
+		}
+		if err == service.ErrInvalidVariables {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid variables"))
+			return
+		}
+		if err == service.ErrScriptNotFound {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "script not found"))
+			return
+		}
+		if err == service.ErrScriptVersionNotFound {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "script version not found"))
+			return
+		}
+		if err == service.ErrInvalidSecret {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid secrets"))
+			return
+		}
 		if err == service.ErrNotFound {
 			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
 			return
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
@@ -13,10 +14,11 @@ import (
 
 type TaskRunHandler struct {
 	runner *service.TaskRunner
+	runs   *service.RunService
 }
 
-func NewTaskRunHandler(runner *service.TaskRunner) *TaskRunHandler {
-	return &TaskRunHandler{runner: runner}
+func NewTaskRunHandler(runner *service.TaskRunner, runs *service.RunService) *TaskRunHandler {
+	return &TaskRunHandler{runner: runner, runs: runs}
 }
 
 type runTaskRequest struct {
@@ -30,15 +32,145 @@ func (h *TaskRunHandler) Run(c *gin.Context) {
 		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
 		return
 	}
-	task, err := h.runner.Run(c.Request.Context(), id, req.TargetHost)
+	run, err := h.runner.Run(c.Request.Context(), id, service.RunOptions{
+		TargetHost:  req.TargetHost,
+		TriggeredBy: c.GetString("user_id"),
+	})
 	if err != nil {
 		if err == service.ErrNotFound {
 			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
 			return
 		}
+		if err == service.ErrUnsupportedEngine {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "unsupported engine"))
+			return
+		}
+		if err == service.ErrInvalidLoadProfile {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid load profile"))
+			return
+		}
+		if err == service.ErrInvalidVariables {
+			model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid variables"))
+			return
+		}
+		if err == service.ErrNoRunnerAvailable {
+			model.JSON(c, http.StatusServiceUnavailable, model.Fail(2001, "no runner available"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+
+	model.JSON(c, http.StatusOK, model.OK(run))
+}
+
+func (h *TaskRunHandler) ListByTask(c *gin.Context) {
+	page := parseIntDefault(c.Query("page"), 1)
+	pageSize := parseIntDefault(c.Query("page_size"), 20)
+	if page < 1 || pageSize < 1 || pageSize > 100 {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
+	}
+
+	offset := (page - 1) * pageSize
+	runs, err := h.runs.ListByTask(c.Request.Context(), c.Param("id"), pageSize, offset)
+	if err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+
+	model.JSON(c, http.StatusOK, model.OK(gin.H{
+		"items": runs,
+		"page":  page,
+		"size":  pageSize,
+	}))
+}
+
+func (h *TaskRunHandler) Get(c *gin.Context) {
+	run, err := h.runs.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(run))
+}
+
+func (h *TaskRunHandler) Queue(c *gin.Context) {
+	runs, err := h.runs.Queue(c.Request.Context())
+	if err != nil {
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(gin.H{"items": runs}))
+}
+
+func (h *TaskRunHandler) Cancel(c *gin.Context) {
+	run, err := h.runner.Cancel(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		if err == service.ErrRunNotQueued {
+			model.JSON(c, http.StatusConflict, model.Fail(2000, "run not queued"))
+			return
+		}
 		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
 		return
 	}
+	model.JSON(c, http.StatusOK, model.OK(run))
+}
+
+func (h *TaskRunHandler) Logs(c *gin.Context) {
+	offset, err := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	if err != nil || offset < 0 {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
+	}
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "0"), 10, 64)
+	if err != nil || limit < 0 || limit > 1<<20 {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
+	}
 
-	model.JSON(c, http.StatusOK, model.OK(task))
+	logs, err := h.runs.ReadLog(c.Request.Context(), c.Param("id"), c.Query("run_id"), offset, limit)
+	if err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(logs))
+}
+
+// Complete is the runner's callback for a finished asynchronous job.
+func (h *TaskRunHandler) Complete(c *gin.Context) {
+	var req service.RunnerJob
+	if err := c.ShouldBindJSON(&req); err != nil || req.RunID == "" {
+		model.JSON(c, http.StatusBadRequest, model.Fail(1000, "invalid params"))
+		return
+	}
+	if err := h.runner.CompleteRemote(c.Request.Context(), c.Param("id"), req); err != nil {
+		if err == service.ErrNotFound {
+			model.JSON(c, http.StatusNotFound, model.Fail(1003, "not found"))
+			return
+		}
+		if err == service.ErrRunNotActive {
+			model.JSON(c, http.StatusConflict, model.Fail(2000, "job not finished"))
+			return
+		}
+		model.JSON(c, http.StatusInternalServerError, model.Fail(9000, "internal error"))
+		return
+	}
+	model.JSON(c, http.StatusOK, model.OK(nil))
 }
@@ -8,7 +8,7 @@ import (
 	"bench-hub/internal/service"
 )
 
-func RegisterRoutes(router *gin.Engine, services *service.Services) {
+func RegisterRoutes(router *gin.Engine, services *service.Services, runnerToken string) {
 	router.GET("/health", handlers.Health)
 
 	v1 := router.Group("/api/v1")
@@ -20,13 +20,26 @@ func RegisterRoutes(router *gin.Engine, services *service.Services) {
 		scriptHandler := handlers.NewScriptHandler(services.Scripts)
 		taskHandler := handlers.NewTaskHandler(services.Tasks, services.Runner)
 		reportHandler := handlers.NewReportHandler(services.Reports)
-		taskRunHandler := handlers.NewTaskRunHandler(services.Runner)
+		taskRunHandler := handlers.NewTaskRunHandler(services.Runner, services.Runs)
 		dashboardHandler := handlers.NewDashboardHandler(services.Stats)
 		settingsHandler := handlers.NewSettingsHandler(services.Settings)
+		metricsHandler := handlers.NewMetricsHandler(services.Metrics)
+		compareHandler := handlers.NewCompareHandler(services.Compare)
+		liveHandler := handlers.NewLiveHandler(services.Live, services.Tasks)
+		runnerHandler := handlers.NewRunnerHandler(services.Pool)
+		scheduleHandler := handlers.NewScheduleHandler(services.Schedules)
+		secretHandler := handlers.NewSecretHandler(services.Secrets)
 
 		v1.POST("/auth/login", authHandler.Login)
 		v1.POST("/auth/refresh", authHandler.Refresh)
 
+		runnerAPI := v1.Group("/runner")
+		runnerAPI.Use(middleware.RunnerAuth(runnerToken))
+		runnerAPI.POST("/runs/:id/snapshots", liveHandler.PublishSnapshot)
+		runnerAPI.POST("/jobs/:id/complete", taskRunHandler.Complete)
+		runnerAPI.POST("/register", runnerHandler.Register)
+		runnerAPI.POST("/runners/:id/heartbeat", runnerHandler.Heartbeat)
+
 		protected := v1.Group("")
 		protected.Use(middleware.Auth(services.Auth))
 		protected.POST("/auth/logout", authHandler.Logout)
@@ -38,11 +51,21 @@ func RegisterRoutes(router *gin.Engine, services *service.Services) {
 		protected.DELETE("/users/:id", userHandler.Delete)
 
 		protected.GET("/scripts", scriptHandler.List)
+		protected.GET("/scripts/types", scriptHandler.Types)
 		protected.GET("/scripts/:id", scriptHandler.Get)
 		protected.POST("/scripts", scriptHandler.Create)
 		protected.PUT("/scripts/:id", scriptHandler.Update)
 		protected.DELETE("/scripts/:id", scriptHandler.Delete)
 		protected.POST("/scripts/import", scriptHandler.Import)
+		protected.POST("/scripts/validate", scriptHandler.Validate)
+		protected.POST("/scripts/generate", scriptHandler.Generate)
+		protected.GET("/scripts/:id/versions", scriptHandler.Versions)
+		protected.GET("/scripts/:id/versions/:version", scriptHandler.Version)
+		protected.POST("/scripts/:id/versions/:version/restore", scriptHandler.Restore)
+		protected.GET("/scripts/:id/diff", scriptHandler.Diff)
+		protected.POST("/scripts/:id/convert", scriptHandler.Convert)
+		protected.GET("/scripts/:id/bundle", scriptHandler.DownloadBundle)
+		protected.PUT("/scripts/:id/bundle", scriptHandler.UploadBundle)
 
 		protected.GET("/tasks", taskHandler.List)
 		protected.GET("/tasks/:id", taskHandler.Get)
@@ -50,6 +73,27 @@ func RegisterRoutes(router *gin.Engine, services *service.Services) {
 		protected.PUT("/tasks/:id", taskHandler.Update)
 		protected.POST("/tasks/:id/stop", taskHandler.Stop)
 		protected.POST("/tasks/:id/run", taskRunHandler.Run)
+		protected.GET("/tasks/:id/runs", taskRunHandler.ListByTask)
+		protected.GET("/tasks/:id/metrics", metricsHandler.ForTask)
+		protected.GET("/tasks/:id/live", liveHandler.Stream)
+		protected.GET("/tasks/:id/logs", taskRunHandler.Logs)
+		protected.GET("/runs/compare", compareHandler.Compare)
+		protected.GET("/runs/:id", taskRunHandler.Get)
+		protected.GET("/runs/:id/metrics", metricsHandler.ForRun)
+		protected.POST("/runs/:id/cancel", taskRunHandler.Cancel)
+		protected.GET("/queue", taskRunHandler.Queue)
+		protected.GET("/runners", runnerHandler.List)
+
+		protected.GET("/schedules", scheduleHandler.List)
+		protected.GET("/schedules/:id", scheduleHandler.Get)
+		protected.POST("/schedules", scheduleHandler.Create)
+		protected.PUT("/schedules/:id", scheduleHandler.Update)
+		protected.DELETE("/schedules/:id", scheduleHandler.Delete)
+
+		protected.GET("/secrets", secretHandler.List)
+		protected.POST("/secrets", secretHandler.Create)
+		protected.PUT("/secrets/:id", secretHandler.Update)
+		protected.DELETE("/secrets/:id", secretHandler.Delete)
 
 		protected.GET("/reports", reportHandler.List)
 		protected.GET("/reports/:id", reportHandler.Get)
@@ -60,5 +104,7 @@ func RegisterRoutes(router *gin.Engine, services *service.Services) {
 		protected.GET("/dashboard/summary", dashboardHandler.Summary)
 		protected.GET("/settings/p95-baseline", settingsHandler.GetP95)
 		protected.PUT("/settings/p95-baseline", settingsHandler.UpdateP95)
+		protected.GET("/settings/sla-rules", settingsHandler.GetSLARules)
+		protected.PUT("/settings/sla-rules", settingsHandler.UpdateSLARules)
 	}
 }
@@ -0,0 +1,227 @@
+// Package bundle reads multi-file script archives (zip, tar or tar.gz) and
+// unpacks them into a run directory.
+package bundle
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var ErrInvalid = errors.New("invalid script bundle")
+
+const (
+	// MaxArchiveBytes bounds the stored archive.
+	MaxArchiveBytes = 32 << 20
+	// maxExtractedBytes bounds the unpacked size so a small archive cannot
+	// fill the runner's disk.
+	maxExtractedBytes = 256 << 20
+	maxFiles          = 1000
+)
+
+var (
+	zipMagic  = []byte("PK\x03\x04")
+	gzipMagic = []byte{0x1f, 0x8b}
+)
+
+// Files lists the regular files in the archive in name order.
+func Files(data []byte) ([]string, error) {
+	var names []string
+	err := walk(data, func(name string, _ io.Reader) error {
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%w: archive has no files", ErrInvalid)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ReadFile returns the content of one file in the archive.
+func ReadFile(data []byte, name string) ([]byte, error) {
+	var content []byte
+	found := false
+	err := walk(data, func(entry string, r io.Reader) error {
+		if entry != name {
+			return nil
+		}
+		found = true
+		var err error
+		content, err = io.ReadAll(r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s not found", ErrInvalid, name)
+	}
+	return content, nil
+}
+
+// Ext returns the file extension matching the archive's format.
+func Ext(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, zipMagic):
+		return ".zip"
+	case bytes.HasPrefix(data, gzipMagic):
+		return ".tar.gz"
+	default:
+		return ".tar"
+	}
+}
+
+// Prepare unpacks data into dir and returns the path of entrypoint within
+// it. Without an archive it returns single, the engine's file name for a
+// single-file script, in dir, so callers write single-file scripts and
+// bundle entrypoints alike.
+func Prepare(dir string, data []byte, entrypoint, single string) (string, error) {
+	if len(data) == 0 {
+		return filepath.Join(dir, single), nil
+	}
+	if err := Extract(data, dir); err != nil {
+		return "", err
+	}
+	name, err := safeName(entrypoint)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.FromSlash(name)), nil
+}
+
+// Extract writes every regular file in the archive below dir.
+func Extract(data []byte, dir string) error {
+	var total int64
+	return walk(data, func(name string, r io.Reader) error {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		n, err := io.Copy(file, io.LimitReader(r, maxExtractedBytes-total+1))
+		total += n
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		if total > maxExtractedBytes {
+			return fmt.Errorf("%w: unpacked size exceeds %d bytes", ErrInvalid, maxExtractedBytes)
+		}
+		return nil
+	})
+}
+
+// walk calls fn for each regular file, rejecting links, unsafe paths and
+// oversized listings.
+func walk(data []byte, fn func(name string, r io.Reader) error) error {
+	if len(data) > MaxArchiveBytes {
+		return fmt.Errorf("%w: archive exceeds %d bytes", ErrInvalid, MaxArchiveBytes)
+	}
+	count := 0
+	visit := func(raw string, r io.Reader) error {
+		count++
+		if count > maxFiles {
+			return fmt.Errorf("%w: more than %d files", ErrInvalid, maxFiles)
+		}
+		name, err := safeName(raw)
+		if err != nil {
+			return err
+		}
+		return fn(name, r)
+	}
+
+	if bytes.HasPrefix(data, zipMagic) {
+		return walkZip(data, visit)
+	}
+	var r io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(data, gzipMagic) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return walkTar(r, visit)
+}
+
+func walkZip(data []byte, visit func(string, io.Reader) error) error {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	for _, file := range archive.File {
+		mode := file.Mode()
+		if mode.IsDir() {
+			continue
+		}
+		if !mode.IsRegular() {
+			return fmt.Errorf("%w: %s is not a regular file", ErrInvalid, file.Name)
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		err = visit(file.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkTar(r io.Reader, visit func(string, io.Reader) error) error {
+	archive := tar.NewReader(r)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir, tar.TypeXGlobalHeader:
+			continue
+		case tar.TypeReg:
+		default:
+			return fmt.Errorf("%w: %s is not a regular file", ErrInvalid, header.Name)
+		}
+		if err := visit(header.Name, archive); err != nil {
+			return err
+		}
+	}
+}
+
+// safeName normalizes an archive path and rejects ones that would escape the
+// extraction directory.
+func safeName(raw string) (string, error) {
+	name := strings.ReplaceAll(raw, `\`, "/")
+	if name == "" || path.IsAbs(name) || strings.Contains(name, ":") {
+		return "", fmt.Errorf("%w: unsafe path %q", ErrInvalid, raw)
+	}
+	name = path.Clean(name)
+	if name == "." || name == ".." || strings.HasPrefix(name, "../") {
+		return "", fmt.Errorf("%w: unsafe path %q", ErrInvalid, raw)
+	}
+	return name, nil
+}
@@ -0,0 +1,99 @@
+package bundle
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func tarGzArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	w := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := w.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	w.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestFiles(t *testing.T) {
+	files := map[string]string{
+		"plan/test.jmx":   "<jmeterTestPlan/>",
+		"plan/users.csv":  "alice,secret\n",
+		"plan/lib/x.json": "{}",
+	}
+	for name, data := range map[string][]byte{"zip": zipArchive(t, files), "tar.gz": tarGzArchive(t, files)} {
+		names, err := Files(data)
+		if err != nil {
+			t.Fatalf("%s: files: %v", name, err)
+		}
+		if want := []string{"plan/lib/x.json", "plan/test.jmx", "plan/users.csv"}; !reflect.DeepEqual(names, want) {
+			t.Fatalf("%s: got %v, want %v", name, names, want)
+		}
+		content, err := ReadFile(data, "plan/users.csv")
+		if err != nil || string(content) != "alice,secret\n" {
+			t.Fatalf("%s: read %q, %v", name, content, err)
+		}
+	}
+}
+
+func TestRejectsUnsafePaths(t *testing.T) {
+	for _, name := range []string{"../evil.py", "/etc/passwd", `..\evil.py`, "a/../../evil.py"} {
+		data := zipArchive(t, map[string]string{name: "x"})
+		if _, err := Files(data); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%q: expected ErrInvalid, got %v", name, err)
+		}
+	}
+	if _, err := Files([]byte("not an archive")); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected garbage to be rejected, got %v", err)
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	dir := t.TempDir()
+	data := zipArchive(t, map[string]string{"locustfile.py": "from helpers import x\n", "helpers.py": "x = 1\n"})
+	path, err := Prepare(dir, data, "locustfile.py", "locustfile.py")
+	if err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	if path != filepath.Join(dir, "locustfile.py") {
+		t.Fatalf("unexpected entrypoint path %s", path)
+	}
+	if content, err := os.ReadFile(filepath.Join(dir, "helpers.py")); err != nil || string(content) != "x = 1\n" {
+		t.Fatalf("helper not extracted: %q, %v", content, err)
+	}
+
+	path, err = Prepare(dir, nil, "", "test.jmx")
+	if err != nil || path != filepath.Join(dir, "test.jmx") {
+		t.Fatalf("single-file path %s, %v", path, err)
+	}
+}
@@ -20,10 +20,22 @@ type Config struct {
 	JWTIssuer          string
 	ReportsDir         string
 	LocustBin          string
+	JMeterBin          string
+	K6Bin              string
 	LocustHost         string
 	MigrationsPath     string
 	AutoMigrate        bool
 	RunnerURL          string
+	RunnerToken        string
+	SecretsKey         string
+	ReconcileInterval  time.Duration
+	RunnerHeartbeatTTL time.Duration
+	ScheduleInterval   time.Duration
+	DispatchInterval   time.Duration
+	MaxConcurrentRuns  int
+	MaxRunsPerHost     int
+	RunLogMaxBytes     int64
+	StopGrace          time.Duration
 }
 
 func Load() Config {
@@ -41,10 +53,22 @@ func Load() Config {
 		JWTIssuer:          getEnv("JWT_ISSUER", "bench-hub"),
 		ReportsDir:         getEnv("REPORTS_DIR", "reports"),
 		LocustBin:          getEnv("LOCUST_BIN", "locust"),
+		JMeterBin:          getEnv("JMETER_BIN", "jmeter"),
+		K6Bin:              getEnv("K6_BIN", "k6"),
 		LocustHost:         getEnv("LOCUST_HOST", "http://localhost:8080"),
 		MigrationsPath:     getEnv("MIGRATIONS_PATH", "migrations"),
 		AutoMigrate:        getEnvBool("AUTO_MIGRATE", false),
 		RunnerURL:          getEnv("RUNNER_URL", ""),
+		RunnerToken:        getEnv("RUNNER_TOKEN", ""),
+		SecretsKey:         getEnv("SECRETS_KEY", ""),
+		ReconcileInterval:  time.Duration(getEnvInt("RECONCILE_INTERVAL_SECONDS", 60)) * time.Second,
+		RunnerHeartbeatTTL: time.Duration(getEnvInt("RUNNER_HEARTBEAT_TIMEOUT_SECONDS", 30)) * time.Second,
+		ScheduleInterval:   time.Duration(getEnvInt("SCHEDULER_INTERVAL_SECONDS", 15)) * time.Second,
+		DispatchInterval:   time.Duration(getEnvInt("DISPATCH_INTERVAL_SECONDS", 5)) * time.Second,
+		MaxConcurrentRuns:  getEnvInt("MAX_CONCURRENT_RUNS", 4),
+		MaxRunsPerHost:     getEnvInt("MAX_RUNS_PER_TARGET_HOST", 1),
+		RunLogMaxBytes:     int64(getEnvInt("RUN_LOG_MAX_BYTES", 10<<20)),
+		StopGrace:          time.Duration(getEnvInt("STOP_GRACE_SECONDS", 30)) * time.Second,
 	}
 }
 
@@ -0,0 +1,416 @@
+// Package engine runs load tests. Each script type has an Engine that lays
+// out the run directory, runs the test and reads back what it produced;
+// Execute drives engines the same way for the embedded runner and
+// cmd/runner, so a new tool is one more Engine in the registry.
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/runlog"
+	"bench-hub/internal/secret"
+)
+
+var (
+	ErrUnknown = errors.New("unknown engine")
+	// ErrRequestsFailed reports a run whose engine completed but saw
+	// failed requests.
+	ErrRequestsFailed = errors.New("requests failed")
+)
+
+// Stop outcomes say how the engine of a stopped run ended.
+const (
+	// StopGraceful: the engine exited within the grace period.
+	StopGraceful = "graceful"
+	// StopKilled: the engine was killed after the grace period. Processes
+	// it leaves behind are killed whatever the outcome.
+	StopKilled = "killed"
+	// StopExited: the engine had already exited when the stop came.
+	StopExited = "already_exited"
+)
+
+// DefaultStopGrace is how long a stopped engine may take to flush its
+// results before it is killed.
+const DefaultStopGrace = 30 * time.Second
+
+// drainTimeout bounds the wait for the output of an engine that has exited.
+const drainTimeout = 5 * time.Second
+
+// Roles of a distributed Locust run.
+const (
+	RoleMaster = "master"
+	RoleWorker = "worker"
+)
+
+// Job is one run of a script, as the task and run describe it.
+type Job struct {
+	// Dir is the absolute run directory; reports are written below it.
+	Dir        string
+	TaskName   string
+	Script     string
+	Bundle     []byte
+	Entrypoint string
+	// TargetHost is the base URL the script is run against.
+	TargetHost string
+	Users      int
+	SpawnRate  int
+	Duration   int
+	JMeterTPM  *int
+	Stages     []model.LoadStage
+	Variables  map[string]string
+	// Secrets are injected like variables and redacted from the log and
+	// the engine's reports.
+	Secrets map[string]string
+
+	// Role, MasterHost, MasterPort and ExpectWorkers place a Locust job in
+	// a distributed run.
+	Role          string
+	MasterHost    string
+	MasterPort    int
+	ExpectWorkers int
+
+	LogMaxBytes int64
+	// StopGrace is how long an engine process may take to exit after
+	// SIGTERM before its process group is killed; zero means
+	// DefaultStopGrace.
+	StopGrace time.Duration
+
+	// stopOutcome is set by RunCommand when the run is stopped.
+	stopOutcome string
+}
+
+// Report is a file a run produced. File is relative to the job directory.
+type Report struct {
+	Name string
+	Type string
+	File string
+}
+
+// Engine runs one script type.
+type Engine interface {
+	// Name is the script type the engine runs.
+	Name() string
+	// Format describes the engine's scripts.
+	Format() Format
+	// Check validates a script without running it. The error is non-nil
+	// only when the check could not be done, e.g. the engine is missing.
+	Check(ctx context.Context, script *model.Script) ([]model.ScriptIssue, error)
+	// Prepare writes the script, and the bundle if any, into job.Dir and
+	// returns the path of the file to run.
+	Prepare(job *Job) (string, error)
+	// Run executes the script until it ends or ctx is cancelled, writing
+	// engine output to out.
+	Run(ctx context.Context, job *Job, script string, out io.Writer) error
+	// Reports lists the result files present after a run.
+	Reports(job *Job) []Report
+	// Failed judges a run that was not stopped, given the error Run
+	// returned.
+	Failed(job *Job, runErr error) bool
+	// Metrics parses the result files left in a run directory. Without
+	// any it returns results.ErrNoResults.
+	Metrics(dir string) ([]model.EndpointMetric, []model.MetricSample, error)
+	// Tail follows the result files of a run in progress in dir.
+	Tail(dir string) Tailer
+}
+
+// Tailer turns what a running engine has written so far into live
+// snapshots.
+type Tailer interface {
+	// Poll returns the latest snapshot since the previous call, or nil when
+	// nothing new has been written.
+	Poll() (*model.MetricSample, error)
+}
+
+// Registry holds the engines a process can run, by script type.
+type Registry struct {
+	engines map[string]Engine
+}
+
+func NewRegistry(engines ...Engine) *Registry {
+	r := &Registry{engines: make(map[string]Engine, len(engines))}
+	for _, e := range engines {
+		r.engines[e.Name()] = e
+	}
+	return r
+}
+
+// Get returns the engine for a script type; an empty type is Locust.
+func (r *Registry) Get(scriptType string) (Engine, error) {
+	if scriptType == "" {
+		scriptType = model.ScriptTypeLocust
+	}
+	if r != nil {
+		if e, ok := r.engines[scriptType]; ok {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknown, scriptType)
+}
+
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.engines))
+	for name := range r.engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Result is the outcome of Execute. Reports are named after the task and
+// their files are relative to the parent of the job directory, as report
+// rows record them.
+type Result struct {
+	Reports []Report
+	Stopped bool
+	// StopOutcome is set when Stopped.
+	StopOutcome string
+	Failed      bool
+	Err         error
+}
+
+// Execute prepares and runs a job with its engine, logging to run.log in
+// the job directory, and collects the reports. Cancelling ctx stops the
+// run; the engine still gets to write its results.
+func Execute(ctx context.Context, e Engine, job *Job) (*Result, error) {
+	if err := os.MkdirAll(job.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	script, err := e.Prepare(job)
+	if err != nil {
+		return nil, err
+	}
+
+	redactor := secret.NewRedactor(values(job.Secrets))
+	logFile, err := runlog.Create(filepath.Join(job.Dir, runlog.FileName), job.LogMaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	logWriter := redactor.Writer(logFile)
+	runCtx, watch := watchStop(ctx)
+	runErr := e.Run(runCtx, job, script, logWriter)
+	stopOutcome := watch.done(job)
+	if stopOutcome != "" {
+		fmt.Fprintf(logWriter, "run stopped: %s\n", stopOutcome)
+	} else if runErr != nil {
+		fmt.Fprintf(logWriter, "%s engine: %v\n", e.Name(), runErr)
+	}
+	_ = logWriter.Close()
+	if err := redactor.Files(reportFiles(e, job)...); err != nil {
+		log.Printf("redact reports in %s: %v", job.Dir, err)
+	}
+
+	result := &Result{Stopped: stopOutcome != "", StopOutcome: stopOutcome, Err: runErr}
+	result.Failed = !result.Stopped && e.Failed(job, runErr)
+	result.Reports = Collect(e, job)
+	return result, nil
+}
+
+// reportFiles lists the files of the engine's reports. A report kept in a
+// directory of its own, such as JMeter's dashboard, brings every file in it.
+func reportFiles(e Engine, job *Job) []string {
+	var files []string
+	for _, report := range e.Reports(job) {
+		file := filepath.Join(job.Dir, filepath.FromSlash(report.File))
+		if path.Dir(report.File) == "." {
+			files = append(files, file)
+			continue
+		}
+		_ = filepath.WalkDir(filepath.Dir(file), func(name string, entry fs.DirEntry, err error) error {
+			if err == nil && entry.Type().IsRegular() {
+				files = append(files, name)
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+// stopWatch passes a stop on to the engine only while it runs, so a stop
+// that comes after the engine returned is told apart from one it handled.
+type stopWatch struct {
+	ctx     context.Context
+	mu      sync.Mutex
+	running bool
+	stopped bool
+	cancel  context.CancelFunc
+	release func() bool
+}
+
+func watchStop(ctx context.Context) (context.Context, *stopWatch) {
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	w := &stopWatch{ctx: ctx, running: true, cancel: cancel}
+	w.release = context.AfterFunc(ctx, w.stop)
+	if ctx.Err() != nil {
+		w.stop()
+	}
+	return runCtx, w
+}
+
+func (w *stopWatch) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.running {
+		w.stopped = true
+		w.cancel()
+	}
+}
+
+// done marks the engine as returned and gives the stop outcome, or "" when
+// it was not stopped.
+func (w *stopWatch) done(job *Job) string {
+	w.mu.Lock()
+	w.running = false
+	stopped := w.stopped
+	w.mu.Unlock()
+	w.release()
+	w.cancel()
+
+	switch {
+	case stopped:
+		// In-process engines wind down on cancellation by themselves.
+		if job.stopOutcome != "" {
+			return job.stopOutcome
+		}
+		return StopGraceful
+	case w.ctx.Err() != nil:
+		return StopExited
+	}
+	return ""
+}
+
+// Collect lists the run log and the engine's reports present in the job
+// directory, named after the task and relative to the parent of the job
+// directory, as report rows record them. It also serves runs that never
+// finished cleanly; without an engine only the run log is listed.
+func Collect(e Engine, job *Job) []Report {
+	reports := Existing(job, Report{Name: runlog.FileName, Type: "log", File: runlog.FileName})
+	if e != nil {
+		reports = append(reports, e.Reports(job)...)
+	}
+	var out []Report
+	relativeDir := filepath.Base(job.Dir)
+	for _, report := range reports {
+		out = append(out, Report{
+			Name: fmt.Sprintf("%s-%s", job.TaskName, report.Name),
+			Type: report.Type,
+			File: filepath.Join(relativeDir, filepath.FromSlash(report.File)),
+		})
+	}
+	return out
+}
+
+// Existing keeps the reports whose file is present in the job directory.
+func Existing(job *Job, reports ...Report) []Report {
+	var present []Report
+	for _, report := range reports {
+		if _, err := os.Stat(filepath.Join(job.Dir, filepath.FromSlash(report.File))); err == nil {
+			present = append(present, report)
+		}
+	}
+	return present
+}
+
+// RunCommand runs an engine process in its own process group with its
+// output going to out. When ctx is cancelled the whole group, including the
+// children of wrapper scripts such as jmeter's, gets SIGTERM so the engine
+// can flush its results; the engine is killed if it is still running after
+// the job's grace period. Processes it leaves behind in the group are killed
+// once it exited and noted in out. The outcome is recorded for Execute.
+func RunCommand(ctx context.Context, job *Job, cmd *exec.Cmd, out io.Writer) error {
+	// The output goes through a pipe of our own so Wait returns when the
+	// engine exits, not when the last process holding the output does.
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	err = cmd.Start()
+	writer.Close()
+	if err != nil {
+		reader.Close()
+		return err
+	}
+	drained := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(out, reader)
+		reader.Close()
+		close(drained)
+	}()
+	grace := job.StopGrace
+	if grace <= 0 {
+		grace = DefaultStopGrace
+	}
+
+	pgid := cmd.Process.Pid
+	exited := make(chan struct{})
+	outcome := make(chan string, 1)
+	go func() {
+		select {
+		case <-exited:
+			outcome <- ""
+		case <-ctx.Done():
+			outcome <- stopGroup(pgid, exited, grace)
+		}
+	}()
+	err = cmd.Wait()
+	close(exited)
+	job.stopOutcome = <-outcome
+	// Processes left behind in the group would keep the output open and
+	// outlive the run; they go with the engine.
+	leftover := job.stopOutcome != StopKilled && syscall.Kill(-pgid, syscall.SIGKILL) == nil
+	select {
+	case <-drained:
+	case <-time.After(drainTimeout):
+		// Something outside the group still holds the output.
+		reader.Close()
+		<-drained
+	}
+	if leftover {
+		fmt.Fprintln(out, "killed processes the engine left behind")
+	}
+	if job.stopOutcome == "" && ctx.Err() != nil {
+		job.stopOutcome = StopExited
+	}
+	return err
+}
+
+// stopGroup sends SIGTERM to a process group and SIGKILL once the grace
+// period is over, and reports how the group leader ended.
+func stopGroup(pgid int, exited <-chan struct{}, grace time.Duration) string {
+	if err := syscall.Kill(-pgid, syscall.SIGTERM); err != nil {
+		return StopExited
+	}
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-exited:
+		return StopGraceful
+	case <-timer.C:
+		_ = syscall.Kill(-pgid, syscall.SIGKILL)
+		return StopKilled
+	}
+}
+
+func values(m map[string]string) []string {
+	out := make([]string, 0, len(m))
+	for _, value := range m {
+		out = append(out, value)
+	}
+	return out
+}
@@ -0,0 +1,260 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bench-hub/internal/bundle"
+	"bench-hub/internal/model"
+)
+
+type fakeEngine struct {
+	name   string
+	format Format
+	runErr error
+	wait   bool
+}
+
+func (f *fakeEngine) Name() string {
+	if f.name == "" {
+		return "fake"
+	}
+	return f.name
+}
+
+func (f *fakeEngine) Format() Format { return f.format }
+
+func (f *fakeEngine) Check(ctx context.Context, script *model.Script) ([]model.ScriptIssue, error) {
+	return nil, nil
+}
+
+func (f *fakeEngine) Prepare(job *Job) (string, error) {
+	path := filepath.Join(job.Dir, "script.txt")
+	return path, os.WriteFile(path, []byte(job.Script), 0o644)
+}
+
+func (f *fakeEngine) Run(ctx context.Context, job *Job, script string, out io.Writer) error {
+	fmt.Fprintf(out, "token=%s\n", job.Secrets["TOKEN"])
+	if f.wait {
+		<-ctx.Done()
+	}
+	return os.WriteFile(filepath.Join(job.Dir, "result.csv"), []byte("token,"+job.Secrets["TOKEN"]+"\n"), 0o644)
+}
+
+func (f *fakeEngine) Reports(job *Job) []Report {
+	return Existing(job,
+		Report{Name: "result.csv", Type: "csv", File: "result.csv"},
+		Report{Name: "report.html", Type: "html", File: "report.html"},
+	)
+}
+
+func (f *fakeEngine) Failed(job *Job, runErr error) bool { return runErr != nil || f.runErr != nil }
+
+func (f *fakeEngine) Metrics(dir string) ([]model.EndpointMetric, []model.MetricSample, error) {
+	return nil, nil, nil
+}
+
+func (f *fakeEngine) Tail(dir string) Tailer { return nil }
+
+func TestExecute(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "task_1")
+	job := &Job{Dir: dir, TaskName: "smoke", Script: "run", Secrets: map[string]string{"TOKEN": "s3cret-value"}}
+
+	result, err := Execute(context.Background(), &fakeEngine{}, job)
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if result.Stopped || result.Failed || result.Err != nil {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	want := []Report{
+		{Name: "smoke-run.log", Type: "log", File: filepath.Join("task_1", "run.log")},
+		{Name: "smoke-result.csv", Type: "csv", File: filepath.Join("task_1", "result.csv")},
+	}
+	if fmt.Sprint(result.Reports) != fmt.Sprint(want) {
+		t.Fatalf("reports = %+v, want %+v", result.Reports, want)
+	}
+	for _, name := range []string{"run.log", "result.csv"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if strings.Contains(string(data), "s3cret-value") {
+			t.Fatalf("%s leaks the secret: %q", name, data)
+		}
+	}
+
+	result, err = Execute(context.Background(), &fakeEngine{runErr: errors.New("boom")}, job)
+	if err != nil || !result.Failed {
+		t.Fatalf("expected a failed run, got %+v, %v", result, err)
+	}
+}
+
+func TestExecuteStopped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	result, err := Execute(ctx, &fakeEngine{wait: true, runErr: errors.New("ignored")}, &Job{Dir: t.TempDir(), TaskName: "smoke"})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if !result.Stopped || result.Failed {
+		t.Fatalf("expected a stopped run that is not failed, got %+v", result)
+	}
+}
+
+func TestRunCommandStops(t *testing.T) {
+	cases := []struct {
+		name     string
+		script   string
+		outcome  string
+		leftover bool
+	}{
+		{"graceful", "trap 'echo flushed; exit 0' TERM; while :; do sleep 0.01; done", StopGraceful, false},
+		{"ignores SIGTERM", "trap '' TERM; while :; do sleep 0.01; done", StopKilled, false},
+		// The leader exits on SIGTERM but leaves a child behind that
+		// ignores it, as a wrapper script can. The child is killed.
+		{"leaves a child", "sh -c \"trap '' TERM; while :; do sleep 0.01; done\" & trap 'exit 0' TERM; while :; do sleep 0.01; done", StopGraceful, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(100*time.Millisecond, cancel)
+			var out strings.Builder
+			job := &Job{StopGrace: 300 * time.Millisecond}
+			start := time.Now()
+			_ = RunCommand(ctx, job, exec.Command("sh", "-c", tc.script), &out)
+			if job.stopOutcome != tc.outcome {
+				t.Fatalf("outcome = %q, want %q (output %q)", job.stopOutcome, tc.outcome, out.String())
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("stop took %s", elapsed)
+			}
+			if strings.Contains(tc.script, "echo flushed") && !strings.Contains(out.String(), "flushed") {
+				t.Fatalf("expected the process to handle SIGTERM, got %q", out.String())
+			}
+			if left := strings.Contains(out.String(), "left behind"); left != tc.leftover {
+				t.Fatalf("unexpected note on leftover processes in %q", out.String())
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &Job{}
+	if err := RunCommand(ctx, job, exec.Command("true"), io.Discard); err != nil || job.stopOutcome != "" {
+		t.Fatalf("expected a plain exit, got %v %q", err, job.stopOutcome)
+	}
+
+	// A child still holding the output does not keep a finished run open.
+	var out strings.Builder
+	start := time.Now()
+	if err := RunCommand(ctx, job, exec.Command("sh", "-c", "sleep 20 & echo done"), &out); err != nil || job.stopOutcome != "" {
+		t.Fatalf("expected a plain exit, got %v %q", err, job.stopOutcome)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("run took %s", elapsed)
+	}
+	if !strings.Contains(out.String(), "done") || !strings.Contains(out.String(), "left behind") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+	cancel()
+}
+
+func TestExecuteRecordsStopOutcome(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := Execute(ctx, &fakeEngine{}, &Job{Dir: t.TempDir(), TaskName: "smoke"})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if !result.Stopped || result.StopOutcome != StopGraceful {
+		t.Fatalf("expected an in-process engine to stop gracefully, got %+v", result)
+	}
+}
+
+func TestStopWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runCtx, watch := watchStop(ctx)
+	cancel()
+	select {
+	case <-runCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected the stop to reach the engine")
+	}
+	if outcome := watch.done(&Job{}); outcome != StopGraceful {
+		t.Fatalf("outcome = %q, want %q", outcome, StopGraceful)
+	}
+
+	// The engine returned on its own before the stop came.
+	ctx, cancel = context.WithCancel(context.Background())
+	runCtx, watch = watchStop(ctx)
+	if outcome := watch.done(&Job{}); outcome != "" {
+		t.Fatalf("expected no stop, got %q", outcome)
+	}
+	cancel()
+	if outcome := watch.done(&Job{}); outcome != StopExited {
+		t.Fatalf("outcome = %q, want %q", outcome, StopExited)
+	}
+	if runCtx.Err() != context.Canceled {
+		t.Fatalf("expected the run context to be released, got %v", runCtx.Err())
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry(&fakeEngine{})
+	if _, err := registry.Get("fake"); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if _, err := registry.Get(""); !errors.Is(err, ErrUnknown) {
+		t.Fatalf("expected an empty type to mean locust, got %v", err)
+	}
+	if names := registry.Names(); len(names) != 1 || names[0] != "fake" {
+		t.Fatalf("names = %v", names)
+	}
+}
+
+func TestRegistryEntrypoint(t *testing.T) {
+	registry := NewRegistry(
+		&fakeEngine{name: "plan", format: Format{Entrypoint: "test.jmx", Extensions: []string{".jmx"}}},
+		&fakeEngine{name: "py", format: Format{Entrypoint: "locustfile.py", Extensions: []string{".py"}}},
+	)
+	if typ := registry.TypeOf("dir/Smoke.JMX"); typ != "plan" {
+		t.Fatalf("TypeOf = %q, want plan", typ)
+	}
+
+	files := []string{"plan/lib/x.json", "plan/smoke.jmx", "plan/users.csv", "tests/locustfile.py", "tests/helpers.py"}
+	cases := []struct {
+		requested, scriptType, want string
+	}{
+		// The single plan, though not named after the default.
+		{"", "plan", "plan/smoke.jmx"},
+		// The default name wins over the other Python files.
+		{"", "py", "tests/locustfile.py"},
+		// Without a type only default names are looked for.
+		{"", "", "tests/locustfile.py"},
+		{"./plan/smoke.jmx", "", "plan/smoke.jmx"},
+		// Not a script, of another type, missing.
+		{"plan/users.csv", "", ""},
+		{"plan/smoke.jmx", "py", ""},
+		{"plan/other.jmx", "", ""},
+	}
+	for _, tc := range cases {
+		got, err := registry.Entrypoint(files, tc.requested, tc.scriptType)
+		if tc.want == "" {
+			if !errors.Is(err, bundle.ErrInvalid) {
+				t.Errorf("Entrypoint(%q, %q): expected ErrInvalid, got %q %v", tc.requested, tc.scriptType, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("Entrypoint(%q, %q) = %q %v, want %q", tc.requested, tc.scriptType, got, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"bench-hub/internal/bundle"
+	"bench-hub/internal/model"
+)
+
+// Format describes the scripts an engine runs.
+type Format struct {
+	// Title names the script type in the UI.
+	Title string
+	// Entrypoint is the file name a single-file script is written as, and
+	// the name looked for first when a bundle does not name its entrypoint.
+	Entrypoint string
+	// Extensions are the lower-case extensions, dot included, of the files
+	// the engine runs.
+	Extensions []string
+	// RunnerCheck says Check runs the engine itself. A healthy runner, whose
+	// environment the script will run in, then checks it when there is one.
+	RunnerCheck bool
+}
+
+// Runs reports whether name is a file the engine runs, judged by its
+// extension.
+func (f Format) Runs(name string) bool {
+	return slices.Contains(f.Extensions, strings.ToLower(path.Ext(name)))
+}
+
+// Formats lists the script types for clients, in name order.
+func (r *Registry) Formats() []model.ScriptFormat {
+	formats := make([]model.ScriptFormat, 0, len(r.engines))
+	for _, name := range r.Names() {
+		format := r.engines[name].Format()
+		formats = append(formats, model.ScriptFormat{
+			Type:       name,
+			Title:      format.Title,
+			Entrypoint: format.Entrypoint,
+			Extensions: format.Extensions,
+		})
+	}
+	return formats
+}
+
+// TypeOf returns the script type a file runs with, judged by its extension,
+// or "" when no engine runs it.
+func (r *Registry) TypeOf(name string) string {
+	for _, typ := range r.Names() {
+		if r.engines[typ].Format().Runs(name) {
+			return typ
+		}
+	}
+	return ""
+}
+
+// Entrypoint checks the requested entrypoint against a bundle's listing. An
+// empty request picks the file named after the type's default entrypoint,
+// else the single file the engine runs. Without a type the default
+// entrypoints of every engine are looked for.
+func (r *Registry) Entrypoint(files []string, requested, scriptType string) (string, error) {
+	if requested != "" {
+		requested = strings.TrimPrefix(path.Clean(requested), "./")
+		typ := r.TypeOf(requested)
+		if typ == "" || (scriptType != "" && typ != scriptType) {
+			return "", fmt.Errorf("%w: entrypoint %s does not match the script type", bundle.ErrInvalid, requested)
+		}
+		if !slices.Contains(files, requested) {
+			return "", fmt.Errorf("%w: entrypoint %s not in archive", bundle.ErrInvalid, requested)
+		}
+		return requested, nil
+	}
+
+	types := r.Names()
+	if scriptType != "" {
+		types = []string{scriptType}
+	}
+	var named, runnable []string
+	for _, typ := range types {
+		e, ok := r.engines[typ]
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrUnknown, typ)
+		}
+		format := e.Format()
+		for _, name := range files {
+			switch {
+			case path.Base(name) == format.Entrypoint:
+				named = append(named, name)
+			case format.Runs(name):
+				runnable = append(runnable, name)
+			}
+		}
+	}
+	candidates := named
+	if len(candidates) == 0 && scriptType != "" {
+		candidates = runnable
+	}
+	if len(candidates) != 1 {
+		return "", fmt.Errorf("%w: cannot pick an entrypoint, name one explicitly", bundle.ErrInvalid)
+	}
+	return candidates[0], nil
+}
@@ -0,0 +1,108 @@
+package jmeter
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/scriptparams"
+)
+
+// propertyPattern matches ${__P(name)} and ${__P(name,default)}.
+var propertyPattern = regexp.MustCompile(`\$\{__P\(([^,()]*)(,[^)]*)?\)`)
+
+// Check parses a JMeter plan and checks that it has an enabled thread group
+// and reads only properties the platform sets or the script declares.
+// Unknown properties without a default are errors; with a default they only
+// warn, since the default is always used.
+func Check(content string, params []model.ScriptParameter) []model.ScriptIssue {
+	declared := make(map[string]bool, len(params))
+	for _, param := range params {
+		declared[param.Name] = true
+	}
+
+	var issues []model.ScriptIssue
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	root := ""
+	threadGroups := 0
+	for {
+		line, _ := decoder.InputPos()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var syntax *xml.SyntaxError
+			if errors.As(err, &syntax) {
+				return append(issues, model.ScriptIssue{Severity: model.IssueError, Line: syntax.Line, Message: "invalid XML: " + syntax.Msg})
+			}
+			return append(issues, model.ScriptIssue{Severity: model.IssueError, Line: line, Message: "invalid XML: " + err.Error()})
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if root == "" {
+				root = t.Name.Local
+				if root != "jmeterTestPlan" {
+					issues = append(issues, model.ScriptIssue{Severity: model.IssueError, Line: line, Message: fmt.Sprintf("root element is <%s>, expected <jmeterTestPlan>", root)})
+				}
+			}
+			if isThreadGroup(t) && attr(t, "enabled") != "false" {
+				threadGroups++
+			}
+			for _, a := range t.Attr {
+				issues = append(issues, checkProperties(a.Value, line, declared)...)
+			}
+		case xml.CharData:
+			issues = append(issues, checkProperties(string(t), line, declared)...)
+		}
+	}
+
+	if root == "" {
+		return append(issues, model.ScriptIssue{Severity: model.IssueError, Message: "empty document"})
+	}
+	if threadGroups == 0 {
+		issues = append(issues, model.ScriptIssue{Severity: model.IssueError, Message: "the plan has no enabled thread group"})
+	}
+	return issues
+}
+
+// isThreadGroup recognizes the stock thread groups as well as plugin ones
+// such as kg.apc.jmeter.threads.UltimateThreadGroup.
+func isThreadGroup(el xml.StartElement) bool {
+	return strings.HasSuffix(el.Name.Local, "ThreadGroup") || strings.HasSuffix(attr(el, "testclass"), "ThreadGroup")
+}
+
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func checkProperties(text string, line int, declared map[string]bool) []model.ScriptIssue {
+	var issues []model.ScriptIssue
+	for _, match := range propertyPattern.FindAllStringSubmatchIndex(text, -1) {
+		name := strings.TrimSpace(text[match[2]:match[3]])
+		if name == "" || strings.Contains(name, "$") || declared[name] || scriptparams.Reserved(name) {
+			continue
+		}
+		issue := model.ScriptIssue{
+			Severity: model.IssueError,
+			Line:     line + strings.Count(text[:match[0]], "\n"),
+			Message:  fmt.Sprintf("property %q is neither a script parameter nor set by the platform", name),
+		}
+		if match[4] >= 0 {
+			issue.Severity = model.IssueWarning
+			issue.Message += "; its default is always used"
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
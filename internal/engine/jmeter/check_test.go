@@ -0,0 +1,50 @@
+package jmeter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/scriptcheck"
+)
+
+func TestCheck(t *testing.T) {
+	template, err := os.ReadFile(filepath.Join("..", "..", "..", "locust", "jmeter-template.jmx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := scriptcheck.Result(Check(string(template), []model.ScriptParameter{{Name: "delay_ms"}})); !result.Valid || len(result.Issues) != 0 {
+		t.Fatalf("template should validate cleanly, got %+v", result.Issues)
+	}
+
+	plan := `<?xml version="1.0"?>
+<jmeterTestPlan>
+  <hashTree>
+    <ThreadGroup testclass="ThreadGroup" enabled="false"/>
+    <stringProp name="x">${__P(threads,1)}</stringProp>
+    <stringProp name="y">
+      ${__P(api_key)}</stringProp>
+    <stringProp name="z">${__P(delay_ms,100)}</stringProp>
+  </hashTree>
+</jmeterTestPlan>`
+	issues := Check(plan, nil)
+	if len(issues) != 3 {
+		t.Fatalf("expected 3 issues, got %+v", issues)
+	}
+	if issues[0].Line != 7 || issues[0].Severity != model.IssueError || !strings.Contains(issues[0].Message, "api_key") {
+		t.Fatalf("unexpected property issue %+v", issues[0])
+	}
+	if issues[1].Line != 8 || issues[1].Severity != model.IssueWarning {
+		t.Fatalf("unexpected defaulted property issue %+v", issues[1])
+	}
+	if issues[2].Line != 0 || !strings.Contains(issues[2].Message, "thread group") {
+		t.Fatalf("expected missing thread group, got %+v", issues[2])
+	}
+
+	issues = Check("<jmeterTestPlan>\n<hashTree>\n</jmeterTestPlan>", nil)
+	if len(issues) != 1 || issues[0].Line != 3 {
+		t.Fatalf("expected syntax error on line 3, got %+v", issues)
+	}
+}
@@ -0,0 +1,165 @@
+// Package jmeter runs JMeter plans in non-GUI mode with a JTL result file
+// and the HTML dashboard. The platform passes the target, duration, load
+// shape and script parameters as -J properties.
+package jmeter
+
+import (
+	"context"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"bench-hub/internal/bundle"
+	"bench-hub/internal/engine"
+	"bench-hub/internal/model"
+	"bench-hub/internal/scriptparams"
+)
+
+const (
+	// scriptFile is the name a single-file plan is written as.
+	scriptFile = "test.jmx"
+	htmlDir    = "html-report"
+)
+
+type Engine struct {
+	bin string
+}
+
+func New(bin string) *Engine {
+	return &Engine{bin: bin}
+}
+
+func (e *Engine) Name() string { return model.ScriptTypeJMeter }
+
+func (e *Engine) Format() engine.Format {
+	return engine.Format{Title: "JMeter", Entrypoint: scriptFile, Extensions: []string{".jmx"}}
+}
+
+func (e *Engine) Check(ctx context.Context, script *model.Script) ([]model.ScriptIssue, error) {
+	return Check(script.Content, script.Parameters), nil
+}
+
+func (e *Engine) Prepare(job *engine.Job) (string, error) {
+	path, err := bundle.Prepare(job.Dir, job.Bundle, job.Entrypoint, scriptFile)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(job.Script), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func (e *Engine) Run(ctx context.Context, job *engine.Job, script string, out io.Writer) error {
+	cmd := exec.Command(e.bin, e.commandLine(job, script)...)
+	cmd.Env = append(os.Environ(), scriptparams.Env(job.Secrets)...)
+	// Relative paths in the plan, e.g. CSVDataSet files, resolve against
+	// the entrypoint's directory.
+	cmd.Dir = filepath.Dir(script)
+	return engine.RunCommand(ctx, job, cmd, out)
+}
+
+func (e *Engine) commandLine(job *engine.Job, script string) []string {
+	host, port, protocol := parseTargetHost(job.TargetHost)
+	args := []string{
+		"-n", "-t", script,
+		"-l", filepath.Join(job.Dir, ResultsFile),
+		"-e", "-o", filepath.Join(job.Dir, htmlDir),
+		"-Jtarget_host=" + host,
+		"-Jtarget_port=" + port,
+		"-Jtarget_protocol=" + protocol,
+		"-Jduration=" + strconv.Itoa(job.Duration),
+	}
+	if job.JMeterTPM != nil && *job.JMeterTPM > 0 {
+		args = append(args, "-Jtpm="+strconv.Itoa(*job.JMeterTPM))
+	}
+	for _, prop := range Properties(job.Stages) {
+		args = append(args, "-J"+prop)
+	}
+	return append(args, scriptparams.JMeterArgs(job.Variables)...)
+}
+
+func (e *Engine) Reports(job *engine.Job) []engine.Report {
+	return engine.Existing(job,
+		engine.Report{Name: "jmeter-report.html", Type: "html", File: htmlDir + "/index.html"},
+		engine.Report{Name: ResultsFile, Type: "jtl", File: ResultsFile},
+	)
+}
+
+// Failed reads the JTL: JMeter exits 0 even when samples fail. Without a
+// readable JTL the exit status decides.
+func (e *Engine) Failed(job *engine.Job, runErr error) bool {
+	failed, err := hasFailures(filepath.Join(job.Dir, ResultsFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("jmeter jtl parse error: %v", err)
+		}
+		return runErr != nil
+	}
+	return failed
+}
+
+func (e *Engine) Metrics(dir string) ([]model.EndpointMetric, []model.MetricSample, error) {
+	return Metrics(dir)
+}
+
+func (e *Engine) Tail(dir string) engine.Tailer {
+	return Tail(dir)
+}
+
+// parseTargetHost splits a target URL into the host, port and protocol the
+// plan reads, defaulting the port from the protocol.
+func parseTargetHost(input string) (string, string, string) {
+	target := strings.TrimSpace(input)
+
+	protocol := "http"
+	hostPort := target
+
+	if strings.Contains(target, "://") {
+		if parsed, err := url.Parse(target); err == nil {
+			if parsed.Scheme != "" {
+				protocol = parsed.Scheme
+			}
+			if parsed.Host != "" {
+				hostPort = parsed.Host
+			} else if parsed.Path != "" {
+				hostPort = parsed.Path
+			}
+		}
+	}
+
+	if strings.Contains(hostPort, "/") {
+		hostPort = strings.Split(hostPort, "/")[0]
+	}
+
+	host := hostPort
+	port := ""
+	if h, p, err := net.SplitHostPort(hostPort); err == nil {
+		host = h
+		port = p
+	}
+
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		host = strings.TrimPrefix(strings.TrimSuffix(host, "]"), "[")
+	}
+
+	if port == "" {
+		if protocol == "https" {
+			port = "443"
+		} else {
+			port = "80"
+		}
+	}
+
+	if _, err := strconv.Atoi(port); err != nil {
+		port = "80"
+	}
+
+	return host, port, protocol
+}
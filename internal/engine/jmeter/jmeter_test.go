@@ -0,0 +1,115 @@
+package jmeter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bench-hub/internal/engine"
+)
+
+func TestParseTargetHost(t *testing.T) {
+	cases := map[string][3]string{
+		"https://api.example.com/v1": {"api.example.com", "443", "https"},
+		"http://[::1]:8080":          {"::1", "8080", "http"},
+		"example.com:9000":           {"example.com", "9000", "http"},
+		"example.com":                {"example.com", "80", "http"},
+	}
+	for input, want := range cases {
+		host, port, protocol := parseTargetHost(input)
+		if [3]string{host, port, protocol} != want {
+			t.Fatalf("%s = %s %s %s, want %v", input, host, port, protocol, want)
+		}
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	tpm := 120
+	job := &engine.Job{
+		Dir:        "/runs/task_1",
+		TargetHost: "https://api.example.com",
+		Duration:   60,
+		JMeterTPM:  &tpm,
+		Variables:  map[string]string{"user": "alice"},
+	}
+	got := strings.Join(New("jmeter").commandLine(job, "/runs/task_1/test.jmx"), " ")
+	want := "-n -t /runs/task_1/test.jmx -l /runs/task_1/results.jtl -e -o /runs/task_1/html-report " +
+		"-Jtarget_host=api.example.com -Jtarget_port=443 -Jtarget_protocol=https -Jduration=60 -Jtpm=120 -Juser=alice"
+	if got != want {
+		t.Fatalf("command line\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestFailed(t *testing.T) {
+	dir := t.TempDir()
+	job := &engine.Job{Dir: dir}
+	e := New("jmeter")
+	if !e.Failed(job, errors.New("exit status 1")) || e.Failed(job, nil) {
+		t.Fatal("without a JTL the exit status decides")
+	}
+
+	jtl := "timeStamp,elapsed,label,success\n1,10,ping,true\n2,12,ping,false\n"
+	if err := os.WriteFile(filepath.Join(dir, "results.jtl"), []byte(jtl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !e.Failed(job, nil) {
+		t.Fatal("expected a failed sample to fail the run")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "results.jtl"), []byte(strings.ReplaceAll(jtl, "false", "true")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if e.Failed(job, errors.New("exit status 1")) {
+		t.Fatal("expected passing samples to pass the run")
+	}
+}
+
+// TestExecute runs a stand-in jmeter that records its arguments and writes
+// the outputs the real one would.
+func TestExecute(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "jmeter")
+	script := `#!/bin/sh
+echo "$@" > "$RECORD"
+while [ "$1" != "-l" ]; do shift; done
+dir=$(dirname "$2")
+printf 'timeStamp,elapsed,label,success\n1700000000000,10,ping,true\n1700000000100,12,ping,false\n' > "$2"
+mkdir -p "$dir/html-report" && echo '<html></html>' > "$dir/html-report/index.html"
+`
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	record := filepath.Join(t.TempDir(), "args")
+	t.Setenv("RECORD", record)
+
+	root := t.TempDir()
+	job := &engine.Job{
+		Dir:        filepath.Join(root, "task_1"),
+		TaskName:   "smoke",
+		Script:     "<jmeterTestPlan/>",
+		TargetHost: "http://api:8080",
+		Duration:   30,
+	}
+	result, err := engine.Execute(context.Background(), New(bin), job)
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if !result.Failed || result.Stopped {
+		t.Fatalf("expected the failed sample to fail the run, got %+v", result)
+	}
+	var names []string
+	for _, report := range result.Reports {
+		names = append(names, report.Name)
+	}
+	if got := strings.Join(names, ","); got != "smoke-run.log,smoke-jmeter-report.html,smoke-results.jtl" {
+		t.Fatalf("reports = %s", got)
+	}
+	args, err := os.ReadFile(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(args), "-Jtarget_host=api -Jtarget_port=8080 -Jtarget_protocol=http -Jduration=30") {
+		t.Fatalf("unexpected arguments %s", args)
+	}
+}
@@ -0,0 +1,128 @@
+package jmeter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"bench-hub/internal/engine"
+	"bench-hub/internal/model"
+	"bench-hub/internal/results"
+)
+
+// ResultsFile is the CSV JTL the plan's samples are written to.
+const ResultsFile = "results.jtl"
+
+// Metrics aggregates the JTL in dir into endpoint metrics and a timeline.
+func Metrics(dir string) ([]model.EndpointMetric, []model.MetricSample, error) {
+	path := filepath.Join(dir, ResultsFile)
+	metrics, err := results.ParseFile(path, ParseJTL)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, results.ErrNoResults
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	samples, err := results.ParseFile(path, ParseJTLHistory)
+	if err != nil {
+		return nil, nil, err
+	}
+	return metrics, samples, nil
+}
+
+func readJTL(r io.Reader, visit func(results.Sample)) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	table, err := results.ReadHeader(reader, "timeStamp", "elapsed", "label", "success")
+	if err != nil {
+		return err
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		visit(sample(table, record))
+	}
+}
+
+func sample(table results.Table, record []string) results.Sample {
+	return results.Sample{
+		Timestamp: table.Int(record, "timeStamp"),
+		ElapsedMs: table.Float(record, "elapsed"),
+		Method:    "HTTP",
+		Label:     table.Str(record, "label"),
+		Success:   !strings.EqualFold(table.Str(record, "success"), "false"),
+		Users:     int(table.Int(record, "allThreads")),
+	}
+}
+
+// ParseJTL aggregates a CSV JTL file per sampler label, adding an
+// "Aggregated" entry shaped like the Locust total row.
+func ParseJTL(r io.Reader) ([]model.EndpointMetric, error) {
+	return results.Aggregate(func(visit func(results.Sample)) error { return readJTL(r, visit) })
+}
+
+// ParseJTLHistory buckets JTL samples per second into a timeline comparable
+// to Locust's stats history.
+func ParseJTLHistory(r io.Reader) ([]model.MetricSample, error) {
+	return results.Timeline(func(visit func(results.Sample)) error { return readJTL(r, visit) })
+}
+
+// hasFailures reports whether any sample in the JTL at path failed.
+func hasFailures(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	failed := false
+	err = readJTL(file, func(sample results.Sample) {
+		failed = failed || !sample.Success
+	})
+	return failed, err
+}
+
+// Tail follows the JTL as JMeter appends samples to it.
+func Tail(dir string) engine.Tailer {
+	return &tailer{lines: results.NewLines(filepath.Join(dir, ResultsFile)), window: results.NewWindow()}
+}
+
+type tailer struct {
+	lines  *results.Lines
+	header *results.Table
+	window *results.Window
+}
+
+func (t *tailer) Poll() (*model.MetricSample, error) {
+	lines, err := t.lines.Next()
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		reader := csv.NewReader(bytes.NewReader(line))
+		reader.FieldsPerRecord = -1
+		record, err := reader.Read()
+		if err != nil {
+			continue
+		}
+		if t.header == nil {
+			table := results.NewTable(record)
+			t.header = &table
+			continue
+		}
+		s := sample(*t.header, record)
+		t.window.Add(s.ElapsedMs, s.Success, s.Users)
+	}
+	return t.window.Flush(), nil
+}
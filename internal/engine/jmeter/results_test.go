@@ -0,0 +1,56 @@
+package jmeter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bench-hub/internal/results"
+)
+
+const jtl = `timeStamp,elapsed,label,responseCode,responseMessage,threadName,dataType,success,failureMessage,bytes,sentBytes,grpThreads,allThreads,URL,Latency,IdleTime,Connect
+1700000000000,10,ping,200,OK,users 1-1,text,true,,10,10,1,1,http://api/ping,9,0,1
+1700000000500,30,ping,500,Error,users 1-1,text,false,,10,10,1,1,http://api/ping,29,0,1
+1700000001000,20,login,200,OK,users 1-2,text,true,,10,10,2,2,http://api/login,19,0,1
+`
+
+func TestParseJTL(t *testing.T) {
+	metrics, err := ParseJTL(strings.NewReader(jtl))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(metrics) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(metrics))
+	}
+	ping := metrics[1]
+	if ping.Name != "ping" || ping.RequestCount != 2 || ping.FailureCount != 1 || ping.AvgMs != 20 || ping.MaxMs != 30 {
+		t.Fatalf("unexpected ping metric %+v", ping)
+	}
+	total := metrics[2]
+	if total.Name != results.AggregatedName || total.RequestCount != 3 || total.P50Ms != 20 {
+		t.Fatalf("unexpected aggregated metric %+v", total)
+	}
+
+	samples, err := ParseJTLHistory(strings.NewReader(jtl))
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(samples) != 2 || samples[1].TotalRequests != 3 || samples[1].UserCount != 2 {
+		t.Fatalf("unexpected samples %+v", samples)
+	}
+}
+
+func TestTailFollowsJTL(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ResultsFile), []byte(jtl), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	sample, err := Tail(dir).Poll()
+	if err != nil || sample == nil {
+		t.Fatalf("expected a sample, got %+v %v", sample, err)
+	}
+	if sample.UserCount != 2 || sample.TotalRequests != 3 || sample.TotalFailures != 1 {
+		t.Fatalf("unexpected sample %+v", sample)
+	}
+}
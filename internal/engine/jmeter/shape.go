@@ -0,0 +1,69 @@
+package jmeter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bench-hub/internal/loadshape"
+	"bench-hub/internal/model"
+)
+
+// Properties returns name=value pairs for -J flags. threads and rampup suit
+// a standard ThreadGroup reading ${__P(threads)} and ${__P(rampup)};
+// threads_schedule drives an Ultimate Thread Group through every stage.
+func Properties(stages []model.LoadStage) []string {
+	if len(stages) == 0 {
+		return nil
+	}
+	first := stages[0]
+	return []string{
+		"threads=" + strconv.Itoa(loadshape.Peak(stages)),
+		"rampup=" + strconv.Itoa(loadshape.Ramp(first.Users, first.SpawnRate)),
+		"threads_schedule=" + UltimateSchedule(stages),
+	}
+}
+
+// UltimateSchedule renders the stages as Ultimate Thread Group rows,
+// spawn(threads,delay,rampup,hold,shutdown). Every increase starts a new
+// row; a decrease ends the newest rows first.
+func UltimateSchedule(stages []model.LoadStage) string {
+	type layer struct {
+		threads, start, ramp int
+	}
+	var rows []string
+	end := func(l layer, at, shutdown int) {
+		hold := max(0, at-l.start-l.ramp)
+		rows = append(rows, fmt.Sprintf("spawn(%d,%ds,%ds,%ds,%ds)", l.threads, l.start, l.ramp, hold, shutdown))
+	}
+
+	var layers []layer
+	current, at := 0, 0
+	for _, stage := range stages {
+		switch {
+		case stage.Users > current:
+			delta := stage.Users - current
+			layers = append(layers, layer{threads: delta, start: at, ramp: loadshape.Ramp(delta, stage.SpawnRate)})
+		case stage.Users < current:
+			excess := current - stage.Users
+			for excess > 0 {
+				top := layers[len(layers)-1]
+				layers = layers[:len(layers)-1]
+				stop := min(excess, top.threads)
+				if rest := top.threads - stop; rest > 0 {
+					// Split the row: both halves ramp together, only one ends.
+					layers = append(layers, layer{threads: rest, start: top.start, ramp: top.ramp})
+					top.threads = stop
+				}
+				end(top, at, loadshape.Ramp(stop, stage.SpawnRate))
+				excess -= stop
+			}
+		}
+		current = stage.Users
+		at += stage.DurationSeconds
+	}
+	for i := len(layers) - 1; i >= 0; i-- {
+		end(layers[i], at, 0)
+	}
+	return strings.Join(rows, " ")
+}
@@ -0,0 +1,25 @@
+package jmeter
+
+import (
+	"testing"
+
+	"bench-hub/internal/model"
+)
+
+func TestUltimateSchedule(t *testing.T) {
+	stages := []model.LoadStage{
+		{DurationSeconds: 60, Users: 10, SpawnRate: 5},
+		{DurationSeconds: 60, Users: 30, SpawnRate: 10},
+		{DurationSeconds: 60, Users: 5, SpawnRate: 5},
+	}
+	got := UltimateSchedule(stages)
+	want := "spawn(20,60s,2s,58s,4s) spawn(5,0s,2s,118s,1s) spawn(5,0s,2s,178s,0s)"
+	if got != want {
+		t.Fatalf("schedule:\n got %s\nwant %s", got, want)
+	}
+
+	props := Properties(stages)
+	if props[0] != "threads=30" || props[1] != "rampup=2" {
+		t.Fatalf("properties: %v", props)
+	}
+}
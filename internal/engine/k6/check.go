@@ -0,0 +1,51 @@
+package k6
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"bench-hub/internal/model"
+)
+
+var (
+	defaultExport = regexp.MustCompile(`(?m)^\s*export\s+default\b`)
+	scenarios     = regexp.MustCompile(`\bscenarios\s*:`)
+	// envPattern matches __ENV.NAME and __ENV["NAME"].
+	envPattern = regexp.MustCompile(`__ENV(?:\.([A-Za-z_][A-Za-z0-9_]*)|\[\s*["']([^"']+)["']\s*\])`)
+)
+
+// Check checks that a k6 script has something to run and warns about
+// environment variables it reads that are neither script parameters nor the
+// TARGET_HOST the platform sets. Those may still come from secrets or the
+// runner's environment, so they do not fail the check.
+func Check(content string, params []model.ScriptParameter) []model.ScriptIssue {
+	if strings.TrimSpace(content) == "" {
+		return []model.ScriptIssue{{Severity: model.IssueError, Message: "empty script"}}
+	}
+	declared := make(map[string]bool, len(params))
+	for _, param := range params {
+		declared[param.Name] = true
+	}
+
+	var issues []model.ScriptIssue
+	if !defaultExport.MatchString(content) && !scenarios.MatchString(content) {
+		issues = append(issues, model.ScriptIssue{Severity: model.IssueError, Message: "the script has no default export and no scenarios"})
+	}
+	warned := map[string]bool{}
+	for i, line := range strings.Split(content, "\n") {
+		for _, match := range envPattern.FindAllStringSubmatch(line, -1) {
+			name := match[1] + match[2]
+			if name == "TARGET_HOST" || declared[name] || warned[name] {
+				continue
+			}
+			warned[name] = true
+			issues = append(issues, model.ScriptIssue{
+				Severity: model.IssueWarning,
+				Line:     i + 1,
+				Message:  fmt.Sprintf("__ENV.%s is neither a script parameter nor set by the platform", name),
+			})
+		}
+	}
+	return issues
+}
@@ -0,0 +1,28 @@
+package k6
+
+import (
+	"strings"
+	"testing"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/scriptcheck"
+)
+
+func TestCheck(t *testing.T) {
+	script := `import http from 'k6/http';
+
+export default function () {
+  http.get(__ENV.TARGET_HOST + '/ping?user=' + __ENV.user);
+  http.get(__ENV["TOKEN"]);
+}
+`
+	issues := Check(script, []model.ScriptParameter{{Name: "user"}})
+	if len(issues) != 1 || issues[0].Severity != model.IssueWarning || issues[0].Line != 5 || !strings.Contains(issues[0].Message, "TOKEN") {
+		t.Fatalf("expected a warning for TOKEN on line 5, got %+v", issues)
+	}
+
+	issues = Check("import http from 'k6/http';\nhttp.get('http://api');\n", nil)
+	if result := scriptcheck.Result(issues); result.Valid {
+		t.Fatalf("expected a script without a default export to fail, got %+v", issues)
+	}
+}
@@ -0,0 +1,122 @@
+// Package k6 runs k6 scripts with the k6 CLI. The task's load is passed as
+// stages, the target host as the TARGET_HOST environment variable, and k6
+// writes its summary export and JSON point stream into the run directory.
+package k6
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"bench-hub/internal/bundle"
+	"bench-hub/internal/engine"
+	"bench-hub/internal/model"
+	"bench-hub/internal/scriptparams"
+)
+
+// trendStats adds p(99) to k6's default summary so the summary carries the
+// same percentiles as a Locust run.
+const trendStats = "avg,min,med,max,p(90),p(95),p(99)"
+
+// scriptFile is the name a single-file script is written as.
+const scriptFile = "script.js"
+
+type Engine struct {
+	bin string
+}
+
+func New(bin string) *Engine {
+	return &Engine{bin: bin}
+}
+
+func (e *Engine) Name() string { return model.ScriptTypeK6 }
+
+func (e *Engine) Format() engine.Format {
+	return engine.Format{Title: "k6", Entrypoint: scriptFile, Extensions: []string{".js"}}
+}
+
+func (e *Engine) Check(ctx context.Context, script *model.Script) ([]model.ScriptIssue, error) {
+	return Check(script.Content, script.Parameters), nil
+}
+
+func (e *Engine) Prepare(job *engine.Job) (string, error) {
+	path, err := bundle.Prepare(job.Dir, job.Bundle, job.Entrypoint, scriptFile)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(job.Script), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Run passes parameters and secrets through the environment, which k6
+// exposes to the script as __ENV, so neither shows up in the command line.
+func (e *Engine) Run(ctx context.Context, job *engine.Job, script string, out io.Writer) error {
+	cmd := exec.Command(e.bin, commandLine(job, script)...)
+	cmd.Env = append(os.Environ(), "TARGET_HOST="+job.TargetHost)
+	cmd.Env = append(cmd.Env, scriptparams.Env(job.Variables)...)
+	cmd.Env = append(cmd.Env, scriptparams.Env(job.Secrets)...)
+	// open() in the script resolves against the entrypoint's directory.
+	cmd.Dir = filepath.Dir(script)
+	return engine.RunCommand(ctx, job, cmd, out)
+}
+
+// commandLine builds the k6 command line. Without a load profile the task's
+// users, spawn rate and duration become a single stage, so VUs ramp up the
+// way Locust users do.
+func commandLine(job *engine.Job, script string) []string {
+	args := []string{
+		"run",
+		"--no-color",
+		"--summary-export", filepath.Join(job.Dir, SummaryFile),
+		"--summary-trend-stats", trendStats,
+		"--out", "json=" + filepath.Join(job.Dir, ResultsFile),
+	}
+	stages := job.Stages
+	if len(stages) == 0 {
+		stages = []model.LoadStage{{DurationSeconds: job.Duration, Users: job.Users, SpawnRate: job.SpawnRate}}
+	}
+	for _, stage := range Stages(stages) {
+		args = append(args, "--stage", stage)
+	}
+	return append(args, script)
+}
+
+func (e *Engine) Reports(job *engine.Job) []engine.Report {
+	return engine.Existing(job,
+		engine.Report{Name: SummaryFile, Type: "json", File: SummaryFile},
+		engine.Report{Name: ResultsFile, Type: "json", File: ResultsFile},
+	)
+}
+
+// Failed treats failed requests as a failed run, like Locust, on top of k6's
+// own exit code for crossed thresholds. k6 exits 0 when requests fail, so
+// the summary decides; without one the exit code does.
+func (e *Engine) Failed(job *engine.Job, runErr error) bool {
+	if runErr != nil {
+		return true
+	}
+	file, err := os.Open(filepath.Join(job.Dir, SummaryFile))
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	metrics, err := ParseSummary(file)
+	if err != nil {
+		log.Printf("k6 summary parse error: %v", err)
+		return false
+	}
+	return metrics[0].FailureCount > 0
+}
+
+func (e *Engine) Metrics(dir string) ([]model.EndpointMetric, []model.MetricSample, error) {
+	return Metrics(dir)
+}
+
+func (e *Engine) Tail(dir string) engine.Tailer {
+	return Tail(dir)
+}
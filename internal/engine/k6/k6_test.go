@@ -0,0 +1,51 @@
+package k6
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bench-hub/internal/engine"
+	"bench-hub/internal/model"
+)
+
+func TestCommandLine(t *testing.T) {
+	job := &engine.Job{Dir: "/runs/task_1", Users: 20, SpawnRate: 5, Duration: 60}
+	got := strings.Join(commandLine(job, "/runs/task_1/script.js"), " ")
+	want := "run --no-color --summary-export /runs/task_1/k6_summary.json --summary-trend-stats avg,min,med,max,p(90),p(95),p(99) " +
+		"--out json=/runs/task_1/k6_results.json --stage 4s:20 --stage 56s:20 /runs/task_1/script.js"
+	if got != want {
+		t.Fatalf("command line\n got %s\nwant %s", got, want)
+	}
+
+	job.Stages = []model.LoadStage{{DurationSeconds: 30, Users: 10, SpawnRate: 10}, {DurationSeconds: 30, Users: 0, SpawnRate: 10}}
+	if got := strings.Join(commandLine(job, "script.js"), " "); !strings.HasSuffix(got, "--stage 1s:10 --stage 29s:10 --stage 1s:0 --stage 29s:0 script.js") {
+		t.Fatalf("stages: %s", got)
+	}
+}
+
+func TestFailed(t *testing.T) {
+	dir := t.TempDir()
+	job := &engine.Job{Dir: dir}
+	e := New("k6")
+	if e.Failed(job, nil) || !e.Failed(job, errors.New("exit status 99")) {
+		t.Fatal("without a summary the exit status decides")
+	}
+
+	summary := `{"metrics":{"http_reqs":{"count":10,"rate":1},"http_req_failed":{"passes":0,"fails":10,"value":0}}}`
+	path := filepath.Join(dir, SummaryFile)
+	if err := os.WriteFile(path, []byte(summary), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if e.Failed(job, nil) {
+		t.Fatal("expected a run without failed requests to pass")
+	}
+	if err := os.WriteFile(path, []byte(strings.Replace(summary, `"passes":0`, `"passes":2`, 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !e.Failed(job, nil) {
+		t.Fatal("expected failed requests to fail the run")
+	}
+}
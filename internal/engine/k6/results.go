@@ -0,0 +1,201 @@
+package k6
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"bench-hub/internal/engine"
+	"bench-hub/internal/model"
+	"bench-hub/internal/results"
+)
+
+const (
+	// ResultsFile is k6's --out json stream, one metric point per line.
+	ResultsFile = "k6_results.json"
+	// SummaryFile is k6's --summary-export of the end-of-test summary.
+	SummaryFile = "k6_summary.json"
+)
+
+// maxLine bounds a single JSON point; points carry every request tag.
+const maxLine = 1 << 20
+
+// Metrics aggregates the JSON stream in dir. A summary export alone yields
+// the aggregate without a timeline.
+func Metrics(dir string) ([]model.EndpointMetric, []model.MetricSample, error) {
+	path := filepath.Join(dir, ResultsFile)
+	metrics, err := results.ParseFile(path, Parse)
+	if err == nil {
+		samples, err := results.ParseFile(path, ParseHistory)
+		if err != nil {
+			return nil, nil, err
+		}
+		return metrics, samples, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	metrics, err = results.ParseFile(filepath.Join(dir, SummaryFile), ParseSummary)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, results.ErrNoResults
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return metrics, nil, nil
+}
+
+type point struct {
+	Type   string `json:"type"`
+	Metric string `json:"metric"`
+	Data   struct {
+		Time  time.Time         `json:"time"`
+		Value float64           `json:"value"`
+		Tags  map[string]string `json:"tags"`
+	} `json:"data"`
+}
+
+// request turns an http_req_duration point into a sample. vus is the
+// latest VU count seen in the stream.
+func (p *point) request(vus int) results.Sample {
+	// k6 stamps points when the request completes.
+	end := p.Data.Time.UnixMilli()
+	return results.Sample{
+		Timestamp: end - int64(p.Data.Value),
+		ElapsedMs: p.Data.Value,
+		Method:    p.Data.Tags["method"],
+		Label:     p.Data.Tags["name"],
+		Success:   success(p.Data.Tags),
+		Users:     vus,
+	}
+}
+
+// success reads the expected_response tag k6 sets on every request and
+// falls back to the status code for scripts that drop it.
+func success(tags map[string]string) bool {
+	if expected, ok := tags["expected_response"]; ok {
+		return expected != "false"
+	}
+	status, err := strconv.Atoi(tags["status"])
+	return err == nil && status > 0 && status < 400
+}
+
+// readPoints visits the HTTP requests of a k6 JSON stream. Lines that do
+// not parse, such as one cut short when the run was killed, are skipped.
+func readPoints(r io.Reader, visit func(results.Sample)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLine)
+	vus := 0
+	for scanner.Scan() {
+		var p point
+		if err := json.Unmarshal(scanner.Bytes(), &p); err != nil || p.Type != "Point" {
+			continue
+		}
+		switch p.Metric {
+		case "vus":
+			vus = int(p.Data.Value)
+		case "http_req_duration":
+			visit(p.request(vus))
+		}
+	}
+	return scanner.Err()
+}
+
+// Parse aggregates a k6 JSON stream per request method and name, adding an
+// "Aggregated" entry shaped like the Locust total row.
+func Parse(r io.Reader) ([]model.EndpointMetric, error) {
+	return results.Aggregate(func(visit func(results.Sample)) error { return readPoints(r, visit) })
+}
+
+// ParseHistory buckets k6 requests per second into a timeline comparable
+// to Locust's stats history.
+func ParseHistory(r io.Reader) ([]model.MetricSample, error) {
+	return results.Timeline(func(visit func(results.Sample)) error { return readPoints(r, visit) })
+}
+
+type summary struct {
+	Metrics map[string]summaryValues `json:"metrics"`
+}
+
+// summaryValues holds one metric of the summary. Besides numbers it may
+// carry threshold results, which are not needed here.
+type summaryValues map[string]any
+
+func (v summaryValues) float(name string) float64 {
+	value, _ := v[name].(float64)
+	return value
+}
+
+// ParseSummary reads a --summary-export file into the "Aggregated" entry
+// alone; the summary has no per-request breakdown. Percentiles missing from
+// the export's trend stats are left at zero.
+func ParseSummary(r io.Reader) ([]model.EndpointMetric, error) {
+	var s summary
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+	requests, ok := s.Metrics["http_reqs"]
+	if !ok {
+		return nil, errors.New("http_reqs metric not found in k6 summary")
+	}
+	duration := s.Metrics["http_req_duration"]
+	// For the http_req_failed rate, "passes" counts the failed requests.
+	failures := s.Metrics["http_req_failed"].float("passes")
+
+	metric := model.EndpointMetric{
+		Name:         results.AggregatedName,
+		RequestCount: int64(requests.float("count")),
+		FailureCount: int64(failures),
+		AvgMs:        duration.float("avg"),
+		MinMs:        duration.float("min"),
+		MaxMs:        duration.float("max"),
+		P50Ms:        duration.float("med"),
+		P90Ms:        duration.float("p(90)"),
+		P95Ms:        duration.float("p(95)"),
+		P99Ms:        duration.float("p(99)"),
+		RPS:          requests.float("rate"),
+	}
+	if metric.RequestCount > 0 {
+		metric.FailuresPerSec = metric.RPS * failures / requests.float("count")
+	}
+	return []model.EndpointMetric{metric}, nil
+}
+
+// Tail follows the JSON stream, taking requests and the VU count from its
+// points.
+func Tail(dir string) engine.Tailer {
+	return &tailer{lines: results.NewLines(filepath.Join(dir, ResultsFile)), window: results.NewWindow()}
+}
+
+type tailer struct {
+	lines  *results.Lines
+	window *results.Window
+	vus    int
+}
+
+func (t *tailer) Poll() (*model.MetricSample, error) {
+	lines, err := t.lines.Next()
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		var p point
+		if err := json.Unmarshal(line, &p); err != nil || p.Type != "Point" {
+			continue
+		}
+		switch p.Metric {
+		case "vus":
+			t.vus = int(p.Data.Value)
+		case "http_req_duration":
+			t.window.Add(p.Data.Value, success(p.Data.Tags), t.vus)
+		}
+		t.window.Users(t.vus)
+	}
+	return t.window.Flush(), nil
+}
@@ -0,0 +1,88 @@
+package k6
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bench-hub/internal/results"
+)
+
+const points = `{"type":"Metric","data":{"name":"http_req_duration","type":"trend","contains":"time"},"metric":"http_req_duration"}
+{"type":"Point","data":{"time":"2023-11-14T22:13:20.01Z","value":2,"tags":null},"metric":"vus"}
+{"type":"Point","data":{"time":"2023-11-14T22:13:20.01Z","value":10,"tags":{"expected_response":"true","method":"GET","name":"http://api/ping","status":"200"}},"metric":"http_req_duration"}
+{"type":"Point","data":{"time":"2023-11-14T22:13:20.5Z","value":1,"tags":{"expected_response":"false","method":"GET","name":"http://api/ping","status":"500"}},"metric":"http_req_failed"}
+{"type":"Point","data":{"time":"2023-11-14T22:13:20.53Z","value":30,"tags":{"expected_response":"false","method":"GET","name":"http://api/ping","status":"500"}},"metric":"http_req_duration"}
+{"type":"Point","data":{"time":"2023-11-14T22:13:21.02Z","value":20,"tags":{"method":"POST","name":"http://api/login","status":"200"}},"metric":"http_req_duration"}
+{"type":"Point","data":{"time":"2023-11-14T22:13:2
+`
+
+const summaryExport = `{"root_group":{"name":"","checks":{}},"metrics":{
+"http_reqs":{"count":3,"rate":1.5},
+"http_req_duration":{"avg":20,"min":10,"med":20,"max":30,"p(90)":28,"p(95)":29,"p(99)":29.8,"thresholds":{"p(95)<500":false}},
+"http_req_failed":{"passes":1,"fails":2,"value":0.3333333333333333}}}`
+
+func TestParse(t *testing.T) {
+	metrics, err := Parse(strings.NewReader(points))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(metrics) != 3 {
+		t.Fatalf("expected 3 rows, got %+v", metrics)
+	}
+	ping := metrics[1]
+	if ping.Method != "GET" || ping.Name != "http://api/ping" || ping.RequestCount != 2 || ping.FailureCount != 1 || ping.AvgMs != 20 {
+		t.Fatalf("unexpected ping metric %+v", ping)
+	}
+	if metrics[0].Method != "POST" || metrics[0].FailureCount != 0 {
+		t.Fatalf("unexpected login metric %+v", metrics[0])
+	}
+	if total := metrics[2]; total.Name != results.AggregatedName || total.RequestCount != 3 || total.FailureCount != 1 {
+		t.Fatalf("unexpected aggregated metric %+v", total)
+	}
+
+	samples, err := ParseHistory(strings.NewReader(points))
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(samples) != 2 || samples[0].UserCount != 2 || samples[1].TotalRequests != 3 || samples[1].TotalFailures != 1 {
+		t.Fatalf("unexpected samples %+v", samples)
+	}
+
+	summary, err := ParseSummary(strings.NewReader(summaryExport))
+	if err != nil {
+		t.Fatalf("summary: %v", err)
+	}
+	if len(summary) != 1 || summary[0].RequestCount != 3 || summary[0].FailureCount != 1 || summary[0].P95Ms != 29 || summary[0].FailuresPerSec != 0.5 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+}
+
+func TestMetricsFallsBackToSummary(t *testing.T) {
+	dir := t.TempDir()
+	if _, _, err := Metrics(dir); err != results.ErrNoResults {
+		t.Fatalf("expected ErrNoResults, got %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, SummaryFile), []byte(summaryExport), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	metrics, samples, err := Metrics(dir)
+	if err != nil || len(metrics) != 1 || samples != nil {
+		t.Fatalf("expected the summary alone, got %+v %+v %v", metrics, samples, err)
+	}
+}
+
+func TestTailFollowsPoints(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ResultsFile), []byte(points), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	sample, err := Tail(dir).Poll()
+	if err != nil || sample == nil {
+		t.Fatalf("expected a sample, got %+v %v", sample, err)
+	}
+	if sample.UserCount != 2 || sample.TotalRequests != 3 || sample.TotalFailures != 1 {
+		t.Fatalf("unexpected sample %+v", sample)
+	}
+}
@@ -0,0 +1,34 @@
+package k6
+
+import (
+	"fmt"
+
+	"bench-hub/internal/loadshape"
+	"bench-hub/internal/model"
+)
+
+// Stages renders the stages as duration:target values for k6 --stage. k6
+// ramps linearly over a whole stage, so each stage becomes a ramp at its
+// spawn rate followed by a hold for the rest of its duration.
+func Stages(stages []model.LoadStage) []string {
+	var out []string
+	current := 0
+	for _, stage := range stages {
+		ramp := min(loadshape.Ramp(abs(stage.Users-current), stage.SpawnRate), stage.DurationSeconds)
+		if ramp > 0 {
+			out = append(out, fmt.Sprintf("%ds:%d", ramp, stage.Users))
+		}
+		if hold := stage.DurationSeconds - ramp; hold > 0 {
+			out = append(out, fmt.Sprintf("%ds:%d", hold, stage.Users))
+		}
+		current = stage.Users
+	}
+	return out
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,22 @@
+package k6
+
+import (
+	"strings"
+	"testing"
+
+	"bench-hub/internal/model"
+)
+
+func TestStages(t *testing.T) {
+	stages := []model.LoadStage{
+		{DurationSeconds: 60, Users: 10, SpawnRate: 5},
+		{DurationSeconds: 30, Users: 10, SpawnRate: 5},
+		{DurationSeconds: 5, Users: 50, SpawnRate: 4},
+		{DurationSeconds: 20, Users: 0, SpawnRate: 25},
+	}
+	got := strings.Join(Stages(stages), " ")
+	want := "2s:10 58s:10 30s:10 5s:50 2s:0 18s:0"
+	if got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+package locust
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"bench-hub/internal/bundle"
+	"bench-hub/internal/model"
+)
+
+// listTimeout bounds how long importing a locustfile may take.
+const listTimeout = 30 * time.Second
+
+// Check writes the script, unpacking its bundle if any, and imports it with
+// `locust --list`, which fails on syntax and import errors and when no User
+// class is defined. The error is non-nil only when locust could not be run
+// at all.
+func (e *Engine) Check(ctx context.Context, script *model.Script) ([]model.ScriptIssue, error) {
+	dir, err := os.MkdirTemp("", "scriptcheck-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := bundle.Prepare(dir, script.Bundle, script.Entrypoint, scriptFile)
+	if err != nil {
+		return []model.ScriptIssue{{Severity: model.IssueError, Message: err.Error()}}, nil
+	}
+	if err := os.WriteFile(path, []byte(script.Content), 0o644); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, listTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, e.bin, "-f", path, "--list")
+	cmd.Dir = filepath.Dir(path)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, err
+		}
+		if ctx.Err() != nil {
+			return []model.ScriptIssue{{Severity: model.IssueError, Message: fmt.Sprintf("importing the script took longer than %s", listTimeout)}}, nil
+		}
+		return parseList(output, dir, path), nil
+	}
+	return nil, nil
+}
+
+var tracebackFile = regexp.MustCompile(`^\s*File "(.+)", line (\d+)`)
+
+// parseList turns the output of a failed `locust --list` into an issue,
+// locating it at the innermost traceback frame inside dir.
+func parseList(output []byte, dir, entrypoint string) []model.ScriptIssue {
+	if bytes.Contains(output, []byte("No User class found")) {
+		return []model.ScriptIssue{{Severity: model.IssueError, Message: "no User subclass found"}}
+	}
+
+	issue := model.ScriptIssue{Severity: model.IssueError, Message: "locust could not load the script"}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := tracebackFile.FindStringSubmatch(line); match != nil {
+			rel, err := filepath.Rel(dir, match[1])
+			if err != nil || strings.HasPrefix(rel, "..") {
+				continue
+			}
+			issue.File = ""
+			if match[1] != entrypoint {
+				issue.File = filepath.ToSlash(rel)
+			}
+			issue.Line, _ = strconv.Atoi(match[2])
+			continue
+		}
+		// The exception summary is the last unindented line.
+		if trimmed := strings.TrimSpace(line); trimmed != "" && trimmed == line {
+			issue.Message = trimmed
+		}
+	}
+	return []model.ScriptIssue{issue}
+}
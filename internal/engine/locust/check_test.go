@@ -0,0 +1,33 @@
+package locust
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseList(t *testing.T) {
+	dir := "/tmp/scriptcheck-1"
+	entry := filepath.Join(dir, "locustfile.py")
+	output := `Traceback (most recent call last):
+  File "/usr/bin/locust", line 8, in <module>
+    sys.exit(main())
+  File "/tmp/scriptcheck-1/locustfile.py", line 2, in <module>
+    from helpers import token
+  File "/tmp/scriptcheck-1/helpers.py", line 4
+    def token(:
+              ^
+SyntaxError: invalid syntax
+`
+	issues := parseList([]byte(output), dir, entry)
+	if len(issues) != 1 {
+		t.Fatalf("expected one issue, got %+v", issues)
+	}
+	if issues[0].File != "helpers.py" || issues[0].Line != 4 || issues[0].Message != "SyntaxError: invalid syntax" {
+		t.Fatalf("unexpected issue %+v", issues[0])
+	}
+
+	issues = parseList([]byte("No User class found!\n"), dir, entry)
+	if len(issues) != 1 || issues[0].Message != "no User subclass found" {
+		t.Fatalf("unexpected issue %+v", issues)
+	}
+}
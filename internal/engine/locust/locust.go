@@ -0,0 +1,121 @@
+// Package locust runs locustfiles with the locust CLI, headless, with CSV
+// history and an HTML report.
+package locust
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"bench-hub/internal/bundle"
+	"bench-hub/internal/engine"
+	"bench-hub/internal/model"
+	"bench-hub/internal/scriptparams"
+)
+
+const (
+	// scriptFile is the name a single-file script is written as.
+	scriptFile = "locustfile.py"
+	csvPrefix  = "report"
+	htmlFile   = "report.html"
+
+	// expectWorkersWait bounds how long a master waits for its workers.
+	expectWorkersWait = 120
+)
+
+type Engine struct {
+	bin string
+}
+
+func New(bin string) *Engine {
+	return &Engine{bin: bin}
+}
+
+func (e *Engine) Name() string { return model.ScriptTypeLocust }
+
+// Format asks for a runner check: importing the script needs the packages
+// installed where it runs.
+func (e *Engine) Format() engine.Format {
+	return engine.Format{Title: "Locust", Entrypoint: scriptFile, Extensions: []string{".py"}, RunnerCheck: true}
+}
+
+// Prepare unpacks the bundle and writes the locustfile with the task's load
+// shape appended.
+func (e *Engine) Prepare(job *engine.Job) (string, error) {
+	path, err := bundle.Prepare(job.Dir, job.Bundle, job.Entrypoint, scriptFile)
+	if err != nil {
+		return "", err
+	}
+	content := WithShape(job.Script, job.Stages)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func (e *Engine) Run(ctx context.Context, job *engine.Job, script string, out io.Writer) error {
+	cmd := exec.Command(e.bin, commandLine(job, script)...)
+	cmd.Env = append(os.Environ(), scriptparams.Env(job.Variables)...)
+	cmd.Env = append(cmd.Env, scriptparams.Env(job.Secrets)...)
+	// Relative paths in the script, e.g. data files, resolve against the
+	// entrypoint's directory.
+	cmd.Dir = filepath.Dir(script)
+	return engine.RunCommand(ctx, job, cmd, out)
+}
+
+// commandLine builds the locust command line. Workers take users, rate and
+// duration from the master.
+func commandLine(job *engine.Job, script string) []string {
+	args := []string{"-f", script}
+	if job.Role == engine.RoleWorker {
+		return append(args,
+			"--worker",
+			"--master-host", job.MasterHost,
+			"--master-port", strconv.Itoa(job.MasterPort),
+		)
+	}
+
+	args = append(args,
+		"--headless",
+		"-u", strconv.Itoa(job.Users),
+		"-r", strconv.Itoa(job.SpawnRate),
+		"--run-time", strconv.Itoa(job.Duration)+"s",
+		"--host", job.TargetHost,
+		"--csv", filepath.Join(job.Dir, csvPrefix),
+		"--csv-full-history",
+		"--html", filepath.Join(job.Dir, htmlFile),
+	)
+	if job.Role == engine.RoleMaster {
+		args = append(args,
+			"--master",
+			"--master-bind-port", strconv.Itoa(job.MasterPort),
+			"--expect-workers", strconv.Itoa(job.ExpectWorkers),
+			"--expect-workers-max-wait", strconv.Itoa(expectWorkersWait),
+		)
+	}
+	return args
+}
+
+func (e *Engine) Reports(job *engine.Job) []engine.Report {
+	return engine.Existing(job,
+		engine.Report{Name: htmlFile, Type: "html", File: htmlFile},
+		engine.Report{Name: StatsFile, Type: "csv", File: StatsFile},
+	)
+}
+
+// Failed follows Locust's exit code, which is non-zero when any request
+// failed.
+func (e *Engine) Failed(job *engine.Job, runErr error) bool {
+	return runErr != nil
+}
+
+func (e *Engine) Metrics(dir string) ([]model.EndpointMetric, []model.MetricSample, error) {
+	return Metrics(dir)
+}
+
+func (e *Engine) Tail(dir string) engine.Tailer {
+	return Tail(dir)
+}
@@ -0,0 +1,34 @@
+package locust
+
+import (
+	"strings"
+	"testing"
+
+	"bench-hub/internal/engine"
+)
+
+func TestCommandLine(t *testing.T) {
+	job := &engine.Job{
+		Dir:        "/runs/task_1",
+		TargetHost: "http://api:8080",
+		Users:      50,
+		SpawnRate:  5,
+		Duration:   300,
+	}
+	got := strings.Join(commandLine(job, "/runs/task_1/locustfile.py"), " ")
+	want := "-f /runs/task_1/locustfile.py --headless -u 50 -r 5 --run-time 300s --host http://api:8080 " +
+		"--csv /runs/task_1/report --csv-full-history --html /runs/task_1/report.html"
+	if got != want {
+		t.Fatalf("standalone\n got %s\nwant %s", got, want)
+	}
+
+	job.Role, job.MasterPort, job.ExpectWorkers = engine.RoleMaster, 5557, 3
+	if got := strings.Join(commandLine(job, "locustfile.py"), " "); !strings.HasSuffix(got, "--master --master-bind-port 5557 --expect-workers 3 --expect-workers-max-wait 120") {
+		t.Fatalf("master: %s", got)
+	}
+
+	job.Role, job.MasterHost = engine.RoleWorker, "10.0.0.2"
+	if got := strings.Join(commandLine(job, "locustfile.py"), " "); got != "-f locustfile.py --worker --master-host 10.0.0.2 --master-port 5557" {
+		t.Fatalf("worker: %s", got)
+	}
+}
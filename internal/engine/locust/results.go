@@ -0,0 +1,162 @@
+package locust
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"bench-hub/internal/engine"
+	"bench-hub/internal/model"
+	"bench-hub/internal/results"
+)
+
+// Files written by --csv report --csv-full-history. Engines that produce
+// Locust-compatible statistics write the same files.
+const (
+	StatsFile   = "report_stats.csv"
+	HistoryFile = "report_stats_history.csv"
+)
+
+// Metrics reads the stats and, when present, the stats history in dir.
+func Metrics(dir string) ([]model.EndpointMetric, []model.MetricSample, error) {
+	metrics, err := results.ParseFile(filepath.Join(dir, StatsFile), ParseStats)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, results.ErrNoResults
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	samples, err := results.ParseFile(filepath.Join(dir, HistoryFile), ParseHistory)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+	return metrics, samples, nil
+}
+
+// ParseStats reads a report_stats.csv file. The "Aggregated" row is kept as
+// a regular entry with an empty method.
+func ParseStats(r io.Reader) ([]model.EndpointMetric, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	table, err := results.ReadHeader(reader, "Name", "Request Count")
+	if err != nil {
+		return nil, err
+	}
+
+	var metrics []model.EndpointMetric
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := table.Str(record, "Name")
+		if name == "" {
+			continue
+		}
+		metrics = append(metrics, model.EndpointMetric{
+			Method:         table.Str(record, "Type"),
+			Name:           name,
+			RequestCount:   table.Int(record, "Request Count"),
+			FailureCount:   table.Int(record, "Failure Count"),
+			AvgMs:          table.Float(record, "Average Response Time"),
+			MinMs:          table.Float(record, "Min Response Time"),
+			MaxMs:          table.Float(record, "Max Response Time"),
+			P50Ms:          table.Float(record, "50%"),
+			P90Ms:          table.Float(record, "90%"),
+			P95Ms:          table.Float(record, "95%"),
+			P99Ms:          table.Float(record, "99%"),
+			RPS:            table.Float(record, "Requests/s"),
+			FailuresPerSec: table.Float(record, "Failures/s"),
+		})
+	}
+	return metrics, nil
+}
+
+// ParseHistory reads the aggregated rows of a report_stats_history.csv
+// file; per-endpoint rows written by --csv-full-history are skipped.
+func ParseHistory(r io.Reader) ([]model.MetricSample, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	table, err := results.ReadHeader(reader, "Timestamp", "Name")
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []model.MetricSample
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if sample := historySample(table, record); sample != nil {
+			samples = append(samples, *sample)
+		}
+	}
+	return samples, nil
+}
+
+// historySample reads an aggregated history row, or returns nil for an
+// endpoint row.
+func historySample(table results.Table, record []string) *model.MetricSample {
+	if table.Str(record, "Name") != results.AggregatedName {
+		return nil
+	}
+	return &model.MetricSample{
+		SampledAt:      time.Unix(table.Int(record, "Timestamp"), 0),
+		UserCount:      int(table.Int(record, "User Count")),
+		RPS:            table.Float(record, "Requests/s"),
+		FailuresPerSec: table.Float(record, "Failures/s"),
+		P50Ms:          table.Float(record, "50%"),
+		P95Ms:          table.Float(record, "95%"),
+		TotalRequests:  table.Int(record, "Total Request Count"),
+		TotalFailures:  table.Int(record, "Total Failure Count"),
+	}
+}
+
+// Tail follows the stats history, whose aggregated rows already are
+// snapshots.
+func Tail(dir string) engine.Tailer {
+	return &tailer{lines: results.NewLines(filepath.Join(dir, HistoryFile))}
+}
+
+type tailer struct {
+	lines  *results.Lines
+	header *results.Table
+}
+
+func (t *tailer) Poll() (*model.MetricSample, error) {
+	lines, err := t.lines.Next()
+	if err != nil {
+		return nil, err
+	}
+	var latest *model.MetricSample
+	for _, line := range lines {
+		reader := csv.NewReader(bytes.NewReader(line))
+		reader.FieldsPerRecord = -1
+		record, err := reader.Read()
+		if err != nil {
+			continue
+		}
+		if t.header == nil {
+			table := results.NewTable(record)
+			t.header = &table
+			continue
+		}
+		if sample := historySample(*t.header, record); sample != nil {
+			latest = sample
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,98 @@
+package locust
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bench-hub/internal/results"
+)
+
+const stats = `Type,Name,Request Count,Failure Count,Median Response Time,Average Response Time,Min Response Time,Max Response Time,Average Content Size,Requests/s,Failures/s,50%,66%,75%,80%,90%,95%,98%,99%,99.9%,99.99%,100%
+GET,/api/v1/ping,120,2,12,14.5,3,80,17,4.0,0.07,12,13,15,16,20,31,45,60,80,80,80
+,Aggregated,120,2,12,14.5,3,80,17,4.0,0.07,12,13,15,16,20,31,45,60,80,80,80
+`
+
+const history = `Timestamp,User Count,Type,Name,Requests/s,Failures/s,50%,66%,75%,80%,90%,95%,98%,99%,99.9%,99.99%,100%,Total Request Count,Total Failure Count,Total Median Response Time,Total Average Response Time,Total Min Response Time,Total Max Response Time,Total Average Content Size
+1700000000,0,,Aggregated,0.000000,0.000000,N/A,N/A,N/A,N/A,N/A,N/A,N/A,N/A,N/A,N/A,N/A,0,0,0,0.0,0,0,0
+1700000001,5,GET,/api/v1/ping,3.0,0.0,12,13,15,16,20,31,45,60,80,80,80,3,0,12,14.5,3,80,17
+1700000001,5,,Aggregated,3.0,0.0,12,13,15,16,20,31,45,60,80,80,80,3,0,12,14.5,3,80,17
+`
+
+func TestParseStats(t *testing.T) {
+	metrics, err := ParseStats(strings.NewReader(stats))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(metrics) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(metrics))
+	}
+	ping := metrics[0]
+	if ping.Method != "GET" || ping.Name != "/api/v1/ping" {
+		t.Fatalf("unexpected endpoint %s %s", ping.Method, ping.Name)
+	}
+	if ping.RequestCount != 120 || ping.FailureCount != 2 || ping.P95Ms != 31 || ping.P99Ms != 60 || ping.RPS != 4 {
+		t.Fatalf("unexpected values %+v", ping)
+	}
+	if metrics[1].Name != results.AggregatedName {
+		t.Fatalf("expected aggregated row, got %s", metrics[1].Name)
+	}
+}
+
+func TestParseHistory(t *testing.T) {
+	samples, err := ParseHistory(strings.NewReader(history))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(samples) != 2 {
+		t.Fatalf("expected 2 aggregated samples, got %d", len(samples))
+	}
+	if samples[0].P95Ms != 0 {
+		t.Fatalf("expected N/A to parse as 0, got %v", samples[0].P95Ms)
+	}
+	if samples[1].UserCount != 5 || samples[1].TotalRequests != 3 {
+		t.Fatalf("unexpected sample %+v", samples[1])
+	}
+}
+
+func TestMetricsWithoutStats(t *testing.T) {
+	if _, _, err := Metrics(t.TempDir()); err != results.ErrNoResults {
+		t.Fatalf("expected ErrNoResults, got %v", err)
+	}
+}
+
+func TestTailFollowsHistory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, HistoryFile)
+	lines := strings.SplitAfter(history, "\n")
+
+	tailer := Tail(dir)
+	if sample, err := tailer.Poll(); err != nil || sample != nil {
+		t.Fatalf("expected nothing before the file exists, got %v %v", sample, err)
+	}
+
+	// Header, first row and half of the second row.
+	if err := os.WriteFile(path, []byte(lines[0]+lines[1]+lines[2][:10]), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	sample, err := tailer.Poll()
+	if err != nil || sample == nil || sample.UserCount != 0 {
+		t.Fatalf("expected first aggregated sample, got %+v %v", sample, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	_, _ = file.WriteString(lines[2][10:] + lines[3])
+	file.Close()
+
+	sample, err = tailer.Poll()
+	if err != nil || sample == nil || sample.UserCount != 5 || sample.TotalRequests != 3 {
+		t.Fatalf("expected appended sample, got %+v %v", sample, err)
+	}
+	if sample, _ := tailer.Poll(); sample != nil {
+		t.Fatalf("expected no new sample, got %+v", sample)
+	}
+}
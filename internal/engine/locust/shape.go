@@ -0,0 +1,41 @@
+package locust
+
+import (
+	"fmt"
+	"strings"
+
+	"bench-hub/internal/model"
+)
+
+// Shape renders the stages as a LoadTestShape class. Locust uses it instead
+// of -u/-r and stops the run after the last stage.
+func Shape(stages []model.LoadStage) string {
+	var b strings.Builder
+	b.WriteString("# Generated by bench-hub from the task's load profile.\n")
+	b.WriteString("from locust import LoadTestShape as _BenchHubLoadTestShape\n\n\n")
+	b.WriteString("class BenchHubLoadShape(_BenchHubLoadTestShape):\n")
+	b.WriteString("    # (end_seconds, users, spawn_rate)\n")
+	b.WriteString("    stages = [\n")
+	end := 0
+	for _, stage := range stages {
+		end += stage.DurationSeconds
+		fmt.Fprintf(&b, "        (%d, %d, %d),\n", end, stage.Users, stage.SpawnRate)
+	}
+	b.WriteString("    ]\n\n")
+	b.WriteString("    def tick(self):\n")
+	b.WriteString("        run_time = self.get_run_time()\n")
+	b.WriteString("        for end, users, spawn_rate in self.stages:\n")
+	b.WriteString("            if run_time < end:\n")
+	b.WriteString("                return users, spawn_rate\n")
+	b.WriteString("        return None\n")
+	return b.String()
+}
+
+// WithShape appends the shape class to a locustfile. Without stages the
+// script is returned unchanged.
+func WithShape(script string, stages []model.LoadStage) string {
+	if len(stages) == 0 {
+		return script
+	}
+	return strings.TrimRight(script, "\n") + "\n\n\n" + Shape(stages)
+}
@@ -0,0 +1,27 @@
+package locust
+
+import (
+	"strings"
+	"testing"
+
+	"bench-hub/internal/model"
+)
+
+func TestWithShapeAppendsShape(t *testing.T) {
+	stages := []model.LoadStage{
+		{DurationSeconds: 60, Users: 10, SpawnRate: 2},
+		{DurationSeconds: 30, Users: 50, SpawnRate: 10},
+	}
+	out := WithShape("from locust import HttpUser\n", stages)
+	if !strings.HasPrefix(out, "from locust import HttpUser\n") {
+		t.Fatalf("script not preserved:\n%s", out)
+	}
+	for _, fragment := range []string{"class BenchHubLoadShape(_BenchHubLoadTestShape):", "(60, 10, 2),", "(90, 50, 10),"} {
+		if !strings.Contains(out, fragment) {
+			t.Errorf("missing %q in:\n%s", fragment, out)
+		}
+	}
+	if WithShape("x", nil) != "x" {
+		t.Fatalf("script without stages must be unchanged")
+	}
+}
@@ -0,0 +1,88 @@
+package native
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"bench-hub/internal/bundle"
+	"bench-hub/internal/engine"
+	"bench-hub/internal/engine/locust"
+	"bench-hub/internal/model"
+)
+
+// scriptFile is the name a single-file scenario is written as.
+const scriptFile = "scenario.yaml"
+
+// Engine runs scenarios in the calling process.
+type Engine struct{}
+
+func New() *Engine {
+	return &Engine{}
+}
+
+func (e *Engine) Name() string { return model.ScriptTypeNative }
+
+// Format accepts JSON scenarios too, which load as YAML.
+func (e *Engine) Format() engine.Format {
+	return engine.Format{Title: "Native", Entrypoint: scriptFile, Extensions: []string{".yaml", ".yml", ".json"}}
+}
+
+func (e *Engine) Check(ctx context.Context, script *model.Script) ([]model.ScriptIssue, error) {
+	return Check(script.Content, script.Parameters), nil
+}
+
+// Prepare writes the scenario into the run directory so the run can be
+// reproduced; the engine itself reads it from the job.
+func (e *Engine) Prepare(job *engine.Job) (string, error) {
+	path, err := bundle.Prepare(job.Dir, job.Bundle, job.Entrypoint, scriptFile)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(job.Script), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func (e *Engine) Run(ctx context.Context, job *engine.Job, script string, out io.Writer) error {
+	scenario, err := Load(job.Script)
+	if err != nil {
+		return err
+	}
+	vars := make(map[string]string, len(job.Variables)+len(job.Secrets))
+	for name, value := range job.Variables {
+		vars[name] = value
+	}
+	for name, value := range job.Secrets {
+		vars[name] = value
+	}
+	return Run(ctx, scenario, Options{
+		Host:      job.TargetHost,
+		Users:     job.Users,
+		SpawnRate: job.SpawnRate,
+		Duration:  job.Duration,
+		Stages:    job.Stages,
+		Variables: vars,
+		Dir:       job.Dir,
+		Log:       out,
+	})
+}
+
+func (e *Engine) Reports(job *engine.Job) []engine.Report {
+	return engine.Existing(job, engine.Report{Name: locust.StatsFile, Type: "csv", File: locust.StatsFile})
+}
+
+// Failed treats any failed request as a failed run, like Locust.
+func (e *Engine) Failed(job *engine.Job, runErr error) bool {
+	return runErr != nil
+}
+
+// Metrics and Tail read the Locust-compatible statistics the engine writes.
+func (e *Engine) Metrics(dir string) ([]model.EndpointMetric, []model.MetricSample, error) {
+	return locust.Metrics(dir)
+}
+
+func (e *Engine) Tail(dir string) engine.Tailer {
+	return locust.Tail(dir)
+}
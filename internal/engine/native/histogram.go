@@ -0,0 +1,128 @@
+package native
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// subBits sets the histogram's precision: values below 2^subBits
+// microseconds are exact and larger ones fall in buckets 1/64 of their
+// magnitude wide, so any percentile is within about 1.6%.
+const (
+	subBits  = 7
+	subCount = 1 << subBits
+	halfSub  = subCount / 2
+)
+
+// Histogram records latencies in microsecond buckets of bounded relative
+// error, like an HDR histogram with two significant digits. It keeps
+// constant memory however many samples it sees. It is not safe for
+// concurrent use.
+type Histogram struct {
+	counts []uint64
+	total  uint64
+	sum    uint64
+	min    uint64
+	max    uint64
+}
+
+func (h *Histogram) Record(d time.Duration) {
+	v := uint64(0)
+	if d > 0 {
+		v = uint64(d / time.Microsecond)
+	}
+	i := bucketOf(v)
+	if i >= len(h.counts) {
+		grown := make([]uint64, i+1)
+		copy(grown, h.counts)
+		h.counts = grown
+	}
+	h.counts[i]++
+	if h.total == 0 || v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+	h.total++
+	h.sum += v
+}
+
+// Merge adds the samples of o.
+func (h *Histogram) Merge(o *Histogram) {
+	if o.total == 0 {
+		return
+	}
+	if len(o.counts) > len(h.counts) {
+		grown := make([]uint64, len(o.counts))
+		copy(grown, h.counts)
+		h.counts = grown
+	}
+	for i, count := range o.counts {
+		h.counts[i] += count
+	}
+	if h.total == 0 || o.min < h.min {
+		h.min = o.min
+	}
+	if o.max > h.max {
+		h.max = o.max
+	}
+	h.total += o.total
+	h.sum += o.sum
+}
+
+func (h *Histogram) Reset() {
+	clear(h.counts)
+	h.total, h.sum, h.min, h.max = 0, 0, 0, 0
+}
+
+func (h *Histogram) Count() uint64 { return h.total }
+
+// Mean, Min, Max and Percentile report milliseconds, 0 when empty.
+func (h *Histogram) Mean() float64 {
+	if h.total == 0 {
+		return 0
+	}
+	return float64(h.sum) / float64(h.total) / 1000
+}
+
+func (h *Histogram) Min() float64 { return float64(h.min) / 1000 }
+
+func (h *Histogram) Max() float64 { return float64(h.max) / 1000 }
+
+// Percentile returns the highest value equivalent to the sample at p
+// percent, clamped to the recorded range.
+func (h *Histogram) Percentile(p float64) float64 {
+	if h.total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(p / 100 * float64(h.total)))
+	rank = max(1, min(rank, h.total))
+	var seen uint64
+	for i, count := range h.counts {
+		seen += count
+		if seen >= rank {
+			v := min(max(bucketHigh(i), h.min), h.max)
+			return float64(v) / 1000
+		}
+	}
+	return h.Max()
+}
+
+func bucketOf(v uint64) int {
+	if v < subCount {
+		return int(v)
+	}
+	shift := bits.Len64(v) - subBits
+	return subCount + (shift-1)*halfSub + int(v>>shift) - halfSub
+}
+
+func bucketHigh(i int) uint64 {
+	if i < subCount {
+		return uint64(i)
+	}
+	shift := (i-subCount)/halfSub + 1
+	mantissa := uint64((i-subCount)%halfSub + halfSub)
+	return (mantissa+1)<<shift - 1
+}
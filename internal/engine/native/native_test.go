@@ -0,0 +1,255 @@
+package native
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bench-hub/internal/engine/locust"
+	"bench-hub/internal/model"
+	"bench-hub/internal/results"
+)
+
+const shopScenario = `name: shop
+think_time: {min: 10ms, max: 20ms}
+headers:
+  Accept: application/json
+setup:
+  - name: login
+    method: post
+    path: /login
+    body: '{"user": "{{USER}}"}'
+    extract:
+      - var: token
+        json: data.token
+requests:
+  - name: list items
+    path: /items
+    headers:
+      Authorization: Bearer {{token}}
+    weight: 3
+    extract:
+      - var: item
+        json: items[1].id
+    assert:
+      status: 200
+      body_contains: '"items"'
+  - name: item
+    path: /items/{{item}}
+    headers:
+      Authorization: Bearer {{token}}
+  - name: broken
+    path: /broken
+    weight: 1
+`
+
+func shopServer(t *testing.T) (*httptest.Server, *atomic.Int64) {
+	var unauthorized atomic.Int64
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"token": "t-123"}}`))
+	})
+	mux.HandleFunc("GET /items", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t-123" {
+			unauthorized.Add(1)
+		}
+		w.Write([]byte(`{"items": [{"id": 1}, {"id": 2}]}`))
+	})
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "2" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	mux.HandleFunc("GET /broken", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &unauthorized
+}
+
+func TestCheck(t *testing.T) {
+	issues := Check(shopScenario, []model.ScriptParameter{{Name: "USER"}})
+	if len(issues) != 0 {
+		t.Fatalf("unexpected issues %+v", issues)
+	}
+	issues = Check(shopScenario, nil)
+	if len(issues) != 1 || issues[0].Severity != model.IssueWarning || issues[0].Line != 6 {
+		t.Fatalf("expected a warning for USER on line 6, got %+v", issues)
+	}
+
+	bad := `model: open
+requests:
+  - path: /a
+    method: fetch
+    extract:
+      - var: x
+        regex: "("
+`
+	var messages []string
+	for _, issue := range Check(bad, nil) {
+		if issue.Severity == model.IssueError {
+			messages = append(messages, issue.Message)
+		}
+	}
+	if len(messages) != 3 {
+		t.Fatalf("expected rate, method and regex errors, got %q", messages)
+	}
+
+	if issues := Check("requests: [", nil); len(issues) != 1 || issues[0].Severity != model.IssueError {
+		t.Fatalf("expected a syntax error, got %+v", issues)
+	}
+	if _, err := Load(`{"requests": [{"path": "/ping", "weight": 0}]}`); !errors.Is(err, ErrInvalidScenario) {
+		t.Fatalf("expected all-zero weights to be rejected, got %v", err)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	var h Histogram
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+	if h.Count() != 1000 || h.Min() != 1 || h.Max() != 1000 {
+		t.Fatalf("unexpected count %d min %v max %v", h.Count(), h.Min(), h.Max())
+	}
+	for _, tc := range []struct{ p, want float64 }{{50, 500}, {95, 950}, {99, 990}, {100, 1000}} {
+		got := h.Percentile(tc.p)
+		if got < tc.want || got > tc.want*1.02 {
+			t.Fatalf("p%v = %v, want within 2%% above %v", tc.p, got, tc.want)
+		}
+	}
+	if mean := h.Mean(); mean != 500.5 {
+		t.Fatalf("mean = %v", mean)
+	}
+
+	var merged Histogram
+	merged.Record(2 * time.Second)
+	merged.Merge(&h)
+	if merged.Count() != 1001 || merged.Max() != 2000 || merged.Min() != 1 {
+		t.Fatalf("unexpected merge %d %v %v", merged.Count(), merged.Min(), merged.Max())
+	}
+	h.Reset()
+	if h.Count() != 0 || h.Percentile(50) != 0 {
+		t.Fatal("reset histogram is not empty")
+	}
+}
+
+func TestRunClosed(t *testing.T) {
+	server, unauthorized := shopServer(t)
+	sc, err := Load(shopScenario)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	dir := t.TempDir()
+	var logs strings.Builder
+	err = Run(context.Background(), sc, Options{
+		Host:      server.URL,
+		Users:     4,
+		SpawnRate: 20,
+		Duration:  2,
+		Variables: map[string]string{"USER": "alice"},
+		Dir:       dir,
+		Log:       &logs,
+	})
+	if !errors.Is(err, ErrRequestsFailed) {
+		t.Fatalf("expected the broken endpoint to fail the run, got %v", err)
+	}
+	if unauthorized.Load() != 0 {
+		t.Fatalf("%d requests missed the extracted token", unauthorized.Load())
+	}
+
+	metrics, samples, err := locust.Metrics(dir)
+	if err != nil {
+		t.Fatalf("parse results: %v", err)
+	}
+	byName := map[string]model.EndpointMetric{}
+	for _, m := range metrics {
+		byName[m.Name] = m
+	}
+	if byName["login"].RequestCount != 4 || byName["login"].FailureCount != 0 {
+		t.Fatalf("expected one login per user, got %+v", byName["login"])
+	}
+	if byName["list items"].RequestCount == 0 || byName["list items"].FailureCount != 0 || byName["list items"].Method != "GET" {
+		t.Fatalf("unexpected list items %+v", byName["list items"])
+	}
+	// Users that pick "item" before "list items" request {{item}} unexpanded;
+	// later ones use the extracted id.
+	if item := byName["item"]; item.RequestCount == 0 || item.FailureCount >= item.RequestCount {
+		t.Fatalf("item never used the extracted id: %+v", item)
+	}
+	if byName["broken"].FailureCount == 0 || byName["broken"].FailureCount != byName["broken"].RequestCount {
+		t.Fatalf("expected every broken request to fail, got %+v", byName["broken"])
+	}
+	agg := byName[results.AggregatedName]
+	if agg.RequestCount < 40 || agg.P95Ms <= 0 {
+		t.Fatalf("unexpected aggregate %+v", agg)
+	}
+	if len(samples) < 2 || samples[len(samples)-1].TotalRequests != agg.RequestCount {
+		t.Fatalf("unexpected history %+v", samples)
+	}
+	if !strings.Contains(logs.String(), "Ramping complete: 4 users") {
+		t.Fatalf("unexpected log:\n%s", logs.String())
+	}
+}
+
+func TestRunOpenAndStop(t *testing.T) {
+	server, _ := shopServer(t)
+	sc, err := Load(`model: open
+rate: 50
+requests:
+  - path: /items
+`)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Second, cancel)
+	dir := t.TempDir()
+	start := time.Now()
+	err = Run(ctx, sc, Options{Host: server.URL, Users: 5, SpawnRate: 1, Duration: 30, Dir: dir})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("stop took %s", elapsed)
+	}
+
+	metrics, _, err := locust.Metrics(dir)
+	if err != nil {
+		t.Fatalf("parse results: %v", err)
+	}
+	agg := metrics[len(metrics)-1]
+	// 50 arrivals per second for about a second, each one request.
+	if agg.RequestCount < 30 || agg.RequestCount > 70 {
+		t.Fatalf("unexpected request count %d", agg.RequestCount)
+	}
+}
+
+func TestJSONValue(t *testing.T) {
+	doc := map[string]interface{}{
+		"data": map[string]interface{}{
+			"items": []interface{}{map[string]interface{}{"id": 7.0, "ok": true}},
+			"name":  "x",
+		},
+	}
+	for path, want := range map[string]string{
+		"data.items[0].id":  "7",
+		"$.data.items.0.ok": "true",
+		"data.name":         "x",
+		"data.items[0]":     `{"id":7,"ok":true}`,
+	} {
+		if got, ok := jsonValue(doc, path); !ok || got != want {
+			t.Fatalf("%s = %q, %v; want %q", path, got, ok, want)
+		}
+	}
+	if _, ok := jsonValue(doc, "data.items[3]"); ok {
+		t.Fatal("expected a missing index")
+	}
+}
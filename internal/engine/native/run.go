@@ -0,0 +1,513 @@
+package native
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"bench-hub/internal/engine"
+	"bench-hub/internal/loadshape"
+	"bench-hub/internal/model"
+)
+
+const (
+	// maxBody bounds how much of a response is kept for assertions and
+	// extraction; the rest is read and discarded.
+	maxBody = 1 << 20
+
+	controlInterval = 100 * time.Millisecond
+	historyInterval = time.Second
+)
+
+// ErrRequestsFailed reports a run in which at least one request failed,
+// mirroring Locust's exit code.
+var ErrRequestsFailed = engine.ErrRequestsFailed
+
+// Options are the task settings a scenario runs with.
+type Options struct {
+	// Host is the base URL relative request paths are resolved against.
+	Host string
+	// Users, SpawnRate and Duration are the task's linear ramp, used when
+	// there are no Stages.
+	Users     int
+	SpawnRate int
+	Duration  int
+	Stages    []model.LoadStage
+	// Variables are the task's parameters and secrets, readable as {{NAME}}.
+	Variables map[string]string
+	// Dir receives the Locust-format report CSV files.
+	Dir string
+	Log io.Writer
+}
+
+type generator struct {
+	sc        *Scenario
+	opts      Options
+	stages    []model.LoadStage
+	host      string
+	stats     *stats
+	transport *http.Transport
+	log       *log.Logger
+	weights   []int
+	total     int
+	active    atomic.Int64
+}
+
+// Run executes the scenario until the stages end or ctx is cancelled, and
+// writes report_stats.csv, report_stats_history.csv and
+// report_failures.csv to opts.Dir. History rows are appended every second
+// while the run is in progress.
+func Run(ctx context.Context, sc *Scenario, opts Options) error {
+	e := &generator{
+		sc:     sc,
+		opts:   opts,
+		stages: opts.Stages,
+		host:   strings.TrimRight(opts.Host, "/"),
+		stats:  newStats(),
+		log:    log.New(opts.Log, "", log.LstdFlags),
+	}
+	if opts.Log == nil {
+		e.log.SetOutput(io.Discard)
+	}
+	if len(e.stages) == 0 {
+		e.stages = []model.LoadStage{{DurationSeconds: opts.Duration, Users: opts.Users, SpawnRate: opts.SpawnRate}}
+	}
+	if e.host != "" && !strings.Contains(e.host, "://") {
+		e.host = "http://" + e.host
+	}
+	for _, req := range append(slices.Clone(sc.Setup), sc.Requests...) {
+		if e.host == "" && !absolute(req.Path) {
+			return fmt.Errorf("%w: request %q needs a target host", ErrInvalidScenario, req.Name)
+		}
+	}
+	for _, req := range sc.Requests {
+		weight := 1
+		if req.Weight != nil {
+			weight = *req.Weight
+		}
+		e.total += weight
+		e.weights = append(e.weights, e.total)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 0
+	transport.MaxIdleConnsPerHost = max(loadshape.Peak(e.stages), 1)
+	e.transport = transport
+	defer transport.CloseIdleConnections()
+
+	historyFile, history, err := openHistory(opts.Dir)
+	if err != nil {
+		return err
+	}
+	defer historyFile.Close()
+
+	duration := time.Duration(loadshape.Duration(e.stages)) * time.Second
+	runCtx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(historyInterval)
+		defer ticker.Stop()
+		last := start
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				e.stats.writeHistory(history, now, int(e.active.Load()), now.Sub(last))
+				last = now
+			}
+		}
+	}()
+
+	if sc.Model == ModelOpen {
+		e.log.Printf("Starting scenario %q: open model, %.2f arrivals/s, at most %d in flight, %s", sc.Name, sc.Rate, loadshape.Peak(e.stages), duration)
+		e.open(runCtx)
+	} else {
+		e.log.Printf("Starting scenario %q: closed model, %d stage(s), %s", sc.Name, len(e.stages), duration)
+		e.closed(runCtx)
+	}
+	close(done)
+	if ctx.Err() != nil {
+		e.log.Printf("Stopping on request")
+	} else {
+		e.log.Printf("Run time limit reached, stopping")
+	}
+
+	elapsed := time.Since(start)
+	e.stats.writeHistory(history, time.Now(), 0, elapsed%historyInterval)
+	if err := history.Error(); err != nil {
+		return err
+	}
+	if err := e.stats.writeFiles(opts.Dir, elapsed); err != nil {
+		return err
+	}
+	e.summary()
+
+	if _, failures := e.stats.totals(); failures > 0 {
+		return ErrRequestsFailed
+	}
+	return nil
+}
+
+// closed follows the stages, starting or stopping users at each stage's
+// spawn rate, until ctx ends.
+func (e *generator) closed(ctx context.Context) {
+	var users []context.CancelFunc
+	var wg sync.WaitGroup
+	ticker := time.NewTicker(controlInterval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	last := start
+	budget := 0.0
+	reported := -1
+	for {
+		now := time.Now()
+		stage := e.stageAt(now.Sub(start))
+		budget += float64(stage.SpawnRate) * now.Sub(last).Seconds()
+		last = now
+
+		diff := stage.Users - len(users)
+		n := diff
+		if n < 0 {
+			n = -n
+		}
+		if stage.SpawnRate > 0 {
+			n = min(n, int(budget))
+			budget -= float64(n)
+		}
+		switch {
+		case diff > 0:
+			for i := 0; i < n; i++ {
+				userCtx, cancel := context.WithCancel(ctx)
+				users = append(users, cancel)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					e.user(userCtx, false)
+				}()
+			}
+		case diff < 0:
+			for i := 0; i < n; i++ {
+				users[len(users)-1]()
+				users = users[:len(users)-1]
+			}
+		default:
+			budget = 0
+		}
+		if len(users) == stage.Users && reported != stage.Users {
+			e.log.Printf("Ramping complete: %d users", stage.Users)
+			reported = stage.Users
+		}
+
+		select {
+		case <-ctx.Done():
+			for _, cancel := range users {
+				cancel()
+			}
+			wg.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// open starts users at the scenario's rate regardless of how fast earlier
+// ones finish, dropping arrivals while the in-flight limit is reached.
+func (e *generator) open(ctx context.Context) {
+	limit := int64(max(loadshape.Peak(e.stages), 1))
+	var wg sync.WaitGroup
+	ticker := time.NewTicker(controlInterval / 10)
+	defer ticker.Stop()
+
+	start := time.Now()
+	var arrived, dropped int64
+	for {
+		due := int64(time.Since(start).Seconds() * e.sc.Rate)
+		for ; arrived < due; arrived++ {
+			if e.active.Load() >= limit {
+				dropped++
+				continue
+			}
+			e.active.Add(1)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer e.active.Add(-1)
+				e.user(ctx, true)
+			}()
+		}
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			if dropped > 0 {
+				e.log.Printf("%d of %d arrivals dropped: %d users in flight", dropped, arrived, limit)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *generator) stageAt(elapsed time.Duration) model.LoadStage {
+	end := time.Duration(0)
+	for _, stage := range e.stages {
+		end += time.Duration(stage.DurationSeconds) * time.Second
+		if elapsed < end {
+			return stage
+		}
+	}
+	return e.stages[len(e.stages)-1]
+}
+
+// user runs the setup requests and then weighted requests with think time
+// until ctx ends; an arrival of the open model runs one request and leaves.
+// Each user has its own cookies and variables.
+func (e *generator) user(ctx context.Context, arrival bool) {
+	if !arrival {
+		e.active.Add(1)
+		defer e.active.Add(-1)
+	}
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Transport: e.transport, Jar: jar, Timeout: time.Duration(e.sc.Timeout)}
+	vars := make(map[string]string, len(e.opts.Variables))
+	for name, value := range e.opts.Variables {
+		vars[name] = value
+	}
+
+	for i := range e.sc.Setup {
+		if ctx.Err() != nil {
+			return
+		}
+		e.do(ctx, client, &e.sc.Setup[i], vars)
+	}
+	for ctx.Err() == nil {
+		e.do(ctx, client, e.pick(), vars)
+		if arrival || !e.think(ctx) {
+			return
+		}
+	}
+}
+
+func (e *generator) pick() *Request {
+	n := rand.IntN(e.total)
+	i, _ := slices.BinarySearch(e.weights, n+1)
+	return &e.sc.Requests[i]
+}
+
+func (e *generator) think(ctx context.Context) bool {
+	pause := e.sc.ThinkTime.Min
+	if spread := e.sc.ThinkTime.Max - e.sc.ThinkTime.Min; spread > 0 {
+		pause += time.Duration(rand.Int64N(int64(spread)))
+	}
+	if pause <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(pause)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// do sends one request and records it unless the run ended while it was in
+// flight.
+func (e *generator) do(ctx context.Context, client *http.Client, req *Request, vars map[string]string) {
+	target := expand(req.Path, vars)
+	if !absolute(target) {
+		target = e.host + "/" + strings.TrimLeft(target, "/")
+	}
+	var body io.Reader
+	if req.Body != "" {
+		body = strings.NewReader(expand(req.Body, vars))
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, target, body)
+	if err != nil {
+		e.stats.record(req.Method, req.Name, 0, 0, err.Error())
+		return
+	}
+	for name, value := range e.sc.Headers {
+		httpReq.Header.Set(name, expand(value, vars))
+	}
+	for name, value := range req.Headers {
+		httpReq.Header.Set(name, expand(value, vars))
+	}
+
+	start := time.Now()
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		if ctx.Err() == nil {
+			e.stats.record(req.Method, req.Name, time.Since(start), 0, errorMessage(err))
+		}
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	size := int64(len(data))
+	if err == nil {
+		var rest int64
+		rest, err = io.Copy(io.Discard, resp.Body)
+		size += rest
+	}
+	resp.Body.Close()
+	latency := time.Since(start)
+	if err != nil {
+		if ctx.Err() == nil {
+			e.stats.record(req.Method, req.Name, latency, size, errorMessage(err))
+		}
+		return
+	}
+
+	failure := verify(req, resp, data, latency)
+	if failure == "" {
+		failure = extract(req, resp, data, vars)
+	}
+	e.stats.record(req.Method, req.Name, latency, size, failure)
+}
+
+func verify(req *Request, resp *http.Response, body []byte, latency time.Duration) string {
+	a := req.Assert
+	if len(a.Status) > 0 {
+		if !slices.Contains(a.Status, resp.StatusCode) {
+			return fmt.Sprintf("unexpected status %d", resp.StatusCode)
+		}
+	} else if resp.StatusCode >= 400 {
+		return fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+	if a.BodyContains != "" && !strings.Contains(string(body), a.BodyContains) {
+		return fmt.Sprintf("response does not contain %q", a.BodyContains)
+	}
+	if limit := time.Duration(a.MaxLatency); limit > 0 && latency > limit {
+		return fmt.Sprintf("latency above %s", limit)
+	}
+	return ""
+}
+
+// extract stores the request's extracted values in vars and reports the
+// first one that is missing.
+func extract(req *Request, resp *http.Response, body []byte, vars map[string]string) string {
+	var doc interface{}
+	parsed := false
+	for i, ex := range req.Extract {
+		value, ok := "", false
+		switch {
+		case ex.Header != "":
+			value = resp.Header.Get(ex.Header)
+			ok = value != ""
+		case ex.Regex != "":
+			value, ok = regexValue(req.re[i], body)
+		case ex.JSON != "":
+			if !parsed {
+				parsed = true
+				if err := json.Unmarshal(body, &doc); err != nil {
+					return "extract " + ex.Var + ": response is not JSON"
+				}
+			}
+			value, ok = jsonValue(doc, ex.JSON)
+		}
+		if !ok {
+			return "extract " + ex.Var + ": not found"
+		}
+		vars[ex.Var] = value
+	}
+	return ""
+}
+
+func regexValue(re *regexp.Regexp, body []byte) (string, bool) {
+	if re == nil {
+		return "", false
+	}
+	m := re.FindSubmatch(body)
+	if m == nil {
+		return "", false
+	}
+	if len(m) > 1 {
+		return string(m[1]), true
+	}
+	return string(m[0]), true
+}
+
+// jsonValue walks a dotted path such as "data.items[0].id"; indexes may
+// also be written as ".0".
+func jsonValue(doc interface{}, path string) (string, bool) {
+	path = strings.NewReplacer("[", ".", "]", "").Replace(strings.TrimPrefix(path, "$."))
+	for _, key := range strings.Split(path, ".") {
+		if key == "" {
+			continue
+		}
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[key]
+			if !ok {
+				return "", false
+			}
+			doc = value
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return "", false
+			}
+			doc = node[i]
+		default:
+			return "", false
+		}
+	}
+	switch value := doc.(type) {
+	case nil:
+		return "", false
+	case string:
+		return value, true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(value), true
+	default:
+		data, _ := json.Marshal(value)
+		return string(data), true
+	}
+}
+
+// errorMessage drops the URL from transport errors so failures group by
+// request name rather than by concrete path.
+func errorMessage(err error) string {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err.Error()
+	}
+	return err.Error()
+}
+
+func absolute(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+func (e *generator) summary() {
+	e.stats.mu.Lock()
+	defer e.stats.mu.Unlock()
+	list := e.stats.sorted()
+	e.log.Printf("%-8s %-40s %9s %9s %9s %9s %9s", "Type", "Name", "# reqs", "# fails", "Avg", "p50", "p95")
+	for _, row := range append(list, aggregated(list)) {
+		e.log.Printf("%-8s %-40s %9d %9d %9.1f %9.1f %9.1f", row.method, row.name, row.total.Count(), row.failures,
+			row.total.Mean(), row.total.Percentile(50), row.total.Percentile(95))
+	}
+}
@@ -0,0 +1,361 @@
+// Package native is bench-hub's built-in HTTP load engine. Its scripts are
+// declarative YAML or JSON scenarios that run in-process, without an
+// external engine, and it writes Locust-format CSV so results, live
+// metrics and comparisons treat its runs like any other.
+package native
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/scriptparams"
+)
+
+const (
+	ModelClosed = "closed"
+	ModelOpen   = "open"
+
+	defaultTimeout = 30 * time.Second
+)
+
+var ErrInvalidScenario = errors.New("invalid scenario")
+
+// placeholder matches {{NAME}} references to task variables, secrets and
+// extracted values.
+var placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Scenario is a load test of virtual users that each run the setup requests
+// once and then pick weighted requests until the run ends.
+//
+// In the closed model the task's users loop with think time between
+// requests. In the open model new users arrive at Rate per second, run the
+// setup and one weighted request and leave; the task's users cap how many
+// are in flight.
+type Scenario struct {
+	Name      string            `yaml:"name"`
+	Model     string            `yaml:"model"`
+	Rate      float64           `yaml:"rate"`
+	ThinkTime ThinkTime         `yaml:"think_time"`
+	Timeout   Duration          `yaml:"timeout"`
+	Headers   map[string]string `yaml:"headers"`
+	Setup     []Request         `yaml:"setup"`
+	Requests  []Request         `yaml:"requests"`
+}
+
+type Request struct {
+	// Name groups the request in reports; it defaults to the path.
+	Name    string            `yaml:"name"`
+	Method  string            `yaml:"method"`
+	Path    string            `yaml:"path"`
+	Headers map[string]string `yaml:"headers"`
+	Body    string            `yaml:"body"`
+	Weight  *int              `yaml:"weight"`
+	Extract []Extract         `yaml:"extract"`
+	Assert  Assert            `yaml:"assert"`
+
+	line int
+	re   []*regexp.Regexp
+}
+
+// Extract stores one value of a response in the user's variables, read from
+// a dotted JSON path, the first group of a regular expression or a header.
+type Extract struct {
+	Var    string `yaml:"var"`
+	JSON   string `yaml:"json"`
+	Regex  string `yaml:"regex"`
+	Header string `yaml:"header"`
+}
+
+// Assert lists what makes a response a success. Without a status list any
+// status below 400 passes, as in Locust.
+type Assert struct {
+	Status       StatusList `yaml:"status"`
+	BodyContains string     `yaml:"body_contains"`
+	MaxLatency   Duration   `yaml:"max_latency"`
+}
+
+// ThinkTime is a fixed pause, e.g. "1s", or a uniform range {min, max}.
+type ThinkTime struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+func (t *ThinkTime) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var d Duration
+		if err := node.Decode(&d); err != nil {
+			return err
+		}
+		t.Min, t.Max = time.Duration(d), time.Duration(d)
+		return nil
+	}
+	var r struct {
+		Min Duration `yaml:"min"`
+		Max Duration `yaml:"max"`
+	}
+	if err := node.Decode(&r); err != nil {
+		return err
+	}
+	t.Min, t.Max = time.Duration(r.Min), time.Duration(r.Max)
+	return nil
+}
+
+// Duration reads "250ms"-style strings and plain numbers of seconds.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: duration must be a scalar", node.Line)
+	}
+	if seconds, err := strconv.ParseFloat(node.Value, 64); err == nil {
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+	parsed, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid duration %q", node.Line, node.Value)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// StatusList reads a single status code or a list of them.
+type StatusList []int
+
+func (s *StatusList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var code int
+		if err := node.Decode(&code); err != nil {
+			return err
+		}
+		*s = StatusList{code}
+		return nil
+	}
+	var codes []int
+	if err := node.Decode(&codes); err != nil {
+		return err
+	}
+	*s = codes
+	return nil
+}
+
+func (r *Request) UnmarshalYAML(node *yaml.Node) error {
+	type plain Request
+	if err := node.Decode((*plain)(r)); err != nil {
+		return err
+	}
+	r.line = node.Line
+	return nil
+}
+
+// Parse reads a YAML or JSON scenario and applies its defaults. It does not
+// validate it; see Check.
+func Parse(content string) (*Scenario, error) {
+	var sc Scenario
+	if err := yaml.Unmarshal([]byte(content), &sc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidScenario, err)
+	}
+	if sc.Model == "" {
+		sc.Model = ModelClosed
+	}
+	if sc.Timeout <= 0 {
+		sc.Timeout = Duration(defaultTimeout)
+	}
+	for _, list := range [][]Request{sc.Setup, sc.Requests} {
+		for i := range list {
+			req := &list[i]
+			req.Method = strings.ToUpper(req.Method)
+			if req.Method == "" {
+				req.Method = http.MethodGet
+			}
+			if req.Name == "" {
+				req.Name = req.Path
+			}
+			for _, ex := range req.Extract {
+				var re *regexp.Regexp
+				if ex.Regex != "" {
+					re, _ = regexp.Compile(ex.Regex)
+				}
+				req.re = append(req.re, re)
+			}
+		}
+	}
+	return &sc, nil
+}
+
+// Load parses and checks a scenario, failing on the first error.
+func Load(content string) (*Scenario, error) {
+	sc, err := Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	for _, issue := range sc.check(nil) {
+		if issue.Severity == model.IssueError {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidScenario, issue.Message)
+		}
+	}
+	return sc, nil
+}
+
+// Check validates a scenario. Placeholders must name a declared parameter,
+// a platform property or a value some request extracts; others only warn,
+// since they may be secrets.
+func Check(content string, params []model.ScriptParameter) []model.ScriptIssue {
+	sc, err := Parse(content)
+	if err != nil {
+		return []model.ScriptIssue{{Severity: model.IssueError, Line: yamlLine(err), Message: err.Error()}}
+	}
+	return sc.check(params)
+}
+
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+func yamlLine(err error) int {
+	if m := yamlLinePattern.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return line
+	}
+	return 0
+}
+
+func (sc *Scenario) check(params []model.ScriptParameter) []model.ScriptIssue {
+	var issues []model.ScriptIssue
+	fail := func(line int, format string, args ...interface{}) {
+		issues = append(issues, model.ScriptIssue{Severity: model.IssueError, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(line int, format string, args ...interface{}) {
+		issues = append(issues, model.ScriptIssue{Severity: model.IssueWarning, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch sc.Model {
+	case ModelClosed:
+	case ModelOpen:
+		if sc.Rate <= 0 {
+			fail(0, "open model needs a rate above 0")
+		}
+	default:
+		fail(0, "model must be %s or %s", ModelClosed, ModelOpen)
+	}
+	if sc.ThinkTime.Min < 0 || sc.ThinkTime.Max < sc.ThinkTime.Min {
+		fail(0, "think_time max must not be below min")
+	}
+	if len(sc.Requests) == 0 {
+		fail(0, "scenario has no requests")
+	}
+
+	known := map[string]bool{}
+	for _, param := range params {
+		known[param.Name] = true
+	}
+	for _, list := range [][]Request{sc.Setup, sc.Requests} {
+		for _, req := range list {
+			for _, ex := range req.Extract {
+				known[ex.Var] = true
+			}
+		}
+	}
+
+	for _, ref := range references("", "", sc.Headers) {
+		if !known[ref] && !scriptparams.Reserved(strings.ToLower(ref)) {
+			warn(0, "headers read {{%s}}, which is not a parameter or extracted value", ref)
+		}
+	}
+
+	weighted := false
+	for i, list := range [][]Request{sc.Setup, sc.Requests} {
+		for _, req := range list {
+			if req.Path == "" {
+				fail(req.line, "request %q has no path", req.Name)
+			}
+			if !validMethod(req.Method) {
+				fail(req.line, "request %q has unknown method %s", req.Name, req.Method)
+			}
+			if req.Weight != nil && (*req.Weight < 0 || i == 0) {
+				fail(req.line, "request %q: weight must be 0 or more and is only valid outside setup", req.Name)
+			}
+			if i == 1 && (req.Weight == nil || *req.Weight > 0) {
+				weighted = true
+			}
+			for j, ex := range req.Extract {
+				sources := 0
+				for _, source := range []string{ex.JSON, ex.Regex, ex.Header} {
+					if source != "" {
+						sources++
+					}
+				}
+				if ex.Var == "" || sources != 1 {
+					fail(req.line, "request %q: extract needs a var and one of json, regex or header", req.Name)
+				}
+				if ex.Regex != "" && req.re[j] == nil {
+					fail(req.line, "request %q: invalid regex %q", req.Name, ex.Regex)
+				}
+			}
+			for _, ref := range references(req.Path, req.Body, req.Headers) {
+				if !known[ref] && !scriptparams.Reserved(strings.ToLower(ref)) {
+					warn(req.line, "request %q reads {{%s}}, which is not a parameter or extracted value", req.Name, ref)
+				}
+			}
+		}
+	}
+	if len(sc.Requests) > 0 && !weighted {
+		fail(0, "every request has weight 0")
+	}
+	return issues
+}
+
+func validMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func references(path, body string, headers map[string]string) []string {
+	seen := map[string]bool{}
+	var refs []string
+	add := func(s string) {
+		for _, m := range placeholder.FindAllStringSubmatch(s, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				refs = append(refs, m[1])
+			}
+		}
+	}
+	add(path)
+	add(body)
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add(headers[name])
+	}
+	return refs
+}
+
+// expand replaces placeholders with the user's variables, leaving unknown
+// ones as written.
+func expand(s string, vars map[string]string) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+	return placeholder.ReplaceAllStringFunc(s, func(m string) string {
+		name := placeholder.FindStringSubmatch(m)[1]
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		return m
+	})
+}
@@ -0,0 +1,252 @@
+package native
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"bench-hub/internal/engine/locust"
+	"bench-hub/internal/results"
+)
+
+const failuresFile = "report_failures.csv"
+
+var (
+	percentiles = []float64{50, 66, 75, 80, 90, 95, 98, 99, 99.9, 99.99, 100}
+
+	percentileColumns = []string{"50%", "66%", "75%", "80%", "90%", "95%", "98%", "99%", "99.9%", "99.99%", "100%"}
+)
+
+type statsKey struct {
+	method string
+	name   string
+}
+
+type failureKey struct {
+	statsKey
+	message string
+}
+
+type entry struct {
+	statsKey
+	total          Histogram
+	window         Histogram
+	failures       int64
+	windowFailures int64
+	contentBytes   int64
+}
+
+// stats aggregates samples per request name and method the way Locust
+// does: totals for the run and a window covering the last history row.
+type stats struct {
+	mu       sync.Mutex
+	entries  map[statsKey]*entry
+	failures map[failureKey]int64
+}
+
+func newStats() *stats {
+	return &stats{
+		entries:  map[statsKey]*entry{},
+		failures: map[failureKey]int64{},
+	}
+}
+
+// record adds one response; a non-empty failure marks it failed.
+func (s *stats) record(method, name string, latency time.Duration, size int64, failure string) {
+	key := statsKey{method: method, name: name}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.entries[key]
+	if e == nil {
+		e = &entry{statsKey: key}
+		s.entries[key] = e
+	}
+	e.total.Record(latency)
+	e.window.Record(latency)
+	e.contentBytes += size
+	if failure != "" {
+		e.failures++
+		e.windowFailures++
+		s.failures[failureKey{statsKey: key, message: failure}]++
+	}
+}
+
+func (s *stats) sorted() []*entry {
+	list := make([]*entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].name != list[j].name {
+			return list[i].name < list[j].name
+		}
+		return list[i].method < list[j].method
+	})
+	return list
+}
+
+// aggregated sums the entries into the "Aggregated" row.
+func aggregated(list []*entry) *entry {
+	agg := &entry{statsKey: statsKey{name: results.AggregatedName}}
+	for _, e := range list {
+		agg.total.Merge(&e.total)
+		agg.window.Merge(&e.window)
+		agg.failures += e.failures
+		agg.windowFailures += e.windowFailures
+		agg.contentBytes += e.contentBytes
+	}
+	return agg
+}
+
+func (s *stats) totals() (requests, failures int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		requests += int64(e.total.Count())
+		failures += e.failures
+	}
+	return requests, failures
+}
+
+var historyHeader = append(append([]string{"Timestamp", "User Count", "Type", "Name", "Requests/s", "Failures/s"}, percentileColumns...),
+	"Total Request Count", "Total Failure Count", "Total Median Response Time", "Total Average Response Time",
+	"Total Min Response Time", "Total Max Response Time", "Total Average Content Size")
+
+// writeHistory appends a row per entry and the aggregated row covering the
+// window since the previous call, then starts a new window.
+func (s *stats) writeHistory(w *csv.Writer, now time.Time, users int, window time.Duration) {
+	s.mu.Lock()
+	list := s.sorted()
+	rows := append(list, aggregated(list))
+	seconds := window.Seconds()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	for _, e := range rows {
+		record := []string{timestamp, strconv.Itoa(users), e.method, e.name,
+			rate(int64(e.window.Count()), seconds), rate(e.windowFailures, seconds)}
+		for _, p := range percentiles {
+			record = append(record, ms(e.window.Percentile(p)))
+		}
+		record = append(record,
+			strconv.FormatUint(e.total.Count(), 10),
+			strconv.FormatInt(e.failures, 10),
+			ms(e.total.Percentile(50)),
+			ms(e.total.Mean()),
+			ms(e.total.Min()),
+			ms(e.total.Max()),
+			ms(e.averageSize()),
+		)
+		_ = w.Write(record)
+	}
+	for _, e := range list {
+		e.window.Reset()
+		e.windowFailures = 0
+	}
+	w.Flush()
+	s.mu.Unlock()
+}
+
+var statsHeader = append([]string{"Type", "Name", "Request Count", "Failure Count", "Median Response Time",
+	"Average Response Time", "Min Response Time", "Max Response Time", "Average Content Size",
+	"Requests/s", "Failures/s"}, percentileColumns...)
+
+// writeFiles writes report_stats.csv and report_failures.csv for the run.
+func (s *stats) writeFiles(dir string, elapsed time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := s.sorted()
+	seconds := elapsed.Seconds()
+	err := writeCSV(filepath.Join(dir, locust.StatsFile), func(w *csv.Writer) {
+		_ = w.Write(statsHeader)
+		for _, e := range append(list, aggregated(list)) {
+			record := []string{e.method, e.name,
+				strconv.FormatUint(e.total.Count(), 10),
+				strconv.FormatInt(e.failures, 10),
+				ms(e.total.Percentile(50)),
+				ms(e.total.Mean()),
+				ms(e.total.Min()),
+				ms(e.total.Max()),
+				ms(e.averageSize()),
+				rate(int64(e.total.Count()), seconds),
+				rate(e.failures, seconds),
+			}
+			for _, p := range percentiles {
+				record = append(record, ms(e.total.Percentile(p)))
+			}
+			_ = w.Write(record)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	keys := make([]failureKey, 0, len(s.failures))
+	for key := range s.failures {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if s.failures[keys[i]] != s.failures[keys[j]] {
+			return s.failures[keys[i]] > s.failures[keys[j]]
+		}
+		return keys[i].message < keys[j].message
+	})
+	return writeCSV(filepath.Join(dir, failuresFile), func(w *csv.Writer) {
+		_ = w.Write([]string{"Method", "Name", "Error", "Occurrences"})
+		for _, key := range keys {
+			_ = w.Write([]string{key.method, key.name, key.message, strconv.FormatInt(s.failures[key], 10)})
+		}
+	})
+}
+
+func (e *entry) averageSize() float64 {
+	if e.total.Count() == 0 {
+		return 0
+	}
+	return float64(e.contentBytes) / float64(e.total.Count())
+}
+
+func writeCSV(path string, fill func(*csv.Writer)) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(file)
+	fill(w)
+	w.Flush()
+	if err := w.Error(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func openHistory(dir string) (*os.File, *csv.Writer, error) {
+	file, err := os.Create(filepath.Join(dir, locust.HistoryFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	w := csv.NewWriter(file)
+	if err := w.Write(historyHeader); err != nil {
+		_ = file.Close()
+		return nil, nil, err
+	}
+	w.Flush()
+	return file, w, nil
+}
+
+func rate(count int64, seconds float64) string {
+	if seconds <= 0 {
+		return "0"
+	}
+	return strconv.FormatFloat(float64(count)/seconds, 'f', 2, 64)
+}
+
+// ms formats a millisecond value with two decimals; Locust rounds, but
+// in-process latencies are often below a millisecond.
+func ms(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
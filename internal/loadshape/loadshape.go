@@ -0,0 +1,118 @@
+// Package loadshape expands task load profiles into stages. Each engine
+// renders the stages in its own terms.
+package loadshape
+
+import (
+	"errors"
+	"fmt"
+
+	"bench-hub/internal/model"
+)
+
+var ErrInvalidProfile = errors.New("invalid load profile")
+
+const (
+	maxStages    = 100
+	defaultSteps = 5
+)
+
+// Stages returns the explicit stages of a profile. Presets are derived from
+// the task's target users, spawn rate and total duration. A nil profile has
+// no stages and keeps the engine's plain linear ramp.
+func Stages(profile *model.LoadProfile, users, spawnRate, duration int) ([]model.LoadStage, error) {
+	if profile == nil {
+		return nil, nil
+	}
+	if profile.Preset != "" && len(profile.Stages) > 0 {
+		return nil, fmt.Errorf("%w: preset and stages are exclusive", ErrInvalidProfile)
+	}
+
+	var stages []model.LoadStage
+	switch profile.Preset {
+	case "":
+		stages = profile.Stages
+	case model.LoadPresetStep:
+		steps := profile.Steps
+		if steps == 0 {
+			steps = defaultSteps
+		}
+		if steps < 0 || steps > users || steps > duration {
+			return nil, fmt.Errorf("%w: steps must be between 1 and both users and duration", ErrInvalidProfile)
+		}
+		for i := 1; i <= steps; i++ {
+			stages = append(stages, model.LoadStage{
+				DurationSeconds: split(duration, steps, i),
+				Users:           (users*i + steps - 1) / steps,
+				SpawnRate:       spawnRate,
+			})
+		}
+	case model.LoadPresetSpike:
+		base := profile.BaseUsers
+		if base == 0 {
+			base = max(1, users/10)
+		}
+		if base < 0 || base >= users || duration < 5 {
+			return nil, fmt.Errorf("%w: spike needs base users below users and at least 5s", ErrInvalidProfile)
+		}
+		burst := max(1, users-base)
+		calm := duration * 2 / 5
+		stages = []model.LoadStage{
+			{DurationSeconds: calm, Users: base, SpawnRate: spawnRate},
+			{DurationSeconds: duration - 2*calm, Users: users, SpawnRate: burst},
+			{DurationSeconds: calm, Users: base, SpawnRate: burst},
+		}
+	case model.LoadPresetSoak:
+		stages = []model.LoadStage{{DurationSeconds: duration, Users: users, SpawnRate: spawnRate}}
+	default:
+		return nil, fmt.Errorf("%w: unknown preset %q", ErrInvalidProfile, profile.Preset)
+	}
+
+	if len(stages) == 0 || len(stages) > maxStages {
+		return nil, fmt.Errorf("%w: between 1 and %d stages required", ErrInvalidProfile, maxStages)
+	}
+	for i, stage := range stages {
+		if stage.DurationSeconds <= 0 || stage.Users < 0 || stage.SpawnRate <= 0 {
+			return nil, fmt.Errorf("%w: stage %d needs a positive duration and spawn rate", ErrInvalidProfile, i+1)
+		}
+	}
+	if Peak(stages) == 0 {
+		return nil, fmt.Errorf("%w: no stage has users", ErrInvalidProfile)
+	}
+	return stages, nil
+}
+
+// split divides total into n parts and returns the i-th (1-based); the last
+// part absorbs the remainder.
+func split(total, n, i int) int {
+	if i == n {
+		return total - (n-1)*(total/n)
+	}
+	return total / n
+}
+
+// Duration is the total length of the stages in seconds.
+func Duration(stages []model.LoadStage) int {
+	total := 0
+	for _, stage := range stages {
+		total += stage.DurationSeconds
+	}
+	return total
+}
+
+// Peak is the highest user count of the stages.
+func Peak(stages []model.LoadStage) int {
+	peak := 0
+	for _, stage := range stages {
+		peak = max(peak, stage.Users)
+	}
+	return peak
+}
+
+// Ramp is how many seconds starting or stopping users takes at spawnRate
+// users per second.
+func Ramp(users, spawnRate int) int {
+	if spawnRate <= 0 {
+		return users
+	}
+	return (users + spawnRate - 1) / spawnRate
+}
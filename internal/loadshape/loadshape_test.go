@@ -0,0 +1,64 @@
+package loadshape
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"bench-hub/internal/model"
+)
+
+func TestStagesPresets(t *testing.T) {
+	step, err := Stages(&model.LoadProfile{Preset: model.LoadPresetStep, Steps: 4}, 100, 10, 600)
+	if err != nil {
+		t.Fatalf("step: %v", err)
+	}
+	want := []model.LoadStage{
+		{DurationSeconds: 150, Users: 25, SpawnRate: 10},
+		{DurationSeconds: 150, Users: 50, SpawnRate: 10},
+		{DurationSeconds: 150, Users: 75, SpawnRate: 10},
+		{DurationSeconds: 150, Users: 100, SpawnRate: 10},
+	}
+	if !reflect.DeepEqual(step, want) {
+		t.Fatalf("step stages: got %+v", step)
+	}
+
+	spike, err := Stages(&model.LoadProfile{Preset: model.LoadPresetSpike}, 200, 5, 100)
+	if err != nil {
+		t.Fatalf("spike: %v", err)
+	}
+	if len(spike) != 3 || spike[0].Users != 20 || spike[1].Users != 200 || spike[1].SpawnRate != 180 || spike[2].Users != 20 {
+		t.Fatalf("spike stages: got %+v", spike)
+	}
+	if Duration(spike) != 100 || Peak(spike) != 200 {
+		t.Fatalf("spike totals: duration %d peak %d", Duration(spike), Peak(spike))
+	}
+
+	if stages, _ := Stages(nil, 10, 1, 60); stages != nil {
+		t.Fatalf("nil profile should have no stages, got %+v", stages)
+	}
+}
+
+func TestStagesRejectsInvalid(t *testing.T) {
+	profiles := []*model.LoadProfile{
+		{Preset: "ramp"},
+		{Preset: model.LoadPresetSoak, Stages: []model.LoadStage{{DurationSeconds: 1, Users: 1, SpawnRate: 1}}},
+		{Stages: []model.LoadStage{{DurationSeconds: 0, Users: 1, SpawnRate: 1}}},
+		{Stages: []model.LoadStage{{DurationSeconds: 10, Users: 0, SpawnRate: 1}}},
+		{},
+	}
+	for _, profile := range profiles {
+		if _, err := Stages(profile, 10, 1, 60); !errors.Is(err, ErrInvalidProfile) {
+			t.Errorf("%+v: expected ErrInvalidProfile, got %v", profile, err)
+		}
+	}
+}
+
+func TestRamp(t *testing.T) {
+	if got := Ramp(25, 10); got != 3 {
+		t.Fatalf("Ramp(25, 10) = %d, want 3", got)
+	}
+	if got := Ramp(5, 0); got != 5 {
+		t.Fatalf("Ramp(5, 0) = %d, want 5", got)
+	}
+}
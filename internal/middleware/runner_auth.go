@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"bench-hub/internal/model"
+)
+
+const RunnerTokenHeader = "X-Runner-Token"
+
+// RunnerAuth guards the endpoints runners call back into. An empty token
+// disables them entirely.
+func RunnerAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader(RunnerTokenHeader)
+		if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			model.JSON(c, http.StatusUnauthorized, model.Fail(1001, "unauthorized"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,32 @@
+package model
+
+type MetricDelta struct {
+	Base       float64  `json:"base"`
+	Candidate  float64  `json:"candidate"`
+	Delta      float64  `json:"delta"`
+	DeltaPct   *float64 `json:"delta_pct"`
+	Regression bool     `json:"regression"`
+}
+
+type EndpointComparison struct {
+	Method     string       `json:"method"`
+	Name       string       `json:"name"`
+	Presence   string       `json:"presence"`
+	P50Ms      *MetricDelta `json:"p50_ms,omitempty"`
+	P90Ms      *MetricDelta `json:"p90_ms,omitempty"`
+	P95Ms      *MetricDelta `json:"p95_ms,omitempty"`
+	P99Ms      *MetricDelta `json:"p99_ms,omitempty"`
+	AvgMs      *MetricDelta `json:"avg_ms,omitempty"`
+	RPS        *MetricDelta `json:"rps,omitempty"`
+	Failures   *MetricDelta `json:"failures,omitempty"`
+	ErrorRate  *MetricDelta `json:"error_rate,omitempty"`
+	Regression bool         `json:"regression"`
+}
+
+type RunComparison struct {
+	BaseRunID      string               `json:"base_run_id"`
+	CandidateRunID string               `json:"candidate_run_id"`
+	TolerancePct   float64              `json:"tolerance_pct"`
+	Regressions    int                  `json:"regressions"`
+	Endpoints      []EndpointComparison `json:"endpoints"`
+}
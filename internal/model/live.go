@@ -0,0 +1,14 @@
+package model
+
+const (
+	LiveEventSnapshot = "snapshot"
+	LiveEventFinished = "finished"
+)
+
+type LiveEvent struct {
+	Type     string        `json:"type"`
+	RunID    string        `json:"run_id"`
+	TaskID   string        `json:"task_id"`
+	Status   string        `json:"status,omitempty"`
+	Snapshot *MetricSample `json:"snapshot,omitempty"`
+}
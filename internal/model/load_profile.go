@@ -0,0 +1,26 @@
+package model
+
+const (
+	LoadPresetStep  = "step"
+	LoadPresetSpike = "spike"
+	LoadPresetSoak  = "soak"
+)
+
+// LoadStage holds Users for DurationSeconds after reaching them at
+// SpawnRate users per second.
+type LoadStage struct {
+	DurationSeconds int `json:"duration_seconds"`
+	Users           int `json:"users"`
+	SpawnRate       int `json:"spawn_rate"`
+}
+
+// LoadProfile shapes a run's load over time, either as explicit stages or as
+// a named preset derived from the task's users, spawn rate and duration.
+type LoadProfile struct {
+	Preset string      `json:"preset,omitempty"`
+	Stages []LoadStage `json:"stages,omitempty"`
+	// Steps is the number of equal steps of the step preset.
+	Steps int `json:"steps,omitempty"`
+	// BaseUsers is the load before and after the burst of the spike preset.
+	BaseUsers int `json:"base_users,omitempty"`
+}
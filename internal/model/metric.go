@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+type EndpointMetric struct {
+	ID             string    `json:"id"`
+	RunID          string    `json:"run_id"`
+	TaskID         string    `json:"task_id"`
+	ReportDir      string    `json:"report_dir"`
+	Method         string    `json:"method"`
+	Name           string    `json:"name"`
+	RequestCount   int64     `json:"request_count"`
+	FailureCount   int64     `json:"failure_count"`
+	AvgMs          float64   `json:"avg_ms"`
+	MinMs          float64   `json:"min_ms"`
+	MaxMs          float64   `json:"max_ms"`
+	P50Ms          float64   `json:"p50_ms"`
+	P90Ms          float64   `json:"p90_ms"`
+	P95Ms          float64   `json:"p95_ms"`
+	P99Ms          float64   `json:"p99_ms"`
+	RPS            float64   `json:"rps"`
+	FailuresPerSec float64   `json:"failures_per_sec"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type MetricSample struct {
+	SampledAt      time.Time `json:"sampled_at"`
+	UserCount      int       `json:"user_count"`
+	RPS            float64   `json:"rps"`
+	FailuresPerSec float64   `json:"failures_per_sec"`
+	P50Ms          float64   `json:"p50_ms"`
+	P95Ms          float64   `json:"p95_ms"`
+	TotalRequests  int64     `json:"total_requests"`
+	TotalFailures  int64     `json:"total_failures"`
+}
+
+type RunMetrics struct {
+	RunID     string           `json:"run_id"`
+	TaskID    string           `json:"task_id"`
+	ReportDir string           `json:"report_dir"`
+	Endpoints []EndpointMetric `json:"endpoints"`
+	Samples   []MetricSample   `json:"samples"`
+}
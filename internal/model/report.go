@@ -6,6 +6,7 @@ type Report struct {
 	ID        string    `json:"id"`
 	TaskID    *string   `json:"task_id"`
 	TaskName  *string   `json:"task_name"`
+	RunID     *string   `json:"run_id"`
 	Name      string    `json:"name"`
 	Type      string    `json:"type"`
 	FilePath  string    `json:"file_path"`
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+type Runner struct {
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	URL             string            `json:"url"`
+	Labels          map[string]string `json:"labels"`
+	MaxJobs         int               `json:"max_jobs"`
+	ActiveJobs      int               `json:"active_jobs"`
+	CPUPercent      float64           `json:"cpu_percent"`
+	Healthy         bool              `json:"healthy"`
+	LastHeartbeatAt time.Time         `json:"last_heartbeat_at"`
+	CreatedAt       time.Time         `json:"created_at"`
+	// HeartbeatAge is how long ago the last heartbeat was, measured on the
+	// database clock.
+	HeartbeatAge time.Duration `json:"-"`
+}
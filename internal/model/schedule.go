@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Schedule triggers a task either on a cron expression or at a fixed
+// interval; exactly one of CronExpr and IntervalSeconds is set.
+type Schedule struct {
+	ID              string     `json:"id"`
+	TaskID          string     `json:"task_id"`
+	Name            string     `json:"name"`
+	CronExpr        string     `json:"cron_expr"`
+	IntervalSeconds int        `json:"interval_seconds"`
+	Timezone        string     `json:"timezone"`
+	TargetHost      *string    `json:"target_host"`
+	Enabled         bool       `json:"enabled"`
+	NextRunAt       *time.Time `json:"next_run_at"`
+	LastRunAt       *time.Time `json:"last_run_at"`
+	LastRunID       *string    `json:"last_run_id"`
+	LastError       string     `json:"last_error"`
+	CreatedBy       *string    `json:"created_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
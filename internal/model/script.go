@@ -5,14 +5,103 @@ import "time"
 const (
 	ScriptTypeLocust = "locust"
 	ScriptTypeJMeter = "jmeter"
+	ScriptTypeNative = "native"
+	ScriptTypeK6     = "k6"
 )
 
+// ScriptFormat describes one script type for clients: the file name a
+// single-file script is written as and the extensions of the files its
+// engine runs.
+type ScriptFormat struct {
+	Type       string   `json:"type"`
+	Title      string   `json:"title"`
+	Entrypoint string   `json:"entrypoint"`
+	Extensions []string `json:"extensions"`
+}
+
+// Script is a load test definition. Bundle scripts carry an archive of
+// helper modules and data files; Entrypoint names the file the engine runs
+// and Content holds its text. Bundle is loaded only to execute or download.
 type Script struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Type        string    `json:"type"`
-	Content     string    `json:"content"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Type        string            `json:"type"`
+	Content     string            `json:"content"`
+	Parameters  []ScriptParameter `json:"parameters"`
+	Entrypoint  string            `json:"entrypoint,omitempty"`
+	Files       []string          `json:"files,omitempty"`
+	Bundle      []byte            `json:"-"`
+	Version     int               `json:"version"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// ScriptVersion is an immutable snapshot of a script's executable parts,
+// recorded whenever its type, content or parameters change.
+type ScriptVersion struct {
+	ScriptID   string            `json:"script_id"`
+	Version    int               `json:"version"`
+	Type       string            `json:"type"`
+	Content    string            `json:"content,omitempty"`
+	Parameters []ScriptParameter `json:"parameters"`
+	Entrypoint string            `json:"entrypoint,omitempty"`
+	Files      []string          `json:"files,omitempty"`
+	Bundle     []byte            `json:"-"`
+	Message    string            `json:"message"`
+	Author     *string           `json:"author"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+const (
+	ParamTypeString = "string"
+	ParamTypeInt    = "int"
+	ParamTypeFloat  = "float"
+	ParamTypeBool   = "bool"
+)
+
+// ScriptParameter is a variable a script reads at run time: an environment
+// variable for Locust, a -J property for JMeter.
+type ScriptParameter struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Default     string `json:"default"`
+	Required    bool   `json:"required"`
+	Description string `json:"description,omitempty"`
+}
+
+const (
+	IssueError   = "error"
+	IssueWarning = "warning"
+)
+
+// ScriptIssue is one validation finding. Line is 1-based and 0 when the
+// issue is not tied to a line; File is set when it is not the entrypoint.
+type ScriptIssue struct {
+	Severity string `json:"severity"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+}
+
+// ScriptValidation is the outcome of checking a script. It is valid when no
+// issue is an error.
+type ScriptValidation struct {
+	Valid  bool          `json:"valid"`
+	Issues []ScriptIssue `json:"issues"`
+}
+
+// UntranslatedElement is a part of a script that a conversion dropped or
+// approximated. Element names it: a JMeter test element or a line of Python.
+type UntranslatedElement struct {
+	Element string `json:"element"`
+	Line    int    `json:"line,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// ScriptConversion is the script a conversion created and what it could not
+// carry over.
+type ScriptConversion struct {
+	Script       *Script               `json:"script"`
+	Untranslated []UntranslatedElement `json:"untranslated"`
 }
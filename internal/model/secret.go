@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// Secret is the metadata of a stored secret. Its value is write-only and
+// never leaves the server except into a run's environment.
+type Secret struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedBy   *string   `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
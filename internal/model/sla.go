@@ -0,0 +1,21 @@
+package model
+
+const (
+	SLAVerdictPassed  = "passed"
+	SLAVerdictFailed  = "failed"
+	SLAVerdictSkipped = "skipped"
+)
+
+type SLARule struct {
+	Metric     string  `json:"metric"`
+	Comparator string  `json:"comparator"`
+	Threshold  float64 `json:"threshold"`
+	Endpoint   string  `json:"endpoint,omitempty"`
+}
+
+type SLAResult struct {
+	Rule    SLARule  `json:"rule"`
+	Actual  *float64 `json:"actual"`
+	Passed  bool     `json:"passed"`
+	Message string   `json:"message,omitempty"`
+}
@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+type RunParameters struct {
+	ScriptID        string `json:"script_id"`
+	ScriptType      string `json:"script_type"`
+	ScriptVersion   int    `json:"script_version,omitempty"`
+	UsersCount      int    `json:"users_count"`
+	SpawnRate       int    `json:"spawn_rate"`
+	DurationSeconds int    `json:"duration_seconds"`
+	JmeterTPM       *int   `json:"jmeter_tpm,omitempty"`
+	Workers         int    `json:"workers,omitempty"`
+	// Stages is the expanded load profile the run was started with.
+	Stages []LoadStage `json:"stages,omitempty"`
+	// Variables are the resolved script parameters, defaults included.
+	Variables map[string]string `json:"variables,omitempty"`
+	// Secrets lists the injected secret names; values are never recorded.
+	Secrets []string `json:"secrets,omitempty"`
+}
+
+// RunWorker is one Locust worker of a distributed run.
+type RunWorker struct {
+	Node  string `json:"node"`
+	JobID string `json:"job_id"`
+}
+
+type TaskRun struct {
+	ID          string        `json:"id"`
+	TaskID      string        `json:"task_id"`
+	TaskName    *string       `json:"task_name"`
+	Status      string        `json:"status"`
+	Parameters  RunParameters `json:"parameters"`
+	TargetHost  string        `json:"target_host"`
+	ReportDir   string        `json:"report_dir"`
+	RunnerNode  string        `json:"runner_node"`
+	RunnerJobID string        `json:"runner_job_id"`
+	Workers     []RunWorker   `json:"workers,omitempty"`
+	ExitReason  string        `json:"exit_reason"`
+	StopOutcome string        `json:"stop_outcome,omitempty"`
+	SLAVerdict  string        `json:"sla_verdict"`
+	SLAResults  []SLAResult   `json:"sla_results"`
+	TriggeredBy *string       `json:"triggered_by"`
+	ScheduleID  *string       `json:"schedule_id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	StartedAt   *time.Time    `json:"started_at"`
+	FinishedAt  *time.Time    `json:"finished_at"`
+	Reports     []Report      `json:"reports,omitempty"`
+	// QueuePosition is the 1-based place of a queued run; zero otherwise.
+	QueuePosition int `json:"queue_position,omitempty"`
+}
+
+type RunLog struct {
+	RunID      string `json:"run_id"`
+	Offset     int64  `json:"offset"`
+	NextOffset int64  `json:"next_offset"`
+	Size       int64  `json:"size"`
+	Content    string `json:"content"`
+	Running    bool   `json:"running"`
+}
@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"bench-hub/internal/repository"
+)
+
+type InstanceRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewInstanceRepo(pool *pgxpool.Pool) *InstanceRepo {
+	return &InstanceRepo{pool: pool}
+}
+
+func (r *InstanceRepo) Heartbeat(ctx context.Context, node string) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO api_instances (node, last_heartbeat_at) VALUES ($1, NOW())
+		 ON CONFLICT (node) DO UPDATE SET last_heartbeat_at = NOW()`,
+		node,
+	)
+	return err
+}
+
+// LastHeartbeat measures the age on the database clock, so instances with
+// skewed clocks agree on it.
+func (r *InstanceRepo) LastHeartbeat(ctx context.Context, node string) (time.Duration, error) {
+	var seconds float64
+	err := r.pool.QueryRow(ctx,
+		"SELECT EXTRACT(EPOCH FROM NOW() - last_heartbeat_at)::float8 FROM api_instances WHERE node = $1",
+		node,
+	).Scan(&seconds)
+	if err == pgx.ErrNoRows {
+		return 0, repository.ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/repository"
+)
+
+type MetricRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewMetricRepo(pool *pgxpool.Pool) *MetricRepo {
+	return &MetricRepo{pool: pool}
+}
+
+func (r *MetricRepo) ReplaceForRun(ctx context.Context, run *model.TaskRun, metrics []model.EndpointMetric, samples []model.MetricSample) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DELETE FROM run_metrics WHERE run_id = $1", run.ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM run_metric_samples WHERE run_id = $1", run.ID); err != nil {
+		return err
+	}
+
+	batch := &pgx.Batch{}
+	for i := range metrics {
+		metric := &metrics[i]
+		if metric.ID == "" {
+			metric.ID = uuid.NewString()
+		}
+		metric.RunID = run.ID
+		metric.TaskID = run.TaskID
+		metric.ReportDir = run.ReportDir
+		batch.Queue(
+			`INSERT INTO run_metrics (id, run_id, task_id, report_dir, method, name, request_count, failure_count, avg_ms, min_ms, max_ms, p50_ms, p90_ms, p95_ms, p99_ms, rps, failures_per_sec)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`,
+			metric.ID,
+			metric.RunID,
+			metric.TaskID,
+			metric.ReportDir,
+			metric.Method,
+			metric.Name,
+			metric.RequestCount,
+			metric.FailureCount,
+			metric.AvgMs,
+			metric.MinMs,
+			metric.MaxMs,
+			metric.P50Ms,
+			metric.P90Ms,
+			metric.P95Ms,
+			metric.P99Ms,
+			metric.RPS,
+			metric.FailuresPerSec,
+		)
+	}
+	for _, sample := range samples {
+		batch.Queue(
+			`INSERT INTO run_metric_samples (run_id, task_id, report_dir, sampled_at, user_count, rps, failures_per_sec, p50_ms, p95_ms, total_requests, total_failures)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+			run.ID,
+			run.TaskID,
+			run.ReportDir,
+			sample.SampledAt,
+			sample.UserCount,
+			sample.RPS,
+			sample.FailuresPerSec,
+			sample.P50Ms,
+			sample.P95Ms,
+			sample.TotalRequests,
+			sample.TotalFailures,
+		)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *MetricRepo) LatestRunID(ctx context.Context, taskID string) (string, error) {
+	var runID string
+	row := r.pool.QueryRow(ctx,
+		"SELECT run_id FROM run_metrics WHERE task_id = $1 AND run_id IS NOT NULL ORDER BY created_at DESC LIMIT 1",
+		taskID,
+	)
+	if err := row.Scan(&runID); err != nil {
+		if err == pgx.ErrNoRows {
+			return "", repository.ErrNotFound
+		}
+		return "", err
+	}
+	return runID, nil
+}
+
+func (r *MetricRepo) ListByRun(ctx context.Context, runID string) ([]model.EndpointMetric, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, run_id, task_id, report_dir, method, name, request_count, failure_count, avg_ms, min_ms, max_ms, p50_ms, p90_ms, p95_ms, p99_ms, rps, failures_per_sec, created_at
+		 FROM run_metrics
+		 WHERE run_id = $1
+		 ORDER BY name = 'Aggregated', name, method`,
+		runID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var metrics []model.EndpointMetric
+	for rows.Next() {
+		var metric model.EndpointMetric
+		if err := rows.Scan(
+			&metric.ID,
+			&metric.RunID,
+			&metric.TaskID,
+			&metric.ReportDir,
+			&metric.Method,
+			&metric.Name,
+			&metric.RequestCount,
+			&metric.FailureCount,
+			&metric.AvgMs,
+			&metric.MinMs,
+			&metric.MaxMs,
+			&metric.P50Ms,
+			&metric.P90Ms,
+			&metric.P95Ms,
+			&metric.P99Ms,
+			&metric.RPS,
+			&metric.FailuresPerSec,
+			&metric.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, rows.Err()
+}
+
+func (r *MetricRepo) ListSamples(ctx context.Context, runID string) ([]model.MetricSample, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT sampled_at, user_count, rps, failures_per_sec, p50_ms, p95_ms, total_requests, total_failures
+		 FROM run_metric_samples
+		 WHERE run_id = $1
+		 ORDER BY sampled_at`,
+		runID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []model.MetricSample
+	for rows.Next() {
+		var sample model.MetricSample
+		if err := rows.Scan(
+			&sample.SampledAt,
+			&sample.UserCount,
+			&sample.RPS,
+			&sample.FailuresPerSec,
+			&sample.P50Ms,
+			&sample.P95Ms,
+			&sample.TotalRequests,
+			&sample.TotalFailures,
+		); err != nil {
+			return nil, err
+		}
+		samples = append(samples, sample)
+	}
+	return samples, rows.Err()
+}
@@ -25,9 +25,10 @@ func (r *ReportRepo) Create(ctx context.Context, report *model.Report) error {
 	}
 
 	row := r.pool.QueryRow(ctx,
-		"INSERT INTO locust_reports (id, task_id, name, report_type, file_path) VALUES ($1, $2, $3, $4, $5) RETURNING created_at",
+		"INSERT INTO locust_reports (id, task_id, run_id, name, report_type, file_path) VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at",
 		report.ID,
 		report.TaskID,
+		report.RunID,
 		report.Name,
 		report.Type,
 		report.FilePath,
@@ -39,13 +40,13 @@ func (r *ReportRepo) Create(ctx context.Context, report *model.Report) error {
 func (r *ReportRepo) GetByID(ctx context.Context, id string) (*model.Report, error) {
 	report := &model.Report{}
 	row := r.pool.QueryRow(ctx,
-		`SELECT r.id, r.task_id, t.name, r.name, r.report_type, r.file_path, r.created_at
+		`SELECT r.id, r.task_id, t.name, r.run_id, r.name, r.report_type, r.file_path, r.created_at
 		 FROM locust_reports r
 		 LEFT JOIN locust_tasks t ON r.task_id = t.id
 		 WHERE r.id = $1`,
 		id,
 	)
-	if err := row.Scan(&report.ID, &report.TaskID, &report.TaskName, &report.Name, &report.Type, &report.FilePath, &report.CreatedAt); err != nil {
+	if err := row.Scan(&report.ID, &report.TaskID, &report.TaskName, &report.RunID, &report.Name, &report.Type, &report.FilePath, &report.CreatedAt); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, repository.ErrNotFound
 		}
@@ -56,7 +57,7 @@ func (r *ReportRepo) GetByID(ctx context.Context, id string) (*model.Report, err
 
 func (r *ReportRepo) List(ctx context.Context, limit, offset int) ([]model.Report, error) {
 	rows, err := r.pool.Query(ctx,
-		`SELECT r.id, r.task_id, t.name, r.name, r.report_type, r.file_path, r.created_at
+		`SELECT r.id, r.task_id, t.name, r.run_id, r.name, r.report_type, r.file_path, r.created_at
 		 FROM locust_reports r
 		 LEFT JOIN locust_tasks t ON r.task_id = t.id
 		 ORDER BY r.created_at DESC
@@ -72,7 +73,32 @@ func (r *ReportRepo) List(ctx context.Context, limit, offset int) ([]model.Repor
 	var reports []model.Report
 	for rows.Next() {
 		var report model.Report
-		if err := rows.Scan(&report.ID, &report.TaskID, &report.TaskName, &report.Name, &report.Type, &report.FilePath, &report.CreatedAt); err != nil {
+		if err := rows.Scan(&report.ID, &report.TaskID, &report.TaskName, &report.RunID, &report.Name, &report.Type, &report.FilePath, &report.CreatedAt); err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
+
+func (r *ReportRepo) ListByRun(ctx context.Context, runID string) ([]model.Report, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT r.id, r.task_id, t.name, r.run_id, r.name, r.report_type, r.file_path, r.created_at
+		 FROM locust_reports r
+		 LEFT JOIN locust_tasks t ON r.task_id = t.id
+		 WHERE r.run_id = $1
+		 ORDER BY r.created_at`,
+		runID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []model.Report
+	for rows.Next() {
+		var report model.Report
+		if err := rows.Scan(&report.ID, &report.TaskID, &report.TaskName, &report.RunID, &report.Name, &report.Type, &report.FilePath, &report.CreatedAt); err != nil {
 			return nil, err
 		}
 		reports = append(reports, report)
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/repository"
+)
+
+type RunnerRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewRunnerRepo(pool *pgxpool.Pool) *RunnerRepo {
+	return &RunnerRepo{pool: pool}
+}
+
+func (r *RunnerRepo) Register(ctx context.Context, runner *model.Runner) error {
+	if runner.ID == "" {
+		runner.ID = uuid.NewString()
+	}
+	labels, err := marshalStringMap(runner.Labels)
+	if err != nil {
+		return err
+	}
+
+	row := r.pool.QueryRow(ctx,
+		`INSERT INTO runners (id, name, url, labels, max_jobs, active_jobs, cpu_percent, last_heartbeat_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		 ON CONFLICT (name) DO UPDATE SET url = EXCLUDED.url, labels = EXCLUDED.labels, max_jobs = EXCLUDED.max_jobs, active_jobs = EXCLUDED.active_jobs, cpu_percent = EXCLUDED.cpu_percent, last_heartbeat_at = NOW()
+		 RETURNING id, last_heartbeat_at, created_at`,
+		runner.ID,
+		runner.Name,
+		runner.URL,
+		labels,
+		runner.MaxJobs,
+		runner.ActiveJobs,
+		runner.CPUPercent,
+	)
+	return row.Scan(&runner.ID, &runner.LastHeartbeatAt, &runner.CreatedAt)
+}
+
+func (r *RunnerRepo) Heartbeat(ctx context.Context, id string, activeJobs int, cpuPercent float64) error {
+	tag, err := r.pool.Exec(ctx,
+		"UPDATE runners SET active_jobs = $1, cpu_percent = $2, last_heartbeat_at = NOW() WHERE id = $3",
+		activeJobs,
+		cpuPercent,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *RunnerRepo) IncrementActive(ctx context.Context, id string) error {
+	_, err := r.pool.Exec(ctx, "UPDATE runners SET active_jobs = active_jobs + 1 WHERE id = $1", id)
+	return err
+}
+
+func (r *RunnerRepo) ReleaseActive(ctx context.Context, url string) error {
+	_, err := r.pool.Exec(ctx, "UPDATE runners SET active_jobs = GREATEST(active_jobs - 1, 0) WHERE url = $1", url)
+	return err
+}
+
+func (r *RunnerRepo) List(ctx context.Context) ([]model.Runner, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, name, url, labels, max_jobs, active_jobs, cpu_percent, last_heartbeat_at, created_at,
+		        EXTRACT(EPOCH FROM NOW() - last_heartbeat_at)::float8
+		 FROM runners ORDER BY name`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runners []model.Runner
+	for rows.Next() {
+		var runner model.Runner
+		var labels []byte
+		var age float64
+		if err := rows.Scan(
+			&runner.ID,
+			&runner.Name,
+			&runner.URL,
+			&labels,
+			&runner.MaxJobs,
+			&runner.ActiveJobs,
+			&runner.CPUPercent,
+			&runner.LastHeartbeatAt,
+			&runner.CreatedAt,
+			&age,
+		); err != nil {
+			return nil, err
+		}
+		runner.HeartbeatAge = time.Duration(age * float64(time.Second))
+		if err := json.Unmarshal(labels, &runner.Labels); err != nil {
+			return nil, err
+		}
+		runners = append(runners, runner)
+	}
+	return runners, rows.Err()
+}
@@ -0,0 +1,182 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/repository"
+)
+
+type ScheduleRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewScheduleRepo(pool *pgxpool.Pool) *ScheduleRepo {
+	return &ScheduleRepo{pool: pool}
+}
+
+const scheduleColumns = `id, task_id, name, cron_expr, interval_seconds, timezone, target_host, enabled, next_run_at, last_run_at, last_run_id, last_error, created_by, created_at, updated_at`
+
+func scanSchedule(row pgx.Row) (*model.Schedule, error) {
+	schedule := &model.Schedule{}
+	var targetHost sql.NullString
+	if err := row.Scan(
+		&schedule.ID,
+		&schedule.TaskID,
+		&schedule.Name,
+		&schedule.CronExpr,
+		&schedule.IntervalSeconds,
+		&schedule.Timezone,
+		&targetHost,
+		&schedule.Enabled,
+		&schedule.NextRunAt,
+		&schedule.LastRunAt,
+		&schedule.LastRunID,
+		&schedule.LastError,
+		&schedule.CreatedBy,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if targetHost.Valid {
+		schedule.TargetHost = &targetHost.String
+	}
+	return schedule, nil
+}
+
+func collectSchedules(rows pgx.Rows) ([]model.Schedule, error) {
+	defer rows.Close()
+
+	var schedules []model.Schedule
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *schedule)
+	}
+	return schedules, rows.Err()
+}
+
+func (r *ScheduleRepo) Create(ctx context.Context, schedule *model.Schedule) error {
+	if schedule.ID == "" {
+		schedule.ID = uuid.NewString()
+	}
+	row := r.pool.QueryRow(ctx,
+		"INSERT INTO schedules (id, task_id, name, cron_expr, interval_seconds, timezone, target_host, enabled, next_run_at, created_by) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING created_at, updated_at",
+		schedule.ID,
+		schedule.TaskID,
+		schedule.Name,
+		schedule.CronExpr,
+		schedule.IntervalSeconds,
+		schedule.Timezone,
+		schedule.TargetHost,
+		schedule.Enabled,
+		schedule.NextRunAt,
+		schedule.CreatedBy,
+	)
+	return row.Scan(&schedule.CreatedAt, &schedule.UpdatedAt)
+}
+
+func (r *ScheduleRepo) GetByID(ctx context.Context, id string) (*model.Schedule, error) {
+	row := r.pool.QueryRow(ctx, "SELECT "+scheduleColumns+" FROM schedules WHERE id = $1", id)
+	schedule, err := scanSchedule(row)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (r *ScheduleRepo) List(ctx context.Context, taskID string, limit, offset int) ([]model.Schedule, error) {
+	rows, err := r.pool.Query(ctx,
+		"SELECT "+scheduleColumns+" FROM schedules WHERE ($1 = '' OR task_id::text = $1) ORDER BY created_at DESC LIMIT $2 OFFSET $3",
+		taskID,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return collectSchedules(rows)
+}
+
+func (r *ScheduleRepo) Update(ctx context.Context, schedule *model.Schedule) error {
+	row := r.pool.QueryRow(ctx,
+		"UPDATE schedules SET task_id = $1, name = $2, cron_expr = $3, interval_seconds = $4, timezone = $5, target_host = $6, enabled = $7, next_run_at = $8, updated_at = NOW() WHERE id = $9 RETURNING updated_at",
+		schedule.TaskID,
+		schedule.Name,
+		schedule.CronExpr,
+		schedule.IntervalSeconds,
+		schedule.Timezone,
+		schedule.TargetHost,
+		schedule.Enabled,
+		schedule.NextRunAt,
+		schedule.ID,
+	)
+	if err := row.Scan(&schedule.UpdatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return repository.ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *ScheduleRepo) Delete(ctx context.Context, id string) error {
+	tag, err := r.pool.Exec(ctx, "DELETE FROM schedules WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *ScheduleRepo) ListDue(ctx context.Context, now time.Time) ([]model.Schedule, error) {
+	rows, err := r.pool.Query(ctx,
+		"SELECT "+scheduleColumns+" FROM schedules WHERE enabled AND next_run_at IS NOT NULL AND next_run_at <= $1 ORDER BY next_run_at",
+		now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return collectSchedules(rows)
+}
+
+func (r *ScheduleRepo) Claim(ctx context.Context, id string, expected, next time.Time) (bool, error) {
+	var nextRunAt *time.Time
+	if !next.IsZero() {
+		nextRunAt = &next
+	}
+	tag, err := r.pool.Exec(ctx,
+		"UPDATE schedules SET next_run_at = $1, last_run_at = NOW() WHERE id = $2 AND enabled AND next_run_at = $3",
+		nextRunAt,
+		id,
+		expected,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *ScheduleRepo) RecordRun(ctx context.Context, id string, runID *string, lastError string) error {
+	_, err := r.pool.Exec(ctx,
+		"UPDATE schedules SET last_run_id = COALESCE($1, last_run_id), last_error = $2 WHERE id = $3",
+		runID,
+		lastError,
+		id,
+	)
+	return err
+}
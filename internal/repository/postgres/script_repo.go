@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -19,30 +20,104 @@ func NewScriptRepo(pool *pgxpool.Pool) *ScriptRepo {
 	return &ScriptRepo{pool: pool}
 }
 
-func (r *ScriptRepo) Create(ctx context.Context, script *model.Script) error {
+func (r *ScriptRepo) Create(ctx context.Context, script *model.Script, version *model.ScriptVersion) error {
 	if script.ID == "" {
 		script.ID = uuid.NewString()
 	}
+	parameters, err := marshalScriptParameters(script.Parameters)
+	if err != nil {
+		return err
+	}
+	files, err := marshalStrings(script.Files)
+	if err != nil {
+		return err
+	}
 
-	row := r.pool.QueryRow(ctx,
-		"INSERT INTO locust_scripts (id, name, description, script_type, content) VALUES ($1, $2, $3, $4, $5) RETURNING created_at, updated_at",
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	script.Version = 1
+	row := tx.QueryRow(ctx,
+		"INSERT INTO locust_scripts (id, name, description, script_type, content, parameters, entrypoint, files, version) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING created_at, updated_at",
 		script.ID,
 		script.Name,
 		script.Description,
 		script.Type,
 		script.Content,
+		parameters,
+		script.Entrypoint,
+		files,
+		script.Version,
 	)
+	if err := row.Scan(&script.CreatedAt, &script.UpdatedAt); err != nil {
+		return err
+	}
+	if err := insertScriptVersion(ctx, tx, script, parameters, files, version); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
 
-	return row.Scan(&script.CreatedAt, &script.UpdatedAt)
+// insertScriptVersion snapshots the script's current type, content,
+// parameters and bundle as script.Version, filling in version's remaining
+// fields. A bundle script saved without its archive loaded keeps the
+// previous version's archive.
+func insertScriptVersion(ctx context.Context, tx pgx.Tx, script *model.Script, parameters, files []byte, version *model.ScriptVersion) error {
+	version.ScriptID = script.ID
+	version.Version = script.Version
+	version.Type = script.Type
+	version.Content = script.Content
+	version.Parameters = script.Parameters
+	version.Entrypoint = script.Entrypoint
+	version.Files = script.Files
+	version.Bundle = script.Bundle
+	row := tx.QueryRow(ctx,
+		`INSERT INTO script_versions (script_id, version, script_type, content, parameters, entrypoint, files, bundle, message, author)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7,
+		         CASE WHEN $6 = '' THEN NULL ELSE COALESCE($8, (SELECT bundle FROM script_versions WHERE script_id = $1 AND version = $2 - 1)) END,
+		         $9, $10)
+		 RETURNING created_at`,
+		version.ScriptID,
+		version.Version,
+		version.Type,
+		version.Content,
+		parameters,
+		version.Entrypoint,
+		files,
+		version.Bundle,
+		version.Message,
+		version.Author,
+	)
+	return row.Scan(&version.CreatedAt)
 }
 
-func (r *ScriptRepo) GetByID(ctx context.Context, id string) (*model.Script, error) {
+const scriptColumns = `id, name, description, script_type, content, parameters, entrypoint, files, version, created_at, updated_at`
+
+func scanScript(row pgx.Row) (*model.Script, error) {
 	script := &model.Script{}
+	var parameters, files []byte
+	if err := row.Scan(&script.ID, &script.Name, &script.Description, &script.Type, &script.Content, &parameters, &script.Entrypoint, &files, &script.Version, &script.CreatedAt, &script.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(parameters, &script.Parameters); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(files, &script.Files); err != nil {
+		return nil, err
+	}
+	return script, nil
+}
+
+func (r *ScriptRepo) GetByID(ctx context.Context, id string) (*model.Script, error) {
 	row := r.pool.QueryRow(ctx,
-		"SELECT id, name, description, script_type, content, created_at, updated_at FROM locust_scripts WHERE id = $1",
+		"SELECT "+scriptColumns+" FROM locust_scripts WHERE id = $1",
 		id,
 	)
-	if err := row.Scan(&script.ID, &script.Name, &script.Description, &script.Type, &script.Content, &script.CreatedAt, &script.UpdatedAt); err != nil {
+	script, err := scanScript(row)
+	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, repository.ErrNotFound
 		}
@@ -53,7 +128,7 @@ func (r *ScriptRepo) GetByID(ctx context.Context, id string) (*model.Script, err
 
 func (r *ScriptRepo) List(ctx context.Context, limit, offset int) ([]model.Script, error) {
 	rows, err := r.pool.Query(ctx,
-		"SELECT id, name, description, script_type, content, created_at, updated_at FROM locust_scripts ORDER BY created_at DESC LIMIT $1 OFFSET $2",
+		"SELECT "+scriptColumns+" FROM locust_scripts ORDER BY created_at DESC LIMIT $1 OFFSET $2",
 		limit,
 		offset,
 	)
@@ -64,31 +139,65 @@ func (r *ScriptRepo) List(ctx context.Context, limit, offset int) ([]model.Scrip
 
 	var scripts []model.Script
 	for rows.Next() {
-		var script model.Script
-		if err := rows.Scan(&script.ID, &script.Name, &script.Description, &script.Type, &script.Content, &script.CreatedAt, &script.UpdatedAt); err != nil {
+		script, err := scanScript(rows)
+		if err != nil {
 			return nil, err
 		}
-		scripts = append(scripts, script)
+		scripts = append(scripts, *script)
 	}
 	return scripts, rows.Err()
 }
 
-func (r *ScriptRepo) Update(ctx context.Context, script *model.Script) error {
-	row := r.pool.QueryRow(ctx,
-		"UPDATE locust_scripts SET name = $1, description = $2, script_type = $3, content = $4, updated_at = NOW() WHERE id = $5 RETURNING updated_at",
+func (r *ScriptRepo) Update(ctx context.Context, script *model.Script, version *model.ScriptVersion) error {
+	parameters, err := marshalScriptParameters(script.Parameters)
+	if err != nil {
+		return err
+	}
+	files, err := marshalStrings(script.Files)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// The row lock serializes concurrent updates so version numbers stay
+	// gapless.
+	var current int
+	row := tx.QueryRow(ctx, "SELECT version FROM locust_scripts WHERE id = $1 FOR UPDATE", script.ID)
+	if err := row.Scan(&current); err != nil {
+		if err == pgx.ErrNoRows {
+			return repository.ErrNotFound
+		}
+		return err
+	}
+	script.Version = current
+	if version != nil {
+		script.Version = current + 1
+		if err := insertScriptVersion(ctx, tx, script, parameters, files, version); err != nil {
+			return err
+		}
+	}
+
+	row = tx.QueryRow(ctx,
+		"UPDATE locust_scripts SET name = $1, description = $2, script_type = $3, content = $4, parameters = $5, entrypoint = $6, files = $7, version = $8, updated_at = NOW() WHERE id = $9 RETURNING updated_at",
 		script.Name,
 		script.Description,
 		script.Type,
 		script.Content,
+		parameters,
+		script.Entrypoint,
+		files,
+		script.Version,
 		script.ID,
 	)
 	if err := row.Scan(&script.UpdatedAt); err != nil {
-		if err == pgx.ErrNoRows {
-			return repository.ErrNotFound
-		}
 		return err
 	}
-	return nil
+	return tx.Commit(ctx)
 }
 
 func (r *ScriptRepo) Delete(ctx context.Context, id string) error {
@@ -110,3 +219,61 @@ func (r *ScriptRepo) Count(ctx context.Context) (int, error) {
 	}
 	return count, nil
 }
+
+func (r *ScriptRepo) ListVersions(ctx context.Context, scriptID string) ([]model.ScriptVersion, error) {
+	rows, err := r.pool.Query(ctx,
+		"SELECT script_id, version, script_type, parameters, entrypoint, files, message, author, created_at FROM script_versions WHERE script_id = $1 ORDER BY version DESC",
+		scriptID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []model.ScriptVersion
+	for rows.Next() {
+		var version model.ScriptVersion
+		var parameters, files []byte
+		if err := rows.Scan(&version.ScriptID, &version.Version, &version.Type, &parameters, &version.Entrypoint, &files, &version.Message, &version.Author, &version.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(parameters, &version.Parameters); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(files, &version.Files); err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+func (r *ScriptRepo) GetVersion(ctx context.Context, scriptID string, version int) (*model.ScriptVersion, error) {
+	item := &model.ScriptVersion{}
+	var parameters, files []byte
+	row := r.pool.QueryRow(ctx,
+		"SELECT script_id, version, script_type, content, parameters, entrypoint, files, bundle, message, author, created_at FROM script_versions WHERE script_id = $1 AND version = $2",
+		scriptID,
+		version,
+	)
+	if err := row.Scan(&item.ScriptID, &item.Version, &item.Type, &item.Content, &parameters, &item.Entrypoint, &files, &item.Bundle, &item.Message, &item.Author, &item.CreatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(parameters, &item.Parameters); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(files, &item.Files); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func marshalScriptParameters(params []model.ScriptParameter) ([]byte, error) {
+	if params == nil {
+		params = []model.ScriptParameter{}
+	}
+	return json.Marshal(params)
+}
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/repository"
+)
+
+type SecretRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewSecretRepo(pool *pgxpool.Pool) *SecretRepo {
+	return &SecretRepo{pool: pool}
+}
+
+// secretColumns deliberately leaves out ciphertext; only Ciphertexts reads
+// it.
+const secretColumns = `id, name, description, created_by, created_at, updated_at`
+
+func scanSecret(row pgx.Row) (*model.Secret, error) {
+	secret := &model.Secret{}
+	if err := row.Scan(&secret.ID, &secret.Name, &secret.Description, &secret.CreatedBy, &secret.CreatedAt, &secret.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+func (r *SecretRepo) Create(ctx context.Context, secret *model.Secret, ciphertext []byte) error {
+	if secret.ID == "" {
+		secret.ID = uuid.NewString()
+	}
+	row := r.pool.QueryRow(ctx,
+		`INSERT INTO secrets (id, name, description, ciphertext, created_by) VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (name) DO NOTHING
+		 RETURNING created_at, updated_at`,
+		secret.ID,
+		secret.Name,
+		secret.Description,
+		ciphertext,
+		secret.CreatedBy,
+	)
+	if err := row.Scan(&secret.CreatedAt, &secret.UpdatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return repository.ErrConflict
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *SecretRepo) Update(ctx context.Context, secret *model.Secret, ciphertext []byte) error {
+	row := r.pool.QueryRow(ctx,
+		"UPDATE secrets SET description = $1, ciphertext = COALESCE($2, ciphertext), updated_at = NOW() WHERE id = $3 RETURNING updated_at",
+		secret.Description,
+		ciphertext,
+		secret.ID,
+	)
+	if err := row.Scan(&secret.UpdatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return repository.ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *SecretRepo) GetByID(ctx context.Context, id string) (*model.Secret, error) {
+	row := r.pool.QueryRow(ctx, "SELECT "+secretColumns+" FROM secrets WHERE id = $1", id)
+	secret, err := scanSecret(row)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return secret, nil
+}
+
+func (r *SecretRepo) List(ctx context.Context) ([]model.Secret, error) {
+	rows, err := r.pool.Query(ctx, "SELECT "+secretColumns+" FROM secrets ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var secrets []model.Secret
+	for rows.Next() {
+		secret, err := scanSecret(rows)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, *secret)
+	}
+	return secrets, rows.Err()
+}
+
+func (r *SecretRepo) Delete(ctx context.Context, id string) error {
+	tag, err := r.pool.Exec(ctx, "DELETE FROM secrets WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *SecretRepo) Ciphertexts(ctx context.Context, names []string) (map[string][]byte, error) {
+	out := make(map[string][]byte, len(names))
+	if len(names) == 0 {
+		return out, nil
+	}
+	rows, err := r.pool.Query(ctx, "SELECT name, ciphertext FROM secrets WHERE name = ANY($1)", names)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var ciphertext []byte
+		if err := rows.Scan(&name, &ciphertext); err != nil {
+			return nil, err
+		}
+		out[name] = ciphertext
+	}
+	return out, rows.Err()
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -24,49 +25,84 @@ func (r *TaskRepo) Create(ctx context.Context, task *model.Task) error {
 	if task.ID == "" {
 		task.ID = uuid.NewString()
 	}
+	slaRules, err := marshalSLARules(task.SLARules)
+	if err != nil {
+		return err
+	}
+	runnerLabels, err := marshalStringMap(task.RunnerLabels)
+	if err != nil {
+		return err
+	}
+	loadProfile, err := marshalLoadProfile(task.LoadProfile)
+	if err != nil {
+		return err
+	}
+	variables, err := marshalStringMap(task.Variables)
+	if err != nil {
+		return err
+	}
+	secrets, err := marshalStrings(task.Secrets)
+	if err != nil {
+		return err
+	}
 
 	row := r.pool.QueryRow(ctx,
-		"INSERT INTO locust_tasks (id, name, script_id, users_count, spawn_rate, duration_seconds, target_host, jmeter_tpm, status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING created_at, updated_at",
+		"INSERT INTO locust_tasks (id, name, script_id, script_version, users_count, spawn_rate, duration_seconds, target_host, jmeter_tpm, workers, load_profile, variables, secrets, sla_rules, runner_labels, status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) RETURNING created_at, updated_at",
 		task.ID,
 		task.Name,
 		task.ScriptID,
+		task.ScriptVersion,
 		task.UsersCount,
 		task.SpawnRate,
 		task.DurationSeconds,
 		task.TargetHost,
 		task.JmeterTPM,
+		task.Workers,
+		loadProfile,
+		variables,
+		secrets,
+		slaRules,
+		runnerLabels,
 		task.Status,
 	)
 
 	return row.Scan(&task.CreatedAt, &task.UpdatedAt)
 }
 
-func (r *TaskRepo) GetByID(ctx context.Context, id string) (*model.Task, error) {
+const taskColumns = `id, name, script_id, script_version, users_count, spawn_rate, duration_seconds, target_host, jmeter_tpm, workers, load_profile, variables, secrets, sla_rules, sla_verdict, runner_labels, status, created_at, updated_at, started_at, finished_at`
+
+func scanTask(row pgx.Row) (*model.Task, error) {
 	task := &model.Task{}
-	row := r.pool.QueryRow(ctx,
-		"SELECT id, name, script_id, users_count, spawn_rate, duration_seconds, target_host, jmeter_tpm, status, created_at, updated_at, started_at, finished_at FROM locust_tasks WHERE id = $1",
-		id,
-	)
 	var targetHost sql.NullString
 	var jmeterTPM sql.NullInt32
+	var slaRules []byte
+	var runnerLabels []byte
+	var loadProfile []byte
+	var variables []byte
+	var secrets []byte
 	if err := row.Scan(
 		&task.ID,
 		&task.Name,
 		&task.ScriptID,
+		&task.ScriptVersion,
 		&task.UsersCount,
 		&task.SpawnRate,
 		&task.DurationSeconds,
 		&targetHost,
 		&jmeterTPM,
+		&task.Workers,
+		&loadProfile,
+		&variables,
+		&secrets,
+		&slaRules,
+		&task.SLAVerdict,
+		&runnerLabels,
 		&task.Status,
 		&task.CreatedAt,
 		&task.UpdatedAt,
 		&task.StartedAt,
 		&task.FinishedAt,
 	); err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, repository.ErrNotFound
-		}
 		return nil, err
 	}
 	if targetHost.Valid {
@@ -76,64 +112,117 @@ func (r *TaskRepo) GetByID(ctx context.Context, id string) (*model.Task, error)
 		value := int(jmeterTPM.Int32)
 		task.JmeterTPM = &value
 	}
+	if err := json.Unmarshal(slaRules, &task.SLARules); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(runnerLabels, &task.RunnerLabels); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(variables, &task.Variables); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(secrets, &task.Secrets); err != nil {
+		return nil, err
+	}
+	if len(loadProfile) > 0 {
+		if err := json.Unmarshal(loadProfile, &task.LoadProfile); err != nil {
+			return nil, err
+		}
+	}
+	return task, nil
+}
+
+func (r *TaskRepo) GetByID(ctx context.Context, id string) (*model.Task, error) {
+	row := r.pool.QueryRow(ctx,
+		"SELECT "+taskColumns+" FROM locust_tasks WHERE id = $1",
+		id,
+	)
+	task, err := scanTask(row)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
 	return task, nil
 }
 
 func (r *TaskRepo) List(ctx context.Context, limit, offset int) ([]model.Task, error) {
 	rows, err := r.pool.Query(ctx,
-		"SELECT id, name, script_id, users_count, spawn_rate, duration_seconds, target_host, jmeter_tpm, status, created_at, updated_at, started_at, finished_at FROM locust_tasks ORDER BY created_at DESC LIMIT $1 OFFSET $2",
+		"SELECT "+taskColumns+" FROM locust_tasks ORDER BY created_at DESC LIMIT $1 OFFSET $2",
 		limit,
 		offset,
 	)
 	if err != nil {
 		return nil, err
 	}
+	return collectTasks(rows)
+}
+
+func (r *TaskRepo) ListByStatus(ctx context.Context, status string) ([]model.Task, error) {
+	rows, err := r.pool.Query(ctx,
+		"SELECT "+taskColumns+" FROM locust_tasks WHERE status = $1 ORDER BY created_at",
+		status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return collectTasks(rows)
+}
+
+func collectTasks(rows pgx.Rows) ([]model.Task, error) {
 	defer rows.Close()
 
 	var tasks []model.Task
 	for rows.Next() {
-		var task model.Task
-		var targetHost sql.NullString
-		var jmeterTPM sql.NullInt32
-		if err := rows.Scan(
-			&task.ID,
-			&task.Name,
-			&task.ScriptID,
-			&task.UsersCount,
-			&task.SpawnRate,
-			&task.DurationSeconds,
-			&targetHost,
-			&jmeterTPM,
-			&task.Status,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-			&task.StartedAt,
-			&task.FinishedAt,
-		); err != nil {
+		task, err := scanTask(rows)
+		if err != nil {
 			return nil, err
 		}
-		if targetHost.Valid {
-			task.TargetHost = &targetHost.String
-		}
-		if jmeterTPM.Valid {
-			value := int(jmeterTPM.Int32)
-			task.JmeterTPM = &value
-		}
-		tasks = append(tasks, task)
+		tasks = append(tasks, *task)
 	}
 	return tasks, rows.Err()
 }
 
 func (r *TaskRepo) Update(ctx context.Context, task *model.Task) error {
+	slaRules, err := marshalSLARules(task.SLARules)
+	if err != nil {
+		return err
+	}
+	runnerLabels, err := marshalStringMap(task.RunnerLabels)
+	if err != nil {
+		return err
+	}
+	loadProfile, err := marshalLoadProfile(task.LoadProfile)
+	if err != nil {
+		return err
+	}
+	variables, err := marshalStringMap(task.Variables)
+	if err != nil {
+		return err
+	}
+	secrets, err := marshalStrings(task.Secrets)
+	if err != nil {
+		return err
+	}
+
 	row := r.pool.QueryRow(ctx,
-		"UPDATE locust_tasks SET name = $1, script_id = $2, users_count = $3, spawn_rate = $4, duration_seconds = $5, target_host = $6, jmeter_tpm = $7, status = $8, started_at = $9, finished_at = $10, updated_at = NOW() WHERE id = $11 RETURNING updated_at",
+		"UPDATE locust_tasks SET name = $1, script_id = $2, script_version = $3, users_count = $4, spawn_rate = $5, duration_seconds = $6, target_host = $7, jmeter_tpm = $8, workers = $9, load_profile = $10, variables = $11, secrets = $12, sla_rules = $13, sla_verdict = $14, runner_labels = $15, status = $16, started_at = $17, finished_at = $18, updated_at = NOW() WHERE id = $19 RETURNING updated_at",
 		task.Name,
 		task.ScriptID,
+		task.ScriptVersion,
 		task.UsersCount,
 		task.SpawnRate,
 		task.DurationSeconds,
 		task.TargetHost,
 		task.JmeterTPM,
+		task.Workers,
+		loadProfile,
+		variables,
+		secrets,
+		slaRules,
+		task.SLAVerdict,
+		runnerLabels,
 		task.Status,
 		task.StartedAt,
 		task.FinishedAt,
@@ -158,3 +247,32 @@ func (r *TaskRepo) Delete(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+func marshalSLARules(rules []model.SLARule) ([]byte, error) {
+	if rules == nil {
+		rules = []model.SLARule{}
+	}
+	return json.Marshal(rules)
+}
+
+func marshalStringMap(labels map[string]string) ([]byte, error) {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	return json.Marshal(labels)
+}
+
+// marshalLoadProfile stores a missing profile as SQL NULL.
+func marshalLoadProfile(profile *model.LoadProfile) ([]byte, error) {
+	if profile == nil {
+		return nil, nil
+	}
+	return json.Marshal(profile)
+}
+
+func marshalStrings(values []string) ([]byte, error) {
+	if values == nil {
+		values = []string{}
+	}
+	return json.Marshal(values)
+}
@@ -0,0 +1,268 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/repository"
+)
+
+type TaskRunRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewTaskRunRepo(pool *pgxpool.Pool) *TaskRunRepo {
+	return &TaskRunRepo{pool: pool}
+}
+
+const taskRunColumns = `r.id, r.task_id, t.name, r.status, r.parameters, r.target_host, r.report_dir, r.runner_node, r.runner_job_id, r.workers, r.exit_reason, r.stop_outcome, r.sla_verdict, r.sla_results, r.triggered_by, r.schedule_id, r.created_at, r.started_at, r.finished_at`
+
+func scanTaskRun(row pgx.Row) (*model.TaskRun, error) {
+	run := &model.TaskRun{}
+	var parameters []byte
+	var slaResults []byte
+	var workers []byte
+	if err := row.Scan(
+		&run.ID,
+		&run.TaskID,
+		&run.TaskName,
+		&run.Status,
+		&parameters,
+		&run.TargetHost,
+		&run.ReportDir,
+		&run.RunnerNode,
+		&run.RunnerJobID,
+		&workers,
+		&run.ExitReason,
+		&run.StopOutcome,
+		&run.SLAVerdict,
+		&slaResults,
+		&run.TriggeredBy,
+		&run.ScheduleID,
+		&run.CreatedAt,
+		&run.StartedAt,
+		&run.FinishedAt,
+	); err != nil {
+		return nil, err
+	}
+	if len(parameters) > 0 {
+		if err := json.Unmarshal(parameters, &run.Parameters); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(slaResults, &run.SLAResults); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(workers, &run.Workers); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func (r *TaskRunRepo) Create(ctx context.Context, run *model.TaskRun) error {
+	if run.ID == "" {
+		run.ID = uuid.NewString()
+	}
+	parameters, err := json.Marshal(run.Parameters)
+	if err != nil {
+		return err
+	}
+	workers, err := marshalWorkers(run.Workers)
+	if err != nil {
+		return err
+	}
+
+	row := r.pool.QueryRow(ctx,
+		"INSERT INTO task_runs (id, task_id, status, parameters, target_host, report_dir, runner_node, runner_job_id, workers, exit_reason, triggered_by, schedule_id, started_at, finished_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING created_at",
+		run.ID,
+		run.TaskID,
+		run.Status,
+		parameters,
+		run.TargetHost,
+		run.ReportDir,
+		run.RunnerNode,
+		run.RunnerJobID,
+		workers,
+		run.ExitReason,
+		run.TriggeredBy,
+		run.ScheduleID,
+		run.StartedAt,
+		run.FinishedAt,
+	)
+
+	return row.Scan(&run.CreatedAt)
+}
+
+func (r *TaskRunRepo) GetByID(ctx context.Context, id string) (*model.TaskRun, error) {
+	row := r.pool.QueryRow(ctx,
+		`SELECT `+taskRunColumns+`
+		 FROM task_runs r
+		 LEFT JOIN locust_tasks t ON r.task_id = t.id
+		 WHERE r.id = $1`,
+		id,
+	)
+	run, err := scanTaskRun(row)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return run, nil
+}
+
+func (r *TaskRunRepo) ListByTask(ctx context.Context, taskID string, limit, offset int) ([]model.TaskRun, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+taskRunColumns+`
+		 FROM task_runs r
+		 LEFT JOIN locust_tasks t ON r.task_id = t.id
+		 WHERE r.task_id = $1
+		 ORDER BY r.created_at DESC
+		 LIMIT $2 OFFSET $3`,
+		taskID,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []model.TaskRun
+	for rows.Next() {
+		run, err := scanTaskRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	return runs, rows.Err()
+}
+
+func (r *TaskRunRepo) ListByStatus(ctx context.Context, status string) ([]model.TaskRun, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+taskRunColumns+`
+		 FROM task_runs r
+		 LEFT JOIN locust_tasks t ON r.task_id = t.id
+		 WHERE r.status = $1
+		 ORDER BY r.created_at`,
+		status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []model.TaskRun
+	for rows.Next() {
+		run, err := scanTaskRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	return runs, rows.Err()
+}
+
+func (r *TaskRunRepo) Update(ctx context.Context, run *model.TaskRun) error {
+	parameters, err := json.Marshal(run.Parameters)
+	if err != nil {
+		return err
+	}
+	slaResults := run.SLAResults
+	if slaResults == nil {
+		slaResults = []model.SLAResult{}
+	}
+	results, err := json.Marshal(slaResults)
+	if err != nil {
+		return err
+	}
+	workers, err := marshalWorkers(run.Workers)
+	if err != nil {
+		return err
+	}
+
+	tag, err := r.pool.Exec(ctx,
+		"UPDATE task_runs SET status = $1, parameters = $2, target_host = $3, report_dir = $4, runner_node = $5, runner_job_id = $6, workers = $7, exit_reason = $8, stop_outcome = $9, sla_verdict = $10, sla_results = $11, started_at = $12, finished_at = $13 WHERE id = $14",
+		run.Status,
+		parameters,
+		run.TargetHost,
+		run.ReportDir,
+		run.RunnerNode,
+		run.RunnerJobID,
+		workers,
+		run.ExitReason,
+		run.StopOutcome,
+		run.SLAVerdict,
+		results,
+		run.StartedAt,
+		run.FinishedAt,
+		run.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *TaskRunRepo) Transition(ctx context.Context, run *model.TaskRun, from string) (bool, error) {
+	workers, err := marshalWorkers(run.Workers)
+	if err != nil {
+		return false, err
+	}
+	tag, err := r.pool.Exec(ctx,
+		"UPDATE task_runs SET status = $1, runner_node = $2, workers = $3, exit_reason = $4, stop_outcome = $5, started_at = $6, finished_at = $7 WHERE id = $8 AND status = $9",
+		run.Status,
+		run.RunnerNode,
+		workers,
+		run.ExitReason,
+		run.StopOutcome,
+		run.StartedAt,
+		run.FinishedAt,
+		run.ID,
+		from,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// queueLockKey identifies the advisory lock held while dispatching queued
+// runs, so only one API instance admits runs at a time.
+const queueLockKey = 0x62656e6368
+
+func (r *TaskRunRepo) LockQueue(ctx context.Context) (func(), bool, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	var locked bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", queueLockKey).Scan(&locked); err != nil {
+		conn.Release()
+		return nil, false, err
+	}
+	if !locked {
+		conn.Release()
+		return nil, false, nil
+	}
+	return func() {
+		_, _ = conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", queueLockKey)
+		conn.Release()
+	}, true, nil
+}
+
+func marshalWorkers(workers []model.RunWorker) ([]byte, error) {
+	if workers == nil {
+		workers = []model.RunWorker{}
+	}
+	return json.Marshal(workers)
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"bench-hub/internal/model"
 )
@@ -17,31 +18,108 @@ type UserRepository interface {
 }
 
 type ScriptRepository interface {
-	Create(ctx context.Context, script *model.Script) error
+	// Create stores the script and version as its version 1.
+	Create(ctx context.Context, script *model.Script, version *model.ScriptVersion) error
 	GetByID(ctx context.Context, id string) (*model.Script, error)
 	List(ctx context.Context, limit, offset int) ([]model.Script, error)
-	Update(ctx context.Context, script *model.Script) error
+	// Update saves the script. A non-nil version is appended as the next
+	// version number in the same transaction and becomes the script's
+	// current version.
+	Update(ctx context.Context, script *model.Script, version *model.ScriptVersion) error
 	Delete(ctx context.Context, id string) error
 	Count(ctx context.Context) (int, error)
+	// ListVersions returns the versions newest first, without content.
+	ListVersions(ctx context.Context, scriptID string) ([]model.ScriptVersion, error)
+	// GetVersion returns one version with its content and bundle archive.
+	GetVersion(ctx context.Context, scriptID string, version int) (*model.ScriptVersion, error)
 }
 
 type TaskRepository interface {
 	Create(ctx context.Context, task *model.Task) error
 	GetByID(ctx context.Context, id string) (*model.Task, error)
 	List(ctx context.Context, limit, offset int) ([]model.Task, error)
+	ListByStatus(ctx context.Context, status string) ([]model.Task, error)
 	Update(ctx context.Context, task *model.Task) error
 	Delete(ctx context.Context, id string) error
 }
 
+type TaskRunRepository interface {
+	Create(ctx context.Context, run *model.TaskRun) error
+	GetByID(ctx context.Context, id string) (*model.TaskRun, error)
+	ListByTask(ctx context.Context, taskID string, limit, offset int) ([]model.TaskRun, error)
+	ListByStatus(ctx context.Context, status string) ([]model.TaskRun, error)
+	Update(ctx context.Context, run *model.TaskRun) error
+	// Transition moves a run out of status from, writing its new status,
+	// placement and timestamps, and reports whether this call won; a run that
+	// already left from is left untouched.
+	Transition(ctx context.Context, run *model.TaskRun, from string) (bool, error)
+	// LockQueue takes the cluster-wide dispatch lock without waiting. When ok
+	// is true the caller must call unlock.
+	LockQueue(ctx context.Context) (unlock func(), ok bool, err error)
+}
+
 type ReportRepository interface {
 	Create(ctx context.Context, report *model.Report) error
 	GetByID(ctx context.Context, id string) (*model.Report, error)
 	List(ctx context.Context, limit, offset int) ([]model.Report, error)
+	ListByRun(ctx context.Context, runID string) ([]model.Report, error)
 	Delete(ctx context.Context, id string) error
 	Count(ctx context.Context) (int, error)
 }
 
+type MetricRepository interface {
+	ReplaceForRun(ctx context.Context, run *model.TaskRun, metrics []model.EndpointMetric, samples []model.MetricSample) error
+	LatestRunID(ctx context.Context, taskID string) (string, error)
+	ListByRun(ctx context.Context, runID string) ([]model.EndpointMetric, error)
+	ListSamples(ctx context.Context, runID string) ([]model.MetricSample, error)
+}
+
+type RunnerRepository interface {
+	// Register creates the runner or refreshes the existing one with the
+	// same name.
+	Register(ctx context.Context, runner *model.Runner) error
+	Heartbeat(ctx context.Context, id string, activeJobs int, cpuPercent float64) error
+	IncrementActive(ctx context.Context, id string) error
+	// ReleaseActive gives back a job counted by IncrementActive on the
+	// runner at url.
+	ReleaseActive(ctx context.Context, url string) error
+	List(ctx context.Context) ([]model.Runner, error)
+}
+
+// InstanceRepository tracks which API instances are alive, so one instance
+// can tell whether a local run of another is still owned by a live process.
+type InstanceRepository interface {
+	Heartbeat(ctx context.Context, node string) error
+	// LastHeartbeat reports how long ago node last heartbeat.
+	LastHeartbeat(ctx context.Context, node string) (time.Duration, error)
+}
+
+type ScheduleRepository interface {
+	Create(ctx context.Context, schedule *model.Schedule) error
+	GetByID(ctx context.Context, id string) (*model.Schedule, error)
+	List(ctx context.Context, taskID string, limit, offset int) ([]model.Schedule, error)
+	Update(ctx context.Context, schedule *model.Schedule) error
+	Delete(ctx context.Context, id string) error
+	ListDue(ctx context.Context, now time.Time) ([]model.Schedule, error)
+	// Claim advances next_run_at from expected to next and reports whether
+	// this caller won, so only one replica fires a given slot.
+	Claim(ctx context.Context, id string, expected, next time.Time) (bool, error)
+	RecordRun(ctx context.Context, id string, runID *string, lastError string) error
+}
+
 type SettingsRepository interface {
 	Get(ctx context.Context, key string) (string, bool, error)
 	Set(ctx context.Context, key, value string) error
 }
+
+type SecretRepository interface {
+	Create(ctx context.Context, secret *model.Secret, ciphertext []byte) error
+	// Update replaces the description and, when ciphertext is non-nil, the
+	// value.
+	Update(ctx context.Context, secret *model.Secret, ciphertext []byte) error
+	GetByID(ctx context.Context, id string) (*model.Secret, error)
+	List(ctx context.Context) ([]model.Secret, error)
+	Delete(ctx context.Context, id string) error
+	// Ciphertexts returns the sealed values of the named secrets that exist.
+	Ciphertexts(ctx context.Context, names []string) (map[string][]byte, error)
+}
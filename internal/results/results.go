@@ -0,0 +1,88 @@
+// Package results holds what the engines' result parsers share: CSV column
+// lookup, aggregation of individual requests into endpoint metrics and a
+// timeline, and following result files that are still being written. Each
+// engine package reads its own formats with these.
+package results
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// AggregatedName names the entry that totals all endpoints, as Locust's
+// total row does.
+const AggregatedName = "Aggregated"
+
+var ErrNoResults = errors.New("no result files")
+
+// ParseFile opens path and parses it with parse.
+func ParseFile[T any](path string, parse func(io.Reader) ([]T, error)) ([]T, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parse(file)
+}
+
+// Table looks up the fields of CSV records by header name, ignoring case.
+type Table struct {
+	index map[string]int
+}
+
+func NewTable(header []string) Table {
+	index := make(map[string]int, len(header))
+	for i, field := range header {
+		index[strings.ToLower(strings.TrimSpace(field))] = i
+	}
+	return Table{index: index}
+}
+
+// ReadHeader reads the header row and checks the required columns.
+func ReadHeader(reader *csv.Reader, required ...string) (Table, error) {
+	header, err := reader.Read()
+	if err != nil {
+		return Table{}, err
+	}
+	table := NewTable(header)
+	for _, column := range required {
+		if !table.Has(column) {
+			return Table{}, fmt.Errorf("%s column not found", column)
+		}
+	}
+	return table, nil
+}
+
+func (t Table) Has(column string) bool {
+	_, ok := t.index[strings.ToLower(column)]
+	return ok
+}
+
+func (t Table) Str(record []string, column string) string {
+	i, ok := t.index[strings.ToLower(column)]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+func (t Table) Float(record []string, column string) float64 {
+	value, err := strconv.ParseFloat(t.Str(record, column), 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+func (t Table) Int(record []string, column string) int64 {
+	value := t.Str(record, column)
+	if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return parsed
+	}
+	return int64(t.Float(record, column))
+}
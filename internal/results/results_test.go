@@ -0,0 +1,67 @@
+package results
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLinesFollowsAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.csv")
+	lines := NewLines(path)
+	if got, err := lines.Next(); err != nil || got != nil {
+		t.Fatalf("expected nothing before the file exists, got %q %v", got, err)
+	}
+
+	if err := os.WriteFile(path, []byte("header\nrow 1\nrow"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	got, err := lines.Next()
+	if err != nil || len(got) != 2 || string(got[1]) != "row 1" {
+		t.Fatalf("expected the complete lines, got %q %v", got, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	_, _ = file.WriteString(" 2\r\n")
+	file.Close()
+	got, err = lines.Next()
+	if err != nil || len(got) != 1 || string(got[0]) != "row 2" {
+		t.Fatalf("expected the finished line, got %q %v", got, err)
+	}
+	if got, _ := lines.Next(); got != nil {
+		t.Fatalf("expected no new lines, got %q", got)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	samples := []Sample{
+		{Timestamp: 1700000000000, ElapsedMs: 10, Method: "GET", Label: "ping", Success: true, Users: 1},
+		{Timestamp: 1700000000500, ElapsedMs: 30, Method: "GET", Label: "ping", Users: 1},
+		{Timestamp: 1700000001000, ElapsedMs: 20, Method: "POST", Label: "login", Success: true, Users: 2},
+	}
+	read := func(visit func(Sample)) error {
+		for _, sample := range samples {
+			visit(sample)
+		}
+		return nil
+	}
+
+	metrics, err := Aggregate(read)
+	if err != nil || len(metrics) != 3 {
+		t.Fatalf("expected 3 rows, got %+v %v", metrics, err)
+	}
+	if ping := metrics[1]; ping.Name != "ping" || ping.RequestCount != 2 || ping.FailureCount != 1 || ping.AvgMs != 20 {
+		t.Fatalf("unexpected ping metric %+v", ping)
+	}
+	if total := metrics[2]; total.Name != AggregatedName || total.RequestCount != 3 || total.P50Ms != 20 {
+		t.Fatalf("unexpected aggregated metric %+v", total)
+	}
+
+	timeline, err := Timeline(read)
+	if err != nil || len(timeline) != 2 || timeline[1].TotalRequests != 3 || timeline[1].UserCount != 2 {
+		t.Fatalf("unexpected timeline %+v %v", timeline, err)
+	}
+}
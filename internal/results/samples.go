@@ -0,0 +1,183 @@
+package results
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"bench-hub/internal/model"
+)
+
+// Sample is one request, for engines whose results list every request
+// rather than precomputed statistics.
+type Sample struct {
+	// Timestamp is when the request started, in Unix milliseconds.
+	Timestamp int64
+	ElapsedMs float64
+	Method    string
+	Label     string
+	Success   bool
+	// Users is the number of active users when the request was made.
+	Users int
+}
+
+// Samples reads a result file and hands each request to visit.
+type Samples func(visit func(Sample)) error
+
+type sampleSet struct {
+	elapsed  []float64
+	failures int64
+	first    int64
+	last     int64
+}
+
+func (s *sampleSet) add(sample Sample) {
+	s.elapsed = append(s.elapsed, sample.ElapsedMs)
+	if !sample.Success {
+		s.failures++
+	}
+	end := sample.Timestamp + int64(sample.ElapsedMs)
+	if s.first == 0 || sample.Timestamp < s.first {
+		s.first = sample.Timestamp
+	}
+	if end > s.last {
+		s.last = end
+	}
+}
+
+func (s *sampleSet) metric(method, name string) model.EndpointMetric {
+	sort.Float64s(s.elapsed)
+	count := int64(len(s.elapsed))
+	metric := model.EndpointMetric{
+		Method:       method,
+		Name:         name,
+		RequestCount: count,
+		FailureCount: s.failures,
+	}
+	if count == 0 {
+		return metric
+	}
+
+	var sum float64
+	for _, value := range s.elapsed {
+		sum += value
+	}
+	metric.AvgMs = sum / float64(count)
+	metric.MinMs = s.elapsed[0]
+	metric.MaxMs = s.elapsed[count-1]
+	metric.P50Ms = Percentile(s.elapsed, 50)
+	metric.P90Ms = Percentile(s.elapsed, 90)
+	metric.P95Ms = Percentile(s.elapsed, 95)
+	metric.P99Ms = Percentile(s.elapsed, 99)
+
+	seconds := float64(s.last-s.first) / 1000
+	if seconds < 1 {
+		seconds = 1
+	}
+	metric.RPS = float64(count) / seconds
+	metric.FailuresPerSec = float64(s.failures) / seconds
+	return metric
+}
+
+// Percentile uses the nearest-rank method on already sorted values.
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// Aggregate groups requests per method and label, in label order, and
+// appends an "Aggregated" entry shaped like the Locust total row.
+func Aggregate(read Samples) ([]model.EndpointMetric, error) {
+	type key struct{ label, method string }
+	var order []key
+	byKey := map[key]*sampleSet{}
+	total := &sampleSet{}
+
+	err := read(func(sample Sample) {
+		k := key{sample.Label, sample.Method}
+		set := byKey[k]
+		if set == nil {
+			set = &sampleSet{}
+			byKey[k] = set
+			order = append(order, k)
+		}
+		set.add(sample)
+		total.add(sample)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(order, func(i, j int) bool {
+		if order[i].label != order[j].label {
+			return order[i].label < order[j].label
+		}
+		return order[i].method < order[j].method
+	})
+	metrics := make([]model.EndpointMetric, 0, len(order)+1)
+	for _, k := range order {
+		metrics = append(metrics, byKey[k].metric(k.method, k.label))
+	}
+	metrics = append(metrics, total.metric("", AggregatedName))
+	return metrics, nil
+}
+
+// Timeline buckets requests per second into a timeline comparable to
+// Locust's stats history.
+func Timeline(read Samples) ([]model.MetricSample, error) {
+	type bucket struct {
+		set   sampleSet
+		users int
+	}
+	buckets := map[int64]*bucket{}
+	err := read(func(sample Sample) {
+		second := sample.Timestamp / 1000
+		b := buckets[second]
+		if b == nil {
+			b = &bucket{}
+			buckets[second] = b
+		}
+		b.set.add(sample)
+		if sample.Users > b.users {
+			b.users = sample.Users
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seconds := make([]int64, 0, len(buckets))
+	for second := range buckets {
+		seconds = append(seconds, second)
+	}
+	sort.Slice(seconds, func(i, j int) bool { return seconds[i] < seconds[j] })
+
+	samples := make([]model.MetricSample, 0, len(seconds))
+	var totalRequests, totalFailures int64
+	for _, second := range seconds {
+		b := buckets[second]
+		metric := b.set.metric("", AggregatedName)
+		totalRequests += metric.RequestCount
+		totalFailures += metric.FailureCount
+		samples = append(samples, model.MetricSample{
+			SampledAt:      time.Unix(second, 0),
+			UserCount:      b.users,
+			RPS:            float64(metric.RequestCount),
+			FailuresPerSec: float64(metric.FailureCount),
+			P50Ms:          metric.P50Ms,
+			P95Ms:          metric.P95Ms,
+			TotalRequests:  totalRequests,
+			TotalFailures:  totalFailures,
+		})
+	}
+	return samples, nil
+}
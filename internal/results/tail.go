@@ -0,0 +1,124 @@
+package results
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"bench-hub/internal/model"
+)
+
+// Lines follows a result file that is still being written.
+type Lines struct {
+	path    string
+	offset  int64
+	partial []byte
+}
+
+func NewLines(path string) *Lines {
+	return &Lines{path: path}
+}
+
+// Next returns the complete lines appended since the previous call. A line
+// still being written is kept for the next call. A file that does not exist
+// yet has no lines.
+func (l *Lines) Next() ([][]byte, error) {
+	file, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(l.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	l.offset += int64(len(data))
+
+	data = append(l.partial, data...)
+	end := bytes.LastIndexByte(data, '\n')
+	if end == -1 {
+		l.partial = data
+		return nil, nil
+	}
+	l.partial = append([]byte(nil), data[end+1:]...)
+
+	var lines [][]byte
+	for _, line := range bytes.Split(data[:end], []byte{'\n'}) {
+		line = bytes.TrimRight(line, "\r")
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// Window turns the requests seen between two polls into a snapshot, for
+// engines that report requests rather than periodic statistics.
+type Window struct {
+	elapsed       []float64
+	failures      int64
+	users         int
+	totalRequests int64
+	totalFailures int64
+	lastFlush     time.Time
+}
+
+func NewWindow() *Window {
+	return &Window{lastFlush: time.Now()}
+}
+
+func (w *Window) Add(elapsedMs float64, success bool, users int) {
+	w.elapsed = append(w.elapsed, elapsedMs)
+	if !success {
+		w.failures++
+	}
+	w.Users(users)
+}
+
+// Users records the number of active users; the snapshot reports the peak.
+func (w *Window) Users(users int) {
+	if users > w.users {
+		w.users = users
+	}
+}
+
+// Flush returns the snapshot of the requests added since the previous
+// flush, or nil when there were none.
+func (w *Window) Flush() *model.MetricSample {
+	now := time.Now()
+	seconds := now.Sub(w.lastFlush).Seconds()
+	w.lastFlush = now
+	if len(w.elapsed) == 0 {
+		return nil
+	}
+	if seconds <= 0 {
+		seconds = 1
+	}
+
+	sort.Float64s(w.elapsed)
+	w.totalRequests += int64(len(w.elapsed))
+	w.totalFailures += w.failures
+	sample := &model.MetricSample{
+		SampledAt:      now,
+		UserCount:      w.users,
+		RPS:            float64(len(w.elapsed)) / seconds,
+		FailuresPerSec: float64(w.failures) / seconds,
+		P50Ms:          Percentile(w.elapsed, 50),
+		P95Ms:          Percentile(w.elapsed, 95),
+		TotalRequests:  w.totalRequests,
+		TotalFailures:  w.totalFailures,
+	}
+	w.elapsed = w.elapsed[:0]
+	w.failures = 0
+	w.users = 0
+	return sample
+}
@@ -0,0 +1,94 @@
+package runlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	FileName        = "run.log"
+	DefaultMaxBytes = 10 << 20
+)
+
+// Writer appends engine output to a log file and stops writing once
+// maxBytes is reached, leaving a truncation marker behind.
+type Writer struct {
+	mu        sync.Mutex
+	file      *os.File
+	written   int64
+	maxBytes  int64
+	truncated bool
+}
+
+func Create(path string, maxBytes int64) (*Writer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	return &Writer{file: file, maxBytes: maxBytes}, nil
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.truncated {
+		return len(p), nil
+	}
+	remaining := w.maxBytes - w.written
+	chunk := p
+	if int64(len(chunk)) > remaining {
+		chunk = chunk[:remaining]
+	}
+	n, err := w.file.Write(chunk)
+	w.written += int64(n)
+	if err != nil {
+		return n, err
+	}
+	if len(chunk) < len(p) {
+		w.truncated = true
+		fmt.Fprintf(w.file, "\n[log truncated at %d bytes]\n", w.maxBytes)
+	}
+	// Report the full length so the engine's pipe keeps draining.
+	return len(p), nil
+}
+
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// Read returns up to limit bytes of the log starting at offset, along with
+// the offset to continue from and the current file size.
+func Read(path string, offset, limit int64) ([]byte, int64, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	size := info.Size()
+	if offset < 0 || offset > size {
+		offset = size
+	}
+	if limit <= 0 || offset+limit > size {
+		limit = size - offset
+	}
+
+	data := make([]byte, limit)
+	n, err := file.ReadAt(data, offset)
+	if err != nil && err != io.EOF {
+		return nil, 0, 0, err
+	}
+	return data[:n], offset + int64(n), size, nil
+}
@@ -0,0 +1,48 @@
+package runlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriterCapsOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	w, err := Create(path, 10)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if n, err := w.Write([]byte("0123456")); err != nil || n != 7 {
+		t.Fatalf("write: %d %v", n, err)
+	}
+	if n, err := w.Write([]byte("789abcdef")); err != nil || n != 9 {
+		t.Fatalf("write over cap: %d %v", n, err)
+	}
+	_, _ = w.Write([]byte("ignored"))
+	w.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "0123456789\n[log truncated") || strings.Contains(string(data), "ignored") {
+		t.Fatalf("unexpected log content %q", data)
+	}
+}
+
+func TestReadFromOffset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("hello world"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	data, next, size, err := Read(path, 6, 3)
+	if err != nil || string(data) != "wor" || next != 9 || size != 11 {
+		t.Fatalf("unexpected read %q %d %d %v", data, next, size, err)
+	}
+	data, next, _, err = Read(path, next, 0)
+	if err != nil || string(data) != "ld" || next != 11 {
+		t.Fatalf("unexpected tail %q %d %v", data, next, err)
+	}
+}
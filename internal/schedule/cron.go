@@ -0,0 +1,201 @@
+// Package schedule computes fire times for cron expressions and fixed
+// intervals.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCron = errors.New("invalid cron expression")
+
+// Spec yields the next fire time strictly after a given instant.
+type Spec interface {
+	Next(after time.Time) time.Time
+}
+
+// Cron is a standard five-field expression: minute, hour, day of month,
+// month and day of week. Times are evaluated in the location of the instant
+// passed to Next.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+type field struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{min: 0, max: 59}
+	hourField   = field{min: 0, max: 23}
+	domField    = field{min: 1, max: 31}
+	monthField  = field{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = field{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses expressions such as "0 2 * * 1-5", "*/15 * * * *" or
+// "@daily". Month and weekday names are accepted; 7 means Sunday.
+func ParseCron(expr string) (*Cron, error) {
+	expr = strings.TrimSpace(strings.ToLower(expr))
+	if macro, ok := macros[expr]; ok {
+		expr = macro
+	}
+	parts := strings.Fields(expr)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("%w: expected 5 fields, got %d", ErrInvalidCron, len(parts))
+	}
+
+	c := &Cron{}
+	var err error
+	if c.minute, err = minuteField.parse(parts[0]); err != nil {
+		return nil, err
+	}
+	if c.hour, err = hourField.parse(parts[1]); err != nil {
+		return nil, err
+	}
+	if c.dom, err = domField.parse(parts[2]); err != nil {
+		return nil, err
+	}
+	if c.month, err = monthField.parse(parts[3]); err != nil {
+		return nil, err
+	}
+	if c.dow, err = dowField.parse(parts[4]); err != nil {
+		return nil, err
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny = parts[2] == "*" || parts[2] == "?"
+	c.dowAny = parts[4] == "*" || parts[4] == "?"
+	return c, nil
+}
+
+func (f field) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			value, err := strconv.Atoi(stepPart)
+			if err != nil || value <= 0 {
+				return 0, fmt.Errorf("%w: bad step %q", ErrInvalidCron, part)
+			}
+			step = value
+		}
+
+		var lo, hi int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			lo, hi = f.min, f.max
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = f.value(from); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(to); err != nil {
+				return 0, err
+			}
+		default:
+			value, err := f.value(rangePart)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = value, value
+			if hasStep {
+				hi = f.max
+			}
+		}
+		if lo > hi {
+			return 0, fmt.Errorf("%w: empty range %q", ErrInvalidCron, part)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (f field) value(s string) (int, error) {
+	if value, ok := f.names[s]; ok {
+		return value, nil
+	}
+	value, err := strconv.Atoi(s)
+	if err != nil || value < f.min || value > f.max {
+		return 0, fmt.Errorf("%w: value %q out of range %d-%d", ErrInvalidCron, s, f.min, f.max)
+	}
+	return value, nil
+}
+
+// Next returns the first matching minute after the given instant, or the
+// zero time if none exists within five years (e.g. "0 0 30 2 *").
+func (c *Cron) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches follows cron semantics: when both day of month and day of week
+// are restricted, either may match.
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// Every fires at a fixed interval.
+type Every time.Duration
+
+func (e Every) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(e))
+}
@@ -0,0 +1,55 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+
+	cases := []struct {
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, 3, 1, 10, 7, 30, 0, time.UTC), time.Date(2024, 3, 1, 10, 15, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC), time.Date(2024, 3, 2, 2, 0, 0, 0, time.UTC)},
+		{"30 9 * * mon-fri", time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), time.Date(2024, 3, 4, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 9, 6, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 2, 28, 12, 0, 0, 0, shanghai), time.Date(2024, 2, 29, 0, 0, 0, 0, shanghai)},
+	}
+	for _, tc := range cases {
+		cron, err := ParseCron(tc.expr)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.expr, err)
+		}
+		if got := cron.Next(tc.after); !got.Equal(tc.want) {
+			t.Errorf("%q after %v: got %v, want %v", tc.expr, tc.after, got, tc.want)
+		}
+	}
+}
+
+func TestCronNeverMatches(t *testing.T) {
+	cron, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if next := cron.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)); !next.IsZero() {
+		t.Fatalf("expected no fire time, got %v", next)
+	}
+}
+
+func TestParseCronRejectsInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		if _, err := ParseCron(expr); !errors.Is(err, ErrInvalidCron) {
+			t.Errorf("%q: expected ErrInvalidCron, got %v", expr, err)
+		}
+	}
+}
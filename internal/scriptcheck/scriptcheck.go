@@ -0,0 +1,19 @@
+// Package scriptcheck turns the issues an engine's check reports into a
+// validation result.
+package scriptcheck
+
+import "bench-hub/internal/model"
+
+// Result wraps issues into a validation, valid unless one is an error.
+func Result(issues []model.ScriptIssue) *model.ScriptValidation {
+	result := &model.ScriptValidation{Valid: true, Issues: issues}
+	if result.Issues == nil {
+		result.Issues = []model.ScriptIssue{}
+	}
+	for _, issue := range issues {
+		if issue.Severity == model.IssueError {
+			result.Valid = false
+		}
+	}
+	return result
+}
@@ -0,0 +1,148 @@
+package scriptgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+type harFile struct {
+	Log struct {
+		Creator struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"creator"`
+		Entries []struct {
+			Request struct {
+				Method   string   `json:"method"`
+				URL      string   `json:"url"`
+				Headers  []Header `json:"headers"`
+				PostData *struct {
+					MimeType string `json:"mimeType"`
+					Text     string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// staticExt lists asset extensions a browser recording pulls in alongside
+// the API calls; they are left to a CDN and not load tested.
+var staticExt = map[string]bool{
+	".css": true, ".js": true, ".map": true, ".png": true, ".jpg": true, ".jpeg": true,
+	".gif": true, ".svg": true, ".ico": true, ".webp": true, ".woff": true, ".woff2": true,
+	".ttf": true, ".eot": true,
+}
+
+// keptHeaders are the recorded headers that shape the request; the rest are
+// browser noise or session state.
+var keptHeaders = map[string]bool{"content-type": true, "accept": true}
+
+// FromHAR builds a plan from a HAR recording. Requests to the most frequent
+// origin are grouped by method and path template, with the group size as
+// the weight; recorded credentials are replaced with placeholders.
+func FromHAR(data []byte) (*Plan, error) {
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+
+	type recorded struct {
+		origin string
+		req    Request
+	}
+	var all []recorded
+	origins := map[string]int{}
+	for _, entry := range har.Log.Entries {
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || staticExt[strings.ToLower(path.Ext(u.Path))] {
+			continue
+		}
+		origin := u.Scheme + "://" + u.Host
+		origins[origin]++
+
+		req := Request{Method: strings.ToUpper(entry.Request.Method), Path: u.RequestURI()}
+		if entry.Request.PostData != nil {
+			req.Body = entry.Request.PostData.Text
+		}
+		req.Headers = harHeaders(entry.Request.Headers, req.Body != "")
+		all = append(all, recorded{origin: origin, req: req})
+	}
+
+	best := ""
+	for origin, n := range origins {
+		if n > origins[best] || (n == origins[best] && origin < best) {
+			best = origin
+		}
+	}
+	source := "HAR recording"
+	if har.Log.Creator.Name != "" {
+		source = strings.TrimSpace(fmt.Sprintf("HAR recording from %s %s", har.Log.Creator.Name, har.Log.Creator.Version))
+	}
+	plan := newPlan(strings.TrimPrefix(strings.TrimPrefix(best, "https://"), "http://"), source, best)
+
+	// The first request of each group is kept as its representative.
+	groups := map[string]int{}
+	for _, r := range all {
+		if r.origin != best {
+			continue
+		}
+		u, _ := url.Parse(r.req.Path)
+		label := r.req.Method + " " + templatePath(u.Path)
+		if i, ok := groups[label]; ok {
+			plan.Requests[i].Weight++
+			continue
+		}
+		r.req.Label = label
+		r.req.Weight = 1
+		for i, h := range r.req.Headers {
+			r.req.Headers[i].Value = credentialPlaceholder(plan, h)
+		}
+		groups[label] = len(plan.Requests)
+		plan.Requests = append(plan.Requests, r.req)
+	}
+	if len(plan.Requests) == 0 {
+		return nil, ErrEmpty
+	}
+	return plan, nil
+}
+
+// harHeaders keeps the headers that shape the request and the credential
+// headers, which FromHAR later swaps for placeholders.
+func harHeaders(headers []Header, hasBody bool) []Header {
+	var out []Header
+	seen := map[string]bool{}
+	for _, h := range headers {
+		name := strings.ToLower(h.Name)
+		if strings.HasPrefix(name, ":") || seen[name] {
+			continue
+		}
+		if keptHeaders[name] || isCredentialHeader(name) {
+			if name == "content-type" && !hasBody {
+				continue
+			}
+			seen[name] = true
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+func isCredentialHeader(name string) bool {
+	return name == "authorization" || strings.Contains(name, "api-key") || strings.Contains(name, "apikey") || strings.Contains(name, "token")
+}
+
+func credentialPlaceholder(plan *Plan, h Header) string {
+	name := strings.ToLower(h.Name)
+	switch {
+	case name == "authorization" && strings.HasPrefix(strings.ToLower(h.Value), "basic "):
+		return "Basic " + plan.placeholder("AUTH_BASIC", "base64 user:password for HTTP basic auth")
+	case name == "authorization":
+		return "Bearer " + plan.placeholder("AUTH_TOKEN", "bearer token sent in the Authorization header")
+	case isCredentialHeader(name):
+		return plan.placeholder("API_KEY", "API key sent in the "+h.Name+" header")
+	}
+	return h.Value
+}
@@ -0,0 +1,174 @@
+package scriptgen
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"bench-hub/internal/model"
+	"bench-hub/locust"
+)
+
+var (
+	templateName     = regexp.MustCompile(`testname="health-template"`)
+	templateComments = regexp.MustCompile(`(<stringProp name="TestPlan\.comments">)[^<]*(</stringProp>)`)
+	templateSampler  = regexp.MustCompile(`(?s)( *)<HTTPSamplerProxy .*?</HTTPSamplerProxy>\n *<hashTree/>\n`)
+	templateTimer    = regexp.MustCompile(`(?s)( *)<ConstantTimer .*?</ConstantTimer>\n`)
+	templateDuration = regexp.MustCompile(`\$\{__P\(duration,[0-9]+\)\}`)
+)
+
+// JMeter renders the plan on top of the bundled JMeter template, which
+// already reads the platform's thread, duration and target properties. It
+// returns the plan together with the parameters the plan reads.
+//
+// Requests run in sequence each iteration; differing weights become
+// percent throughput controllers. The template's timer paces requests with
+// delay_ms, the plan's minimum wait, plus a uniform random range when the
+// wait varies.
+func JMeter(plan *Plan) (string, []model.ScriptParameter, error) {
+	if len(plan.Requests) == 0 {
+		return "", nil, ErrEmpty
+	}
+	loc := templateSampler.FindStringSubmatchIndex(locust.JMeterTemplate)
+	if loc == nil {
+		return "", nil, errors.New("jmeter template has no HTTP sampler")
+	}
+	indent := locust.JMeterTemplate[loc[2]:loc[3]]
+
+	maxWeight, uniform := 0, true
+	for _, req := range plan.Requests {
+		if req.Weight > maxWeight {
+			maxWeight = req.Weight
+		}
+		if req.Weight != plan.Requests[0].Weight {
+			uniform = false
+		}
+	}
+
+	var samplers strings.Builder
+	for _, req := range plan.Requests {
+		if uniform {
+			writeSampler(&samplers, plan, req, indent)
+			continue
+		}
+		percent := float64(req.Weight) * 100 / float64(maxWeight)
+		fmt.Fprintf(&samplers, `%[1]s<ThroughputController guiclass="ThroughputControllerGui" testclass="ThroughputController" testname="%[2]s weight" enabled="true">
+%[1]s  <intProp name="ThroughputController.style">1</intProp>
+%[1]s  <boolProp name="ThroughputController.perThread">false</boolProp>
+%[1]s  <FloatProperty>
+%[1]s    <name>ThroughputController.percentThroughput</name>
+%[1]s    <value>%[3]s</value>
+%[1]s  </FloatProperty>
+%[1]s</ThroughputController>
+%[1]s<hashTree>
+`, indent, xmlEscape(req.Label), strconv.FormatFloat(percent, 'f', 1, 64))
+		writeSampler(&samplers, plan, req, indent+"  ")
+		fmt.Fprintf(&samplers, "%s</hashTree>\n", indent)
+	}
+
+	content := locust.JMeterTemplate[:loc[0]] + samplers.String() + locust.JMeterTemplate[loc[1]:]
+	content = templateName.ReplaceAllLiteralString(content, `testname="`+xmlEscape(plan.Name)+`"`)
+	content = templateComments.ReplaceAllString(content, "${1}"+strings.ReplaceAll(xmlEscape("Generated from "+plan.Source), "$", "$$")+"${2}")
+
+	delay := int(plan.WaitMin * 1000)
+	if spread := int((plan.WaitMax - plan.WaitMin) * 1000); spread > 0 {
+		content = templateTimer.ReplaceAllString(content, strings.ReplaceAll(fmt.Sprintf(`${1}<UniformRandomTimer guiclass="UniformRandomTimerGui" testclass="UniformRandomTimer" testname="Think Time" enabled="true">
+${1}  <stringProp name="ConstantTimer.delay">${__P(delay_ms,%d)}</stringProp>
+${1}  <stringProp name="RandomTimer.range">%d</stringProp>
+${1}</UniformRandomTimer>
+`, delay, spread), "${__P", "$${__P"))
+	}
+	if plan.Duration > 0 {
+		content = templateDuration.ReplaceAllLiteralString(content, fmt.Sprintf("${__P(duration,%d)}", plan.Duration))
+	}
+
+	params := append([]model.ScriptParameter{{
+		Name:        "delay_ms",
+		Type:        model.ParamTypeInt,
+		Default:     strconv.Itoa(delay),
+		Description: "minimum pause between requests in milliseconds",
+	}}, plan.Variables...)
+	return content, params, nil
+}
+
+func writeSampler(b *strings.Builder, plan *Plan, req Request, indent string) {
+	fmt.Fprintf(b, `%[1]s<HTTPSamplerProxy guiclass="HttpTestSampleGui" testclass="HTTPSamplerProxy" testname="%[2]s" enabled="true">
+`, indent, xmlEscape(req.Label))
+	if req.Body != "" {
+		fmt.Fprintf(b, `%[1]s  <boolProp name="HTTPSampler.postBodyRaw">true</boolProp>
+%[1]s  <elementProp name="HTTPsampler.Arguments" elementType="Arguments">
+%[1]s    <collectionProp name="Arguments.arguments">
+%[1]s      <elementProp name="" elementType="HTTPArgument">
+%[1]s        <boolProp name="HTTPArgument.always_encode">false</boolProp>
+%[1]s        <stringProp name="Argument.value">%[2]s</stringProp>
+%[1]s        <stringProp name="Argument.metadata">=</stringProp>
+%[1]s      </elementProp>
+%[1]s    </collectionProp>
+%[1]s  </elementProp>
+`, indent, xmlEscape(jmeterValue(plan, req.Body)))
+	} else {
+		fmt.Fprintf(b, `%[1]s  <elementProp name="HTTPsampler.Arguments" elementType="Arguments">
+%[1]s    <collectionProp name="Arguments.arguments"/>
+%[1]s  </elementProp>
+`, indent)
+	}
+	fmt.Fprintf(b, `%[1]s  <stringProp name="HTTPSampler.domain"></stringProp>
+%[1]s  <stringProp name="HTTPSampler.port"></stringProp>
+%[1]s  <stringProp name="HTTPSampler.protocol"></stringProp>
+%[1]s  <stringProp name="HTTPSampler.path">%[2]s</stringProp>
+%[1]s  <stringProp name="HTTPSampler.method">%[3]s</stringProp>
+%[1]s  <boolProp name="HTTPSampler.follow_redirects">true</boolProp>
+%[1]s  <boolProp name="HTTPSampler.auto_redirects">false</boolProp>
+%[1]s  <boolProp name="HTTPSampler.use_keepalive">true</boolProp>
+%[1]s  <boolProp name="HTTPSampler.DO_MULTIPART_POST">false</boolProp>
+%[1]s  <stringProp name="HTTPSampler.embedded_url_re"></stringProp>
+%[1]s</HTTPSamplerProxy>
+`, indent, xmlEscape(jmeterValue(plan, req.Path)), xmlEscape(req.Method))
+
+	if len(req.Headers) == 0 {
+		fmt.Fprintf(b, "%s<hashTree/>\n", indent)
+		return
+	}
+	fmt.Fprintf(b, `%[1]s<hashTree>
+%[1]s  <HeaderManager guiclass="HeaderPanel" testclass="HeaderManager" testname="Headers" enabled="true">
+%[1]s    <collectionProp name="HeaderManager.headers">
+`, indent)
+	for _, h := range req.Headers {
+		fmt.Fprintf(b, `%[1]s      <elementProp name="" elementType="Header">
+%[1]s        <stringProp name="Header.name">%[2]s</stringProp>
+%[1]s        <stringProp name="Header.value">%[3]s</stringProp>
+%[1]s      </elementProp>
+`, indent, xmlEscape(h.Name), xmlEscape(jmeterValue(plan, h.Value)))
+	}
+	fmt.Fprintf(b, `%[1]s    </collectionProp>
+%[1]s  </HeaderManager>
+%[1]s  <hashTree/>
+%[1]s</hashTree>
+`, indent)
+}
+
+// jmeterValue turns placeholders into property reads defaulting to the
+// variable's default, so an unset secret does not fail the run.
+func jmeterValue(plan *Plan, value string) string {
+	var b strings.Builder
+	for _, p := range plan.split(value) {
+		if p.variable {
+			b.WriteString("${__P(" + p.text + "," + functionArg.Replace(plan.defaultOf(p.text)) + ")}")
+		} else {
+			b.WriteString(p.text)
+		}
+	}
+	return b.String()
+}
+
+// functionArg escapes the characters that end a JMeter function argument.
+var functionArg = strings.NewReplacer(`\`, `\\`, ",", `\,`, ")", `\)`)
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,483 @@
+package scriptgen
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/scriptparams"
+)
+
+// jmxNode is a generic JMeter test element or property.
+type jmxNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Text    string     `xml:",chardata"`
+	Nodes   []jmxNode  `xml:",any"`
+}
+
+func (n *jmxNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// prop returns the direct property called name, or nil.
+func (n *jmxNode) prop(name string) *jmxNode {
+	for i := range n.Nodes {
+		if n.Nodes[i].attr("name") == name {
+			return &n.Nodes[i]
+		}
+	}
+	return nil
+}
+
+func (n *jmxNode) str(name string) string {
+	if p := n.prop(name); p != nil {
+		return strings.TrimSpace(p.Text)
+	}
+	return ""
+}
+
+// label names an element in reports, e.g. `ResponseAssertion "status"`.
+func (n *jmxNode) label() string {
+	if name := n.attr("testname"); name != "" {
+		return fmt.Sprintf("%s %q", n.XMLName.Local, name)
+	}
+	return n.XMLName.Local
+}
+
+// silentElements are dropped without a report: listeners only collect
+// results, and Locust keeps cookies per user just like a cookie manager.
+var silentElements = map[string]bool{
+	"ResultCollector": true,
+	"BackendListener": true,
+	"Summariser":      true,
+	"CookieManager":   true,
+	"CacheManager":    true,
+	"DNSCacheManager": true,
+}
+
+// flowControllers only group their children.
+var flowControllers = map[string]bool{
+	"GenericController":     true,
+	"TransactionController": true,
+	"LoopController":        true,
+	"ThroughputController":  true,
+}
+
+var (
+	jmxExpr     = regexp.MustCompile(`\$\{([^{}]*)\}`)
+	jmxProperty = regexp.MustCompile(`^__P\(([^,()]*)(?:,([^()]*))?\)$`)
+)
+
+type jmxReader struct {
+	plan *Plan
+	// vars are the literal user-defined variables, read as ${name}.
+	vars map[string]string
+	// factors hold each request's relative frequency until weights are
+	// normalized.
+	factors     []float64
+	waitSet     bool
+	threadGroup bool
+}
+
+// FromJMX reads the HTTP samplers of the first enabled thread group of a
+// JMeter plan, with their headers, the thread group duration, a constant or
+// uniform timer and percent throughput controllers as weights. Properties
+// read with ${__P(name,default)} become variables. Whatever else the plan
+// contains is listed in Plan.Untranslated.
+func FromJMX(content string) (*Plan, error) {
+	var root jmxNode
+	if err := xml.Unmarshal([]byte(content), &root); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	if root.XMLName.Local != "jmeterTestPlan" {
+		return nil, fmt.Errorf("%w: root element is <%s>", ErrUnsupported, root.XMLName.Local)
+	}
+
+	r := &jmxReader{plan: newPlan("jmeter", "JMeter plan", ""), vars: map[string]string{}}
+	for i := range root.Nodes {
+		if root.Nodes[i].XMLName.Local == "hashTree" {
+			r.walk(root.Nodes[i].Nodes, nil, 1)
+			break
+		}
+	}
+	if len(r.plan.Requests) == 0 {
+		return nil, ErrEmpty
+	}
+
+	least := math.Inf(1)
+	for _, f := range r.factors {
+		least = math.Min(least, f)
+	}
+	for i, f := range r.factors {
+		r.plan.Requests[i].Weight = int(math.Max(1, math.Round(f/least)))
+	}
+	return r.plan, nil
+}
+
+// walk reads a hashTree's children, where each element is followed by the
+// hashTree of its own children. Header managers apply to every sampler in
+// scope regardless of their position, so they are collected first.
+func (r *jmxReader) walk(nodes []jmxNode, headers []Header, factor float64) {
+	type element struct {
+		node     *jmxNode
+		children []jmxNode
+	}
+	var elements []element
+	for i := 0; i < len(nodes); i++ {
+		if nodes[i].XMLName.Local == "hashTree" {
+			continue
+		}
+		el := element{node: &nodes[i]}
+		if i+1 < len(nodes) && nodes[i+1].XMLName.Local == "hashTree" {
+			el.children = nodes[i+1].Nodes
+		}
+		if el.node.attr("enabled") != "false" {
+			elements = append(elements, el)
+		}
+	}
+
+	scoped := append([]Header(nil), headers...)
+	for _, el := range elements {
+		if el.node.XMLName.Local == "HeaderManager" {
+			scoped = r.headers(el.node, scoped)
+		}
+	}
+
+	for _, el := range elements {
+		node := el.node
+		kind := node.XMLName.Local
+		if class := node.attr("testclass"); class != "" {
+			kind = class
+		}
+		switch {
+		case kind == "HeaderManager" || silentElements[kind]:
+		case kind == "TestPlan":
+			if name := node.attr("testname"); name != "" {
+				r.plan.Name = name
+			}
+			r.arguments(node.prop("TestPlan.user_defined_variables"))
+			r.walk(el.children, scoped, factor)
+		case kind == "Arguments":
+			r.arguments(node)
+		case strings.HasSuffix(kind, "ThreadGroup"):
+			if r.threadGroup {
+				r.plan.skip(node.label(), 0, "only the first thread group is converted")
+				continue
+			}
+			r.threadGroup = true
+			r.readThreadGroup(node, kind)
+			r.walk(el.children, scoped, factor)
+		case kind == "ConfigTestElement" && node.attr("guiclass") == "HttpDefaultsGui":
+			r.defaults(node)
+		case kind == "ConstantTimer" || kind == "UniformRandomTimer":
+			r.timer(node, kind)
+		case kind == "HTTPSamplerProxy":
+			r.sampler(node, el.children, scoped, factor)
+		case flowControllers[kind]:
+			r.walk(el.children, scoped, r.controllerFactor(node, kind, factor))
+		case strings.HasSuffix(kind, "Controller"):
+			r.plan.skip(node.label(), 0, "logic controller not supported; its samplers were dropped")
+		default:
+			r.plan.skip(node.label(), 0, "element not supported")
+		}
+	}
+}
+
+// readThreadGroup keeps a fixed duration. Values read from platform
+// properties are left out, since the task sets them either way.
+func (r *jmxReader) readThreadGroup(node *jmxNode, kind string) {
+	if kind != "ThreadGroup" {
+		r.plan.skip(node.label(), 0, "the load shape comes from the task")
+	}
+	if threads := node.str("ThreadGroup.num_threads"); threads != "" && !strings.Contains(threads, "${") {
+		r.plan.skip(node.label(), 0, "the fixed thread count "+threads+" is replaced by the task's users")
+	}
+	duration := node.str("ThreadGroup.duration")
+	if node.str("ThreadGroup.scheduler") == "true" && !strings.Contains(duration, "${") {
+		if seconds, err := strconv.Atoi(duration); err == nil && seconds > 0 {
+			r.plan.Duration = seconds
+		}
+	}
+}
+
+// arguments records literal user-defined variables.
+func (r *jmxReader) arguments(node *jmxNode) {
+	if node == nil {
+		return
+	}
+	args := node.prop("Arguments.arguments")
+	if args == nil {
+		return
+	}
+	for i := range args.Nodes {
+		arg := &args.Nodes[i]
+		if name := arg.str("Argument.name"); name != "" {
+			r.vars[name] = arg.str("Argument.value")
+		}
+	}
+}
+
+// defaults records a fixed default server; one read from the platform's
+// target properties says nothing about the source.
+func (r *jmxReader) defaults(node *jmxNode) {
+	host := node.str("HTTPSampler.domain")
+	if host == "" || strings.Contains(host, "${") {
+		return
+	}
+	protocol, _ := r.literal(node.str("HTTPSampler.protocol"))
+	if protocol == "" {
+		protocol = "http"
+	}
+	if port, _ := r.literal(node.str("HTTPSampler.port")); port != "" {
+		host += ":" + port
+	}
+	r.plan.BaseURL = protocol + "://" + host
+}
+
+func (r *jmxReader) timer(node *jmxNode, kind string) {
+	if r.waitSet {
+		r.plan.skip(node.label(), 0, "only one timer is converted")
+		return
+	}
+	delay, ok := r.number(node.str("ConstantTimer.delay"))
+	if !ok {
+		r.plan.skip(node.label(), 0, "delay is not a number")
+		return
+	}
+	spread := 0.0
+	if kind == "UniformRandomTimer" {
+		if spread, ok = r.number(node.str("RandomTimer.range")); !ok {
+			r.plan.skip(node.label(), 0, "range is not a number")
+			return
+		}
+	}
+	r.plan.WaitMin = delay / 1000
+	r.plan.WaitMax = (delay + spread) / 1000
+	r.waitSet = true
+}
+
+func (r *jmxReader) controllerFactor(node *jmxNode, kind string, factor float64) float64 {
+	switch kind {
+	case "ThroughputController":
+		if node.str("ThroughputController.style") != "1" {
+			r.plan.skip(node.label(), 0, "only percent throughput is converted; its samplers run at full weight")
+			return factor
+		}
+		percent := node.str("ThroughputController.percentThroughput")
+		for _, p := range node.Nodes {
+			if p.XMLName.Local == "FloatProperty" {
+				for _, field := range p.Nodes {
+					if field.XMLName.Local == "value" {
+						percent = strings.TrimSpace(field.Text)
+					}
+				}
+			}
+		}
+		if value, ok := r.number(percent); ok && value > 0 {
+			return factor * value / 100
+		}
+	case "LoopController":
+		if loops, ok := r.number(node.str("LoopController.loops")); ok && loops > 1 {
+			return factor * loops
+		}
+	}
+	return factor
+}
+
+func (r *jmxReader) headers(node *jmxNode, headers []Header) []Header {
+	list := node.prop("HeaderManager.headers")
+	if list == nil {
+		return headers
+	}
+	for i := range list.Nodes {
+		h := &list.Nodes[i]
+		name := r.value(h.str("Header.name"), node)
+		if name == "" {
+			continue
+		}
+		header := Header{Name: name, Value: r.value(h.str("Header.value"), node)}
+		replaced := false
+		for j := range headers {
+			if strings.EqualFold(headers[j].Name, name) {
+				headers[j] = header
+				replaced = true
+			}
+		}
+		if !replaced {
+			headers = append(headers, header)
+		}
+	}
+	return headers
+}
+
+func (r *jmxReader) sampler(node *jmxNode, children []jmxNode, headers []Header, factor float64) {
+	label := node.label()
+	method := strings.ToUpper(node.str("HTTPSampler.method"))
+	if method == "" {
+		method = "GET"
+	}
+	path := r.value(node.str("HTTPSampler.path"), node)
+	if u, err := url.Parse(path); err == nil && u.IsAbs() {
+		r.plan.skip(label, 0, "absolute URL; the request goes to the task's host")
+		path = u.RequestURI()
+	} else if domain := node.str("HTTPSampler.domain"); domain != "" {
+		r.plan.skip(label, 0, "sampler host "+domain+" is replaced by the task's host")
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if node.str("HTTPSampler.DO_MULTIPART_POST") == "true" || node.prop("HTTPsampler.Files") != nil {
+		r.plan.skip(label, 0, "file uploads are not supported")
+	}
+
+	// Sampler-level headers override the inherited ones.
+	var own []jmxNode
+	for i := 0; i < len(children); i++ {
+		child := &children[i]
+		if child.XMLName.Local == "hashTree" || child.attr("enabled") == "false" {
+			continue
+		}
+		switch kind := child.XMLName.Local; {
+		case kind == "HeaderManager":
+			own = append(own, *child)
+		case silentElements[kind]:
+		default:
+			r.plan.skip(child.label(), 0, "element not supported")
+		}
+	}
+	headers = append([]Header(nil), headers...)
+	for i := range own {
+		headers = r.headers(&own[i], headers)
+	}
+
+	req := Request{Label: node.attr("testname"), Method: method, Path: path, Headers: headers}
+	if req.Label == "" {
+		req.Label = method + " " + templatePath(strings.SplitN(path, "?", 2)[0])
+	}
+
+	var args [][2]string
+	if list := node.prop("HTTPsampler.Arguments"); list != nil {
+		if items := list.prop("Arguments.arguments"); items != nil {
+			for i := range items.Nodes {
+				arg := &items.Nodes[i]
+				args = append(args, [2]string{r.value(arg.str("Argument.name"), node), r.value(arg.str("Argument.value"), node)})
+			}
+		}
+	}
+	switch {
+	case node.str("HTTPSampler.postBodyRaw") == "true":
+		if len(args) > 0 {
+			req.Body = args[0][1]
+		}
+	case len(args) == 0:
+	case method == "GET" || method == "HEAD" || method == "DELETE" || method == "OPTIONS":
+		sep := "?"
+		if strings.Contains(req.Path, "?") {
+			sep = "&"
+		}
+		req.Path += sep + r.plan.encodeForm(args)
+	default:
+		req.Body = r.plan.encodeForm(args)
+		if !hasHeader(req.Headers, "Content-Type") {
+			req.Headers = append(req.Headers, Header{Name: "Content-Type", Value: "application/x-www-form-urlencoded"})
+		}
+	}
+
+	r.plan.Requests = append(r.plan.Requests, req)
+	r.factors = append(r.factors, factor)
+}
+
+// value resolves ${name} user variables and turns ${__P(name,default)} into
+// placeholders. Other expressions are kept verbatim and reported.
+func (r *jmxReader) value(text string, node *jmxNode) string {
+	return jmxExpr.ReplaceAllStringFunc(text, func(expr string) string {
+		inner := expr[2 : len(expr)-1]
+		if value, ok := r.vars[inner]; ok && !strings.Contains(value, "${") {
+			return value
+		}
+		if m := jmxProperty.FindStringSubmatch(inner); m != nil {
+			name := strings.TrimSpace(m[1])
+			switch {
+			case scriptparams.Reserved(name):
+				r.plan.skip(node.label(), 0, "platform property "+name+" is not available to scripts; its default is used")
+				return m[2]
+			case identPattern.MatchString(name):
+				return r.plan.variable(model.ScriptParameter{Name: name, Type: model.ParamTypeString, Default: m[2]})
+			}
+		}
+		r.plan.skip(node.label(), 0, "expression "+expr+" is kept verbatim")
+		return expr
+	})
+}
+
+// literal resolves text that may read a property and reports whether it
+// has a fixed value; properties contribute their default.
+func (r *jmxReader) literal(text string) (string, bool) {
+	m := jmxExpr.FindStringSubmatchIndex(text)
+	if m == nil {
+		return text, true
+	}
+	if m[0] != 0 || m[1] != len(text) {
+		return "", false
+	}
+	inner := text[2 : len(text)-1]
+	if value, ok := r.vars[inner]; ok {
+		return value, !strings.Contains(value, "${")
+	}
+	if p := jmxProperty.FindStringSubmatch(inner); p != nil && p[2] != "" {
+		return p[2], true
+	}
+	return "", false
+}
+
+func (r *jmxReader) number(text string) (float64, bool) {
+	value, ok := r.literal(text)
+	if !ok {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	return f, err == nil
+}
+
+var identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// encodeForm URL-encodes pairs, leaving placeholders intact.
+func (p *Plan) encodeForm(pairs [][2]string) string {
+	encoded := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		encoded = append(encoded, p.escape(pair[0])+"="+p.escape(pair[1]))
+	}
+	return strings.Join(encoded, "&")
+}
+
+func (p *Plan) escape(value string) string {
+	var b strings.Builder
+	for _, part := range p.split(value) {
+		if part.variable {
+			b.WriteString("{{" + part.text + "}}")
+		} else {
+			b.WriteString(url.QueryEscape(part.text))
+		}
+	}
+	return b.String()
+}
+
+func hasHeader(headers []Header, name string) bool {
+	for _, h := range headers {
+		if strings.EqualFold(h.Name, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package scriptgen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Locust renders the plan as a locustfile with one weighted task per
+// request. Placeholders read the environment variables the platform sets
+// from script parameters.
+func Locust(plan *Plan) (string, error) {
+	if len(plan.Requests) == 0 {
+		return "", ErrEmpty
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated from %s.\n", plan.Source)
+	if plan.BaseURL != "" {
+		fmt.Fprintf(&b, "# Source server: %s (runs target the task's host).\n", plan.BaseURL)
+	}
+	if plan.Duration > 0 {
+		fmt.Fprintf(&b, "# The source ran for %ds; set the task duration to match.\n", plan.Duration)
+	}
+	if len(plan.Variables) > 0 {
+		b.WriteString("import os\n\n")
+	}
+	wait := fmt.Sprintf("between(%s, %s)", pyFloat(plan.WaitMin), pyFloat(plan.WaitMax))
+	if plan.WaitMin == plan.WaitMax {
+		wait = fmt.Sprintf("constant(%s)", pyFloat(plan.WaitMin))
+		b.WriteString("from locust import HttpUser, constant, task\n\n")
+	} else {
+		b.WriteString("from locust import HttpUser, between, task\n\n")
+	}
+	for _, v := range plan.Variables {
+		fmt.Fprintf(&b, "%s = os.getenv(%s, %s)\n", v.Name, pyString(v.Name), pyString(v.Default))
+	}
+	if len(plan.Variables) > 0 {
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "\nclass GeneratedUser(HttpUser):\n    wait_time = %s\n", wait)
+
+	used := map[string]bool{}
+	for _, req := range plan.Requests {
+		weight := req.Weight
+		if weight < 1 {
+			weight = 1
+		}
+		fmt.Fprintf(&b, "\n    @task(%d)\n    def %s(self):\n", weight, identifier(req.Label, used))
+
+		args := []string{pyString(req.Method), pyValue(plan, req.Path), "name=" + pyString(req.Label)}
+		if len(req.Headers) > 0 {
+			headers := make([]string, 0, len(req.Headers))
+			for _, h := range req.Headers {
+				headers = append(headers, pyString(h.Name)+": "+pyValue(plan, h.Value))
+			}
+			args = append(args, "headers={"+strings.Join(headers, ", ")+"}")
+		}
+		if req.Body != "" {
+			args = append(args, "data="+pyValue(plan, req.Body))
+		}
+		fmt.Fprintf(&b, "        self.client.request(\n")
+		for _, arg := range args {
+			fmt.Fprintf(&b, "            %s,\n", arg)
+		}
+		b.WriteString("        )\n")
+	}
+	return b.String(), nil
+}
+
+// pyValue renders a value as a Python expression, concatenating literal
+// runs with the variables its placeholders name.
+func pyValue(plan *Plan, value string) string {
+	parts := plan.split(value)
+	if len(parts) == 0 {
+		return `""`
+	}
+	exprs := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p.variable {
+			exprs = append(exprs, p.text)
+		} else {
+			exprs = append(exprs, pyString(p.text))
+		}
+	}
+	return strings.Join(exprs, " + ")
+}
+
+// pyString quotes s as a Python string literal. strconv.Quote escapes are a
+// subset of Python's, so its output is valid Python.
+func pyString(s string) string {
+	return strconv.Quote(s)
+}
+
+func pyFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,478 @@
+package scriptgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"bench-hub/internal/model"
+)
+
+var clientMethods = map[string]bool{"get": true, "post": true, "put": true, "patch": true, "delete": true, "head": true, "options": true}
+
+// ignoredKwargs change how Locust records a response, not the request.
+var ignoredKwargs = map[string]bool{"name": true, "catch_response": true, "allow_redirects": true, "timeout": true}
+
+type locustReader struct {
+	plan *Plan
+	// names maps module-level names to their text: a placeholder for those
+	// read from the environment, else a constant.
+	names    map[string]string
+	userSeen bool
+}
+
+// FromLocust reads the first User class of a locustfile: its @task methods'
+// self.client calls with their headers, query parameters and bodies, the
+// task weights and wait_time. Module-level names read with os.getenv become
+// variables. Whatever else the file contains is listed in
+// Plan.Untranslated.
+func FromLocust(content string) (*Plan, error) {
+	lines, err := pyLines(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	r := &locustReader{plan: newPlan("locust", "Locust script", ""), names: map[string]string{}}
+	r.module(lines)
+	if len(r.plan.Requests) == 0 {
+		return nil, ErrEmpty
+	}
+	return r.plan, nil
+}
+
+func (r *locustReader) skip(l *pyLine, reason string) {
+	r.plan.skip(l.text, l.line, reason)
+}
+
+func (r *locustReader) module(lines []pyLine) {
+	for i := 0; i < len(lines); {
+		l := &lines[i]
+		end := blockEnd(lines, i)
+		switch first := l.first(); {
+		case first == "import" || first == "from" || isDocstring(l):
+		case first == "@":
+			// Decorated functions and classes are reported with their
+			// definition on the next line.
+		case first == "class":
+			r.class(l, lines[i+1:end])
+		case first == "def" || first == "async":
+			r.skip(l, "module-level functions are not translated")
+		case len(l.tokens) > 2 && l.tokens[1].text == "=" && l.tokens[0].kind == tokName:
+			r.assign(l)
+		default:
+			r.skip(l, "module-level statement not translated")
+		}
+		i = end
+	}
+}
+
+// assign records NAME = os.getenv("ENV", "default") as a variable and
+// string or number constants for later substitution.
+func (r *locustReader) assign(l *pyLine) {
+	name := l.tokens[0].text
+	p := &pyParser{toks: l.tokens[2:]}
+	e := p.expr()
+	if !p.done() {
+		r.skip(l, "module-level statement not translated")
+		return
+	}
+	if param, ok := envParam(e); ok {
+		r.names[name] = r.plan.variable(param)
+		return
+	}
+	if text, ok := r.text(e); ok && (e.kind == pyStr || e.kind == pyNum || e.kind == pyConcat) {
+		r.names[name] = text
+		return
+	}
+	r.skip(l, "module-level value not translated")
+}
+
+// envParam recognizes os.getenv(name, default), os.environ.get(name,
+// default) and os.environ[name].
+func envParam(e pyExpr) (model.ScriptParameter, bool) {
+	switch {
+	case e.kind == pyCall && (e.text == "os.getenv" || e.text == "os.environ.get" || e.text == "getenv") && len(e.items) >= 1 && e.items[0].kind == pyStr:
+		param := model.ScriptParameter{Name: e.items[0].text, Type: model.ParamTypeString}
+		if len(e.items) > 1 {
+			if e.items[1].kind != pyStr && e.items[1].kind != pyNum {
+				return param, false
+			}
+			param.Default = e.items[1].text
+		}
+		return param, identPattern.MatchString(param.Name)
+	case e.kind == pyIndex && (e.text == "os.environ" || e.text == "environ") && e.items[0].kind == pyStr:
+		param := model.ScriptParameter{Name: e.items[0].text, Type: model.ParamTypeString, Required: true}
+		return param, identPattern.MatchString(param.Name)
+	}
+	return model.ScriptParameter{}, false
+}
+
+func (r *locustReader) class(l *pyLine, body []pyLine) {
+	name := ""
+	if len(l.tokens) > 1 {
+		name = l.tokens[1].text
+	}
+	user := false
+	for _, tok := range l.tokens[2:] {
+		if tok.kind == tokName && strings.HasSuffix(tok.text, "User") {
+			user = true
+		}
+	}
+	switch {
+	case !user:
+		r.skip(l, "only User classes are translated")
+		return
+	case r.userSeen:
+		r.skip(l, "only the first User class is converted")
+		return
+	}
+	r.userSeen = true
+	r.plan.Name = name
+
+	var decorators []*pyLine
+	for i := 0; i < len(body); {
+		m := &body[i]
+		end := blockEnd(body, i)
+		switch first := m.first(); {
+		case first == "@":
+			decorators = append(decorators, m)
+			i = end
+			continue
+		case first == "def" && len(m.tokens) > 1:
+			method := m.tokens[1].text
+			if weight, ok := r.taskWeight(decorators); ok {
+				r.task(m, weight, body[i+1:end])
+			} else if method == "on_start" || method == "on_stop" {
+				r.skip(m, method+" is not translated")
+			}
+		case first == "pass" || isDocstring(m):
+		case len(m.tokens) > 2 && m.tokens[1].text == "=":
+			r.attribute(m)
+		default:
+			r.skip(m, "class statement not translated")
+		}
+		decorators = nil
+		i = end
+	}
+}
+
+// taskWeight reads @task and @task(n); other decorators are reported.
+func (r *locustReader) taskWeight(decorators []*pyLine) (int, bool) {
+	weight, isTask := 0, false
+	for _, d := range decorators {
+		p := &pyParser{toks: d.tokens[1:]}
+		e := p.expr()
+		switch {
+		case e.kind == pyRef && (e.text == "task" || e.text == "locust.task"):
+			weight, isTask = 1, true
+		case e.kind == pyCall && (e.text == "task" || e.text == "locust.task"):
+			arg, ok := e.kwarg("weight")
+			if !ok && len(e.items) > 0 {
+				arg, ok = e.items[0], true
+			}
+			weight, isTask = 1, true
+			if ok {
+				n, err := strconv.Atoi(arg.text)
+				if arg.kind != pyNum || err != nil || n < 1 {
+					r.skip(d, "task weight is not a positive integer; 1 is used")
+				} else {
+					weight = n
+				}
+			}
+		case (e.kind == pyRef || e.kind == pyCall) && (e.text == "tag" || e.text == "locust.tag"):
+		default:
+			r.skip(d, "decorator not translated")
+		}
+	}
+	return weight, isTask
+}
+
+func (r *locustReader) attribute(l *pyLine) {
+	name := l.tokens[0].text
+	p := &pyParser{toks: l.tokens[2:]}
+	e := p.expr()
+	switch name {
+	case "wait_time":
+		fn := e.text[strings.LastIndex(e.text, ".")+1:]
+		args := make([]float64, len(e.items))
+		for i, arg := range e.items {
+			v, err := strconv.ParseFloat(arg.text, 64)
+			if arg.kind != pyNum || err != nil {
+				fn = ""
+			}
+			args[i] = v
+		}
+		switch {
+		case e.kind == pyCall && fn == "between" && len(args) == 2:
+			r.plan.WaitMin, r.plan.WaitMax = args[0], args[1]
+		case e.kind == pyCall && fn == "constant" && len(args) == 1:
+			r.plan.WaitMin, r.plan.WaitMax = args[0], args[0]
+		case e.kind == pyCall && fn == "constant_pacing" && len(args) == 1:
+			r.plan.WaitMin, r.plan.WaitMax = args[0], args[0]
+			r.skip(l, "pacing is approximated by a constant wait")
+		default:
+			r.skip(l, "wait_time not translated; the default wait is used")
+		}
+	case "host":
+		if text, ok := r.text(e); ok {
+			r.plan.BaseURL = text
+		}
+	case "weight", "fixed_count", "abstract":
+	default:
+		r.skip(l, "class attribute not translated")
+	}
+}
+
+// task reads the requests a task method sends; each becomes a request with
+// the task's weight.
+func (r *locustReader) task(def *pyLine, weight int, body []pyLine) {
+	before := len(r.plan.Requests)
+	for i := 0; i < len(body); {
+		l := &body[i]
+		end := blockEnd(body, i)
+		p := &pyParser{toks: l.tokens}
+		with := false
+		switch {
+		case l.first() == "with":
+			p.pos, with = 1, true
+		case len(l.tokens) > 2 && l.tokens[0].kind == tokName && l.tokens[1].text == "=":
+			p.pos = 2
+		}
+
+		e := p.expr()
+		switch {
+		case l.first() == "pass" || isDocstring(l):
+		case e.kind == pyCall && strings.HasPrefix(e.text, "self.client."):
+			if req, ok := r.request(l, e); ok {
+				req.Weight = weight
+				r.plan.Requests = append(r.plan.Requests, req)
+			}
+			if with && end > i+1 {
+				r.skip(&body[i+1], "response handling not translated")
+			} else if !with && !p.done() {
+				r.skip(l, "statement not translated")
+			}
+		default:
+			r.skip(l, "statement not translated")
+		}
+		i = end
+	}
+	if len(r.plan.Requests) == before {
+		r.skip(def, "task sends no HTTP request")
+	}
+}
+
+func (r *locustReader) request(l *pyLine, call pyExpr) (Request, bool) {
+	verb := strings.TrimPrefix(call.text, "self.client.")
+	args := call.items
+	var req Request
+	switch {
+	case verb == "request":
+		method, ok := call.kwarg("method")
+		if !ok && len(args) > 0 {
+			method, args = args[0], args[1:]
+		}
+		text, ok := r.text(method)
+		if !ok {
+			r.skip(l, "request method is not a string")
+			return req, false
+		}
+		req.Method = strings.ToUpper(text)
+	case clientMethods[verb]:
+		req.Method = strings.ToUpper(verb)
+	default:
+		r.skip(l, "client method "+verb+" not translated")
+		return req, false
+	}
+
+	target, ok := call.kwarg("url")
+	if !ok && len(args) > 0 {
+		target, ok = args[0], true
+	}
+	path, textOK := r.text(target)
+	if !ok || !textOK {
+		r.skip(l, "request URL is not a string")
+		return req, false
+	}
+	if u, err := url.Parse(path); err == nil && u.IsAbs() {
+		r.skip(l, "absolute URL; the request goes to the task's host")
+		path = u.RequestURI()
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	req.Path = path
+
+	for i, key := range call.keys {
+		value := call.kwargs[i]
+		switch key {
+		case "name":
+			if text, ok := r.text(value); ok {
+				req.Label = text
+			}
+		case "headers":
+			headers, ok := r.pairs(value)
+			if !ok {
+				r.skip(l, "headers are not a literal dict")
+				continue
+			}
+			for _, h := range headers {
+				req.Headers = append(req.Headers, Header{Name: h[0], Value: h[1]})
+			}
+		case "params":
+			params, ok := r.pairs(value)
+			if !ok {
+				r.skip(l, "params are not a literal dict")
+				continue
+			}
+			sep := "?"
+			if strings.Contains(req.Path, "?") {
+				sep = "&"
+			}
+			req.Path += sep + r.plan.encodeForm(params)
+		case "json":
+			body, ok := r.json(value)
+			if !ok {
+				r.skip(l, "json body is not a literal")
+				continue
+			}
+			req.Body = body
+			if !hasHeader(req.Headers, "Content-Type") {
+				req.Headers = append(req.Headers, Header{Name: "Content-Type", Value: "application/json"})
+			}
+		case "data":
+			if value.kind == pyDict {
+				form, ok := r.pairs(value)
+				if !ok {
+					r.skip(l, "form data is not a literal dict")
+					continue
+				}
+				req.Body = r.plan.encodeForm(form)
+				if !hasHeader(req.Headers, "Content-Type") {
+					req.Headers = append(req.Headers, Header{Name: "Content-Type", Value: "application/x-www-form-urlencoded"})
+				}
+				continue
+			}
+			body, ok := r.text(value)
+			if !ok {
+				r.skip(l, "request body is not a string")
+				continue
+			}
+			req.Body = body
+		default:
+			if !ignoredKwargs[key] {
+				r.skip(l, "argument "+key+" not translated")
+			}
+		}
+	}
+	if req.Label == "" {
+		req.Label = req.Method + " " + templatePath(strings.SplitN(req.Path, "?", 2)[0])
+	}
+	return req, true
+}
+
+// text evaluates a string expression; names read from the environment
+// become placeholders.
+func (r *locustReader) text(e pyExpr) (string, bool) {
+	switch e.kind {
+	case pyStr, pyNum:
+		return e.text, true
+	case pyConcat:
+		var b strings.Builder
+		for _, part := range e.items {
+			text, ok := r.text(part)
+			if !ok {
+				return "", false
+			}
+			b.WriteString(text)
+		}
+		return b.String(), true
+	case pyRef:
+		text, ok := r.names[e.text]
+		return text, ok
+	case pyCall, pyIndex:
+		if e.kind == pyCall && e.text == "str" && len(e.items) == 1 {
+			return r.text(e.items[0])
+		}
+		if param, ok := envParam(e); ok {
+			return r.plan.variable(param), true
+		}
+	}
+	return "", false
+}
+
+// pairs evaluates a dict of strings.
+func (r *locustReader) pairs(e pyExpr) ([][2]string, bool) {
+	if e.kind != pyDict {
+		return nil, false
+	}
+	var out [][2]string
+	for i := 0; i+1 < len(e.items); i += 2 {
+		key, ok := r.text(e.items[i])
+		if !ok {
+			return nil, false
+		}
+		value, ok := r.text(e.items[i+1])
+		if !ok {
+			return nil, false
+		}
+		out = append(out, [2]string{key, value})
+	}
+	return out, true
+}
+
+// json renders a literal as Python's json.dumps would, keeping key order.
+func (r *locustReader) json(e pyExpr) (string, bool) {
+	switch e.kind {
+	case pyConst:
+		return map[string]string{"True": "true", "False": "false", "None": "null"}[e.text], true
+	case pyNum:
+		if _, err := strconv.ParseFloat(strings.ReplaceAll(e.text, "_", ""), 64); err != nil {
+			return "", false
+		}
+		return strings.ReplaceAll(e.text, "_", ""), true
+	case pyList:
+		items := make([]string, 0, len(e.items))
+		for _, item := range e.items {
+			text, ok := r.json(item)
+			if !ok {
+				return "", false
+			}
+			items = append(items, text)
+		}
+		return "[" + strings.Join(items, ", ") + "]", true
+	case pyDict:
+		fields := make([]string, 0, len(e.items)/2)
+		for i := 0; i+1 < len(e.items); i += 2 {
+			key, ok := r.text(e.items[i])
+			if !ok {
+				return "", false
+			}
+			value, ok := r.json(e.items[i+1])
+			if !ok {
+				return "", false
+			}
+			fields = append(fields, jsonString(key)+": "+value)
+		}
+		return "{" + strings.Join(fields, ", ") + "}", true
+	}
+	text, ok := r.text(e)
+	if !ok {
+		return "", false
+	}
+	return jsonString(text), true
+}
+
+func jsonString(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func isDocstring(l *pyLine) bool {
+	return len(l.tokens) > 0 && l.tokens[0].kind == tokString && len(l.tokens) == 1
+}
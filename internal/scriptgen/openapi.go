@@ -0,0 +1,322 @@
+package scriptgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type openAPIDoc struct {
+	OpenAPI string `yaml:"openapi"`
+	Info    struct {
+		Title   string `yaml:"title"`
+		Version string `yaml:"version"`
+	} `yaml:"info"`
+	Servers    []struct{ URL string } `yaml:"servers"`
+	Paths      map[string]openAPIPath `yaml:"paths"`
+	Security   []map[string][]string  `yaml:"security"`
+	Components struct {
+		Schemas         map[string]*openAPISchema        `yaml:"schemas"`
+		SecuritySchemes map[string]openAPISecurityScheme `yaml:"securitySchemes"`
+	} `yaml:"components"`
+}
+
+// openAPIPath holds the operations of one path, keyed by lower-case method,
+// plus the parameters shared by all of them.
+type openAPIPath map[string]yaml.Node
+
+type openAPIOperation struct {
+	OperationID string             `yaml:"operationId"`
+	Parameters  []openAPIParameter `yaml:"parameters"`
+	RequestBody *struct {
+		Content map[string]openAPIMedia `yaml:"content"`
+	} `yaml:"requestBody"`
+	Security   *[]map[string][]string `yaml:"security"`
+	Deprecated bool                   `yaml:"deprecated"`
+}
+
+type openAPIParameter struct {
+	Name     string         `yaml:"name"`
+	In       string         `yaml:"in"`
+	Required bool           `yaml:"required"`
+	Example  interface{}    `yaml:"example"`
+	Schema   *openAPISchema `yaml:"schema"`
+}
+
+type openAPIMedia struct {
+	Example interface{}    `yaml:"example"`
+	Schema  *openAPISchema `yaml:"schema"`
+}
+
+type openAPISchema struct {
+	Ref        string                    `yaml:"$ref"`
+	Type       string                    `yaml:"type"`
+	Format     string                    `yaml:"format"`
+	Example    interface{}               `yaml:"example"`
+	Default    interface{}               `yaml:"default"`
+	Enum       []interface{}             `yaml:"enum"`
+	Properties map[string]*openAPISchema `yaml:"properties"`
+	Items      *openAPISchema            `yaml:"items"`
+	AllOf      []*openAPISchema          `yaml:"allOf"`
+	OneOf      []*openAPISchema          `yaml:"oneOf"`
+	AnyOf      []*openAPISchema          `yaml:"anyOf"`
+}
+
+type openAPISecurityScheme struct {
+	Type   string `yaml:"type"`
+	Scheme string `yaml:"scheme"`
+	In     string `yaml:"in"`
+	Name   string `yaml:"name"`
+}
+
+var methodOrder = []string{"get", "post", "put", "patch", "delete", "head", "options"}
+
+// readWeights favours reads, which dominate typical traffic.
+var readWeights = map[string]int{"GET": 3, "HEAD": 1}
+
+// maxSchemaDepth stops sample bodies of recursive schemas.
+const maxSchemaDepth = 6
+
+// FromOpenAPI builds a plan with one request per operation of an OpenAPI 3
+// document in JSON or YAML. Parameters and bodies are filled from examples,
+// defaults or the schema; secured operations get credential placeholders.
+func FromOpenAPI(data []byte) (*Plan, error) {
+	var doc openAPIDoc
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("%w: only OpenAPI 3 documents are supported", ErrUnsupported)
+	}
+
+	baseURL, basePath := "", ""
+	if len(doc.Servers) > 0 {
+		baseURL = doc.Servers[0].URL
+		if u, err := url.Parse(baseURL); err == nil {
+			basePath = strings.TrimSuffix(u.Path, "/")
+		}
+	}
+	name := doc.Info.Title
+	if name == "" {
+		name = "openapi"
+	}
+	plan := newPlan(name, strings.TrimSpace(fmt.Sprintf("OpenAPI %q %s", doc.Info.Title, doc.Info.Version)), baseURL)
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		item := doc.Paths[path]
+		var shared []openAPIParameter
+		if node, ok := item["parameters"]; ok {
+			if err := node.Decode(&shared); err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrUnsupported, path, err)
+			}
+		}
+		for _, method := range methodOrder {
+			node, ok := item[method]
+			if !ok {
+				continue
+			}
+			var op openAPIOperation
+			if err := node.Decode(&op); err != nil {
+				return nil, fmt.Errorf("%w: %s %s: %v", ErrUnsupported, method, path, err)
+			}
+			if op.Deprecated {
+				continue
+			}
+			plan.Requests = append(plan.Requests, doc.request(plan, strings.ToUpper(method), basePath+path, op, shared))
+		}
+	}
+	if len(plan.Requests) == 0 {
+		return nil, ErrEmpty
+	}
+	return plan, nil
+}
+
+func (doc *openAPIDoc) request(plan *Plan, method, path string, op openAPIOperation, shared []openAPIParameter) Request {
+	req := Request{Label: method + " " + path, Method: method, Weight: 1}
+	if w, ok := readWeights[method]; ok {
+		req.Weight = w
+	}
+
+	params := map[string]openAPIParameter{}
+	for _, p := range shared {
+		params[p.In+":"+p.Name] = p
+	}
+	for _, p := range op.Parameters {
+		params[p.In+":"+p.Name] = p
+	}
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	query := url.Values{}
+	for _, key := range keys {
+		p := params[key]
+		value := fmt.Sprint(doc.sample(p.Schema, p.Example, 0))
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(value))
+		case "query":
+			if p.Required {
+				query.Set(p.Name, value)
+			}
+		case "header":
+			if p.Required {
+				req.Headers = append(req.Headers, Header{Name: p.Name, Value: value})
+			}
+		}
+	}
+	req.Path = path
+	if len(query) > 0 {
+		req.Path += "?" + query.Encode()
+	}
+
+	if op.RequestBody != nil {
+		req.Headers, req.Body = doc.body(req.Headers, op.RequestBody.Content)
+	}
+
+	security := doc.Security
+	if op.Security != nil {
+		security = *op.Security
+	}
+	req.Headers = append(req.Headers, doc.credentials(plan, security)...)
+	return req
+}
+
+// body prefers JSON, then form and text media types.
+func (doc *openAPIDoc) body(headers []Header, content map[string]openAPIMedia) ([]Header, string) {
+	for _, mediaType := range []string{"application/json", "application/x-www-form-urlencoded", "text/plain"} {
+		media, ok := content[mediaType]
+		if !ok {
+			continue
+		}
+		value := doc.sample(media.Schema, media.Example, 0)
+		headers = append(headers, Header{Name: "Content-Type", Value: mediaType})
+		switch mediaType {
+		case "application/json":
+			data, _ := json.Marshal(value)
+			return headers, string(data)
+		case "application/x-www-form-urlencoded":
+			form := url.Values{}
+			if fields, ok := value.(map[string]interface{}); ok {
+				for key, field := range fields {
+					form.Set(key, fmt.Sprint(field))
+				}
+			}
+			return headers, form.Encode()
+		default:
+			return headers, fmt.Sprint(value)
+		}
+	}
+	return headers, ""
+}
+
+// credentials returns placeholder headers for the first security
+// requirement that can be expressed as headers.
+func (doc *openAPIDoc) credentials(plan *Plan, security []map[string][]string) []Header {
+	for _, requirement := range security {
+		names := make([]string, 0, len(requirement))
+		for name := range requirement {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var headers []Header
+		ok := true
+		for _, name := range names {
+			scheme := doc.Components.SecuritySchemes[name]
+			switch {
+			case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+				headers = append(headers, Header{Name: "Authorization", Value: "Basic " + plan.placeholder("AUTH_BASIC", "base64 user:password for HTTP basic auth")})
+			case scheme.Type == "http" || scheme.Type == "oauth2" || scheme.Type == "openIdConnect":
+				headers = append(headers, Header{Name: "Authorization", Value: "Bearer " + plan.placeholder("AUTH_TOKEN", "bearer token sent in the Authorization header")})
+			case scheme.Type == "apiKey" && scheme.In == "header":
+				headers = append(headers, Header{Name: scheme.Name, Value: plan.placeholder("API_KEY", "API key sent in the "+scheme.Name+" header")})
+			default:
+				ok = false
+			}
+		}
+		if ok {
+			return headers
+		}
+	}
+	return nil
+}
+
+// sample picks a value for a schema: the given example, the schema's own
+// example, default or first enum value, else a placeholder of its type.
+func (doc *openAPIDoc) sample(schema *openAPISchema, example interface{}, depth int) interface{} {
+	if example != nil {
+		return example
+	}
+	if schema == nil || depth > maxSchemaDepth {
+		return nil
+	}
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		return doc.sample(doc.Components.Schemas[name], nil, depth+1)
+	}
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	case len(schema.AllOf) > 0:
+		merged := map[string]interface{}{}
+		for _, part := range schema.AllOf {
+			if fields, ok := doc.sample(part, nil, depth+1).(map[string]interface{}); ok {
+				for key, value := range fields {
+					merged[key] = value
+				}
+			}
+		}
+		return merged
+	case len(schema.OneOf) > 0:
+		return doc.sample(schema.OneOf[0], nil, depth+1)
+	case len(schema.AnyOf) > 0:
+		return doc.sample(schema.AnyOf[0], nil, depth+1)
+	}
+
+	switch schema.Type {
+	case "object", "":
+		if schema.Type == "" && len(schema.Properties) == 0 {
+			return "string"
+		}
+		fields := map[string]interface{}{}
+		for name, property := range schema.Properties {
+			fields[name] = doc.sample(property, nil, depth+1)
+		}
+		return fields
+	case "array":
+		return []interface{}{doc.sample(schema.Items, nil, depth+1)}
+	case "integer", "number":
+		return 1
+	case "boolean":
+		return true
+	default:
+		switch schema.Format {
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "date":
+			return "2024-01-01"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000001"
+		}
+		return "string"
+	}
+}
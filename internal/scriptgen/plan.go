@@ -0,0 +1,184 @@
+// Package scriptgen converts load tests between formats through an
+// engine-neutral plan: readers build a Plan from an OpenAPI document, a HAR
+// recording, a JMeter plan or a locustfile, and writers render it as a
+// Locust or JMeter script.
+package scriptgen
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"bench-hub/internal/model"
+)
+
+var (
+	ErrUnsupported = errors.New("unsupported source document")
+	ErrEmpty       = errors.New("no requests to generate")
+)
+
+// Plan is a load test of identical virtual users issuing weighted HTTP
+// requests with a random wait between them.
+type Plan struct {
+	Name string
+	// Source describes where the plan came from, for the generated header.
+	Source string
+	// BaseURL is the server the source was written against; scripts run
+	// against the task's target host instead.
+	BaseURL  string
+	Requests []Request
+	// Variables are the placeholders header values reference as {{NAME}};
+	// they become script parameters.
+	Variables []model.ScriptParameter
+	// WaitMin and WaitMax bound the think time in seconds.
+	WaitMin float64
+	WaitMax float64
+	// Duration is the run length in seconds the source fixed, 0 if none.
+	Duration int
+	// Untranslated lists what the reader could not express in the plan.
+	Untranslated []model.UntranslatedElement
+}
+
+type Request struct {
+	// Label groups the request in reports, e.g. "GET /pets/{id}".
+	Label  string
+	Method string
+	// Path is the concrete path requested, query string included.
+	Path    string
+	Headers []Header
+	Body    string
+	Weight  int
+}
+
+type Header struct {
+	Name  string
+	Value string
+}
+
+const (
+	defaultWaitMin = 1
+	defaultWaitMax = 3
+)
+
+func newPlan(name, source, baseURL string) *Plan {
+	return &Plan{Name: name, Source: source, BaseURL: baseURL, WaitMin: defaultWaitMin, WaitMax: defaultWaitMax}
+}
+
+// placeholder adds a variable once and returns its {{NAME}} reference.
+func (p *Plan) placeholder(name, description string) string {
+	return p.variable(model.ScriptParameter{Name: name, Type: model.ParamTypeString, Description: description})
+}
+
+// variable declares param unless a variable of that name exists and
+// returns its {{NAME}} reference.
+func (p *Plan) variable(param model.ScriptParameter) string {
+	if !p.declared(param.Name) {
+		p.Variables = append(p.Variables, param)
+	}
+	return "{{" + param.Name + "}}"
+}
+
+func (p *Plan) skip(element string, line int, reason string) {
+	p.Untranslated = append(p.Untranslated, model.UntranslatedElement{Element: element, Line: line, Reason: reason})
+}
+
+func (p *Plan) declared(name string) bool {
+	for _, v := range p.Variables {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Plan) defaultOf(name string) string {
+	for _, v := range p.Variables {
+		if v.Name == name {
+			return v.Default
+		}
+	}
+	return ""
+}
+
+var placeholderPattern = regexp.MustCompile(`\{\{([A-Za-z_][A-Za-z0-9_]*)\}\}`)
+
+// part is a run of literal text or, when variable is set, a placeholder
+// name.
+type part struct {
+	text     string
+	variable bool
+}
+
+// split cuts value at the placeholders of declared variables; other
+// {{...}} text is kept literally.
+func (p *Plan) split(value string) []part {
+	var parts []part
+	last := 0
+	for _, match := range placeholderPattern.FindAllStringSubmatchIndex(value, -1) {
+		if !p.declared(value[match[2]:match[3]]) {
+			continue
+		}
+		if match[0] > last {
+			parts = append(parts, part{text: value[last:match[0]]})
+		}
+		parts = append(parts, part{text: value[match[2]:match[3]], variable: true})
+		last = match[1]
+	}
+	if last < len(value) {
+		parts = append(parts, part{text: value[last:]})
+	}
+	return parts
+}
+
+var (
+	idSegment   = regexp.MustCompile(`^([0-9]+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})$`)
+	nonIdentRun = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// templatePath replaces numeric and UUID path segments with {id} so
+// recorded requests to different resources share a label.
+func templatePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if idSegment.MatchString(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// identifier turns a label into a unique snake_case name.
+func identifier(label string, used map[string]bool) string {
+	name := strings.Trim(nonIdentRun.ReplaceAllString(strings.ToLower(label), "_"), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "request_" + name
+	}
+	base := name
+	for i := 2; used[name]; i++ {
+		name = base + "_" + strconv.Itoa(i)
+	}
+	used[name] = true
+	return name
+}
+
+// FromSource detects whether data is a HAR recording or an OpenAPI 3
+// document and reads it.
+func FromSource(data []byte) (*Plan, error) {
+	var probe map[string]interface{}
+	if err := yaml.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	switch {
+	case probe["log"] != nil:
+		return FromHAR(data)
+	case probe["openapi"] != nil:
+		return FromOpenAPI(data)
+	case probe["swagger"] != nil:
+		return nil, fmt.Errorf("%w: only OpenAPI 3 documents are supported", ErrUnsupported)
+	}
+	return nil, fmt.Errorf("%w: expected an OpenAPI 3 document or a HAR file", ErrUnsupported)
+}
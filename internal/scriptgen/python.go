@@ -0,0 +1,600 @@
+package scriptgen
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A minimal Python reader: enough of the lexical grammar to split a
+// locustfile into logical lines, and an expression parser for the literals,
+// names and calls the locustfile reader interprets. Anything else parses as
+// pyOther and is reported rather than translated.
+
+type pyTokenKind int
+
+const (
+	tokName pyTokenKind = iota
+	tokNumber
+	tokString
+	tokOp
+)
+
+type pyToken struct {
+	kind pyTokenKind
+	// text is the decoded value for strings and the source text otherwise.
+	text string
+	// fstring marks an f-string, whose text still holds the {expressions}.
+	fstring bool
+}
+
+// pyLine is a logical line: a statement, possibly spanning several physical
+// lines inside brackets.
+type pyLine struct {
+	indent int
+	line   int
+	// text is the first physical line, for reports.
+	text   string
+	tokens []pyToken
+}
+
+func (l *pyLine) first() string {
+	if len(l.tokens) == 0 {
+		return ""
+	}
+	return l.tokens[0].text
+}
+
+// blockEnd returns the index after the lines indented under lines[i].
+func blockEnd(lines []pyLine, i int) int {
+	j := i + 1
+	for j < len(lines) && lines[j].indent > lines[i].indent {
+		j++
+	}
+	return j
+}
+
+var errPySyntax = errors.New("unterminated string")
+
+var pyOps = []string{"**=", "//=", ">>=", "<<=", "...", "->", ":=", "==", "!=", "<=", ">=", "**", "//", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "<<", ">>"}
+
+func pyLines(src string) ([]pyLine, error) {
+	physical := strings.Split(src, "\n")
+	var lines []pyLine
+	var cur *pyLine
+	depth, line, i := 0, 1, 0
+	lineStart := true
+
+	for i < len(src) {
+		c := src[i]
+		if lineStart && depth == 0 && cur == nil {
+			indent := 0
+			for i < len(src) && (src[i] == ' ' || src[i] == '\t' || src[i] == '\f') {
+				if src[i] == '\t' {
+					indent = indent/8*8 + 8
+				} else {
+					indent++
+				}
+				i++
+			}
+			lineStart = false
+			if i < len(src) && src[i] != '\n' && src[i] != '#' && src[i] != '\r' {
+				cur = &pyLine{indent: indent, line: line, text: strings.TrimSpace(physical[line-1])}
+			}
+			continue
+		}
+
+		switch {
+		case c == '\n':
+			line++
+			i++
+			lineStart = true
+			if depth == 0 && cur != nil {
+				lines = append(lines, *cur)
+				cur = nil
+			}
+		case c == ' ' || c == '\t' || c == '\f' || c == '\r':
+			i++
+		case c == '\\' && i+1 < len(src) && (src[i+1] == '\n' || src[i+1] == '\r'):
+			i += 2
+			if src[i-1] == '\r' && i < len(src) && src[i] == '\n' {
+				i++
+			}
+			line++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case isPyNameStart(c):
+			start := i
+			for i < len(src) && isPyNameChar(src[i]) {
+				i++
+			}
+			word := src[start:i]
+			if i < len(src) && (src[i] == '"' || src[i] == '\'') && isStringPrefix(word) {
+				tok, n, newlines, err := lexString(src[i:], word)
+				if err != nil {
+					return nil, err
+				}
+				i += n
+				line += newlines
+				cur.tokens = append(cur.tokens, tok)
+				continue
+			}
+			cur.tokens = append(cur.tokens, pyToken{kind: tokName, text: word})
+		case c == '"' || c == '\'':
+			tok, n, newlines, err := lexString(src[i:], "")
+			if err != nil {
+				return nil, err
+			}
+			i += n
+			line += newlines
+			cur.tokens = append(cur.tokens, tok)
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			for i < len(src) && (isPyNameChar(src[i]) || src[i] == '.' ||
+				(src[i] == '-' || src[i] == '+') && (src[i-1] == 'e' || src[i-1] == 'E') && !strings.HasPrefix(src[start:], "0x")) {
+				i++
+			}
+			cur.tokens = append(cur.tokens, pyToken{kind: tokNumber, text: src[start:i]})
+		default:
+			op := string(c)
+			for _, candidate := range pyOps {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			switch op {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				if depth > 0 {
+					depth--
+				}
+			}
+			i += len(op)
+			cur.tokens = append(cur.tokens, pyToken{kind: tokOp, text: op})
+		}
+	}
+	if cur != nil {
+		lines = append(lines, *cur)
+	}
+	return lines, nil
+}
+
+func isPyNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= utf8.RuneSelf
+}
+
+func isPyNameChar(c byte) bool {
+	return isPyNameStart(c) || c >= '0' && c <= '9'
+}
+
+func isStringPrefix(word string) bool {
+	switch strings.ToLower(word) {
+	case "r", "u", "b", "f", "br", "rb", "fr", "rf":
+		return true
+	}
+	return false
+}
+
+// lexString reads the string literal at the start of src and returns it
+// with the bytes and newlines it spans.
+func lexString(src, prefix string) (pyToken, int, int, error) {
+	prefix = strings.ToLower(prefix)
+	raw := strings.Contains(prefix, "r")
+	quote := src[:1]
+	if strings.HasPrefix(src, strings.Repeat(quote, 3)) {
+		quote = strings.Repeat(quote, 3)
+	}
+
+	var b strings.Builder
+	newlines := 0
+	i := len(quote)
+	for {
+		if i >= len(src) || (len(quote) == 1 && src[i] == '\n') {
+			return pyToken{}, 0, 0, errPySyntax
+		}
+		if strings.HasPrefix(src[i:], quote) {
+			i += len(quote)
+			break
+		}
+		c := src[i]
+		if c == '\n' {
+			newlines++
+		}
+		if c != '\\' || i+1 >= len(src) {
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		next := src[i+1]
+		if next == '\n' {
+			newlines++
+		}
+		if raw {
+			b.WriteByte(c)
+			b.WriteByte(next)
+			i += 2
+			continue
+		}
+		i += 2
+		switch next {
+		case '\n':
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '0':
+			b.WriteByte(0)
+		case '\\', '\'', '"':
+			b.WriteByte(next)
+		case 'x', 'u', 'U':
+			size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[next]
+			if i+size <= len(src) {
+				if r, err := strconv.ParseUint(src[i:i+size], 16, 32); err == nil {
+					b.WriteRune(rune(r))
+					i += size
+					continue
+				}
+			}
+			b.WriteByte('\\')
+			b.WriteByte(next)
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(next)
+		}
+	}
+	return pyToken{kind: tokString, text: b.String(), fstring: strings.Contains(prefix, "f")}, i, newlines, nil
+}
+
+type pyKind int
+
+const (
+	pyOther pyKind = iota
+	pyStr
+	pyNum
+	pyConst
+	pyRef
+	pyCall
+	pyIndex
+	pyList
+	pyDict
+	pyConcat
+)
+
+// pyExpr is a parsed expression. text is the string value, the number or
+// constant literal, or the dotted name of a reference, call target or
+// subscripted value. items are list elements, alternating dict keys and
+// values, concatenated parts, a call's positional arguments or a subscript.
+type pyExpr struct {
+	kind   pyKind
+	text   string
+	items  []pyExpr
+	keys   []string
+	kwargs []pyExpr
+}
+
+func (e pyExpr) kwarg(name string) (pyExpr, bool) {
+	for i, key := range e.keys {
+		if key == name {
+			return e.kwargs[i], true
+		}
+	}
+	return pyExpr{}, false
+}
+
+type pyParser struct {
+	toks []pyToken
+	pos  int
+}
+
+func (p *pyParser) peek() string {
+	if p.pos >= len(p.toks) || p.toks[p.pos].kind == tokString {
+		return ""
+	}
+	return p.toks[p.pos].text
+}
+
+func (p *pyParser) done() bool {
+	return p.pos >= len(p.toks)
+}
+
+// expr parses an expression; + joins operands, any other operator makes it
+// pyOther.
+func (p *pyParser) expr() pyExpr {
+	left := p.unary()
+	for !p.done() {
+		switch op := p.peek(); op {
+		case "+":
+			p.pos++
+			right := p.unary()
+			if left.kind == pyNum && right.kind == pyNum {
+				left = pyExpr{kind: pyOther}
+				continue
+			}
+			left = pyExpr{kind: pyConcat, items: append(concatParts(left), concatParts(right)...)}
+		case ",", ")", "]", "}", ":", "=", "as":
+			return left
+		default:
+			p.skipOperand()
+			left = pyExpr{kind: pyOther}
+		}
+	}
+	return left
+}
+
+func concatParts(e pyExpr) []pyExpr {
+	if e.kind == pyConcat {
+		return e.items
+	}
+	return []pyExpr{e}
+}
+
+// skipOperand skips tokens up to the end of the enclosing expression.
+func (p *pyParser) skipOperand() {
+	depth := 0
+	for !p.done() {
+		switch p.peek() {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			if depth == 0 {
+				return
+			}
+			depth--
+		case ",", ":", "=", "as":
+			if depth == 0 {
+				return
+			}
+		}
+		p.pos++
+	}
+}
+
+func (p *pyParser) unary() pyExpr {
+	if p.peek() == "-" && p.pos+1 < len(p.toks) && p.toks[p.pos+1].kind == tokNumber {
+		p.pos += 2
+		return pyExpr{kind: pyNum, text: "-" + p.toks[p.pos-1].text}
+	}
+	return p.postfix(p.atom())
+}
+
+func (p *pyParser) atom() pyExpr {
+	if p.done() {
+		return pyExpr{kind: pyOther}
+	}
+	tok := p.toks[p.pos]
+	switch tok.kind {
+	case tokString:
+		var parts []pyExpr
+		for !p.done() && p.toks[p.pos].kind == tokString {
+			t := p.toks[p.pos]
+			if t.fstring {
+				parts = append(parts, fstringParts(t.text)...)
+			} else {
+				parts = append(parts, pyExpr{kind: pyStr, text: t.text})
+			}
+			p.pos++
+		}
+		if len(parts) == 1 {
+			return parts[0]
+		}
+		return pyExpr{kind: pyConcat, items: parts}
+	case tokNumber:
+		p.pos++
+		return pyExpr{kind: pyNum, text: tok.text}
+	case tokName:
+		p.pos++
+		switch tok.text {
+		case "True", "False", "None":
+			return pyExpr{kind: pyConst, text: tok.text}
+		case "lambda", "not", "await", "yield":
+			p.skipOperand()
+			return pyExpr{kind: pyOther}
+		}
+		return pyExpr{kind: pyRef, text: tok.text}
+	}
+
+	p.pos++
+	switch tok.text {
+	case "(":
+		e := p.expr()
+		if p.peek() != ")" {
+			p.skipPast()
+			return pyExpr{kind: pyOther}
+		}
+		p.pos++
+		return e
+	case "[":
+		items, ok := p.sequence("]")
+		if !ok {
+			return pyExpr{kind: pyOther}
+		}
+		return pyExpr{kind: pyList, items: items}
+	case "{":
+		return p.dict()
+	}
+	p.skipOperand()
+	return pyExpr{kind: pyOther}
+}
+
+// skipPast skips to just past the bracket closing the current one.
+func (p *pyParser) skipPast() {
+	depth := 0
+	for !p.done() {
+		switch p.peek() {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			if depth == 0 {
+				p.pos++
+				return
+			}
+			depth--
+		}
+		p.pos++
+	}
+}
+
+// sequence parses comma-separated expressions up to closing.
+func (p *pyParser) sequence(closing string) ([]pyExpr, bool) {
+	var items []pyExpr
+	for !p.done() && p.peek() != closing {
+		items = append(items, p.expr())
+		switch p.peek() {
+		case ",":
+			p.pos++
+		case closing:
+		default:
+			p.skipPast()
+			return nil, false
+		}
+	}
+	p.pos++
+	return items, true
+}
+
+func (p *pyParser) dict() pyExpr {
+	var items []pyExpr
+	for !p.done() && p.peek() != "}" {
+		if p.peek() == "**" {
+			p.skipPast()
+			return pyExpr{kind: pyOther}
+		}
+		key := p.expr()
+		if p.peek() != ":" {
+			p.skipPast()
+			return pyExpr{kind: pyOther}
+		}
+		p.pos++
+		items = append(items, key, p.expr())
+		switch p.peek() {
+		case ",":
+			p.pos++
+		case "}":
+		default:
+			p.skipPast()
+			return pyExpr{kind: pyOther}
+		}
+	}
+	p.pos++
+	return pyExpr{kind: pyDict, items: items}
+}
+
+func (p *pyParser) postfix(e pyExpr) pyExpr {
+	for !p.done() {
+		switch p.peek() {
+		case ".":
+			p.pos++
+			if p.done() || p.toks[p.pos].kind != tokName {
+				return pyExpr{kind: pyOther}
+			}
+			name := p.toks[p.pos].text
+			p.pos++
+			if e.kind == pyRef {
+				e.text += "." + name
+			} else {
+				e = pyExpr{kind: pyOther}
+			}
+		case "(":
+			p.pos++
+			e = p.call(e)
+		case "[":
+			p.pos++
+			index := p.expr()
+			if p.peek() != "]" {
+				p.skipPast()
+				return pyExpr{kind: pyOther}
+			}
+			p.pos++
+			if e.kind == pyRef {
+				e = pyExpr{kind: pyIndex, text: e.text, items: []pyExpr{index}}
+			} else {
+				e = pyExpr{kind: pyOther}
+			}
+		default:
+			return e
+		}
+	}
+	return e
+}
+
+func (p *pyParser) call(target pyExpr) pyExpr {
+	call := pyExpr{kind: pyCall}
+	if target.kind == pyRef {
+		call.text = target.text
+	}
+	for !p.done() && p.peek() != ")" {
+		if p.peek() == "*" || p.peek() == "**" {
+			p.skipPast()
+			return pyExpr{kind: pyOther}
+		}
+		if p.toks[p.pos].kind == tokName && p.pos+1 < len(p.toks) && p.toks[p.pos+1].kind == tokOp && p.toks[p.pos+1].text == "=" {
+			call.keys = append(call.keys, p.toks[p.pos].text)
+			p.pos += 2
+			call.kwargs = append(call.kwargs, p.expr())
+		} else {
+			call.items = append(call.items, p.expr())
+		}
+		switch p.peek() {
+		case ",":
+			p.pos++
+		case ")":
+		default:
+			p.skipPast()
+			return pyExpr{kind: pyOther}
+		}
+	}
+	p.pos++
+	if target.kind != pyRef {
+		return pyExpr{kind: pyOther}
+	}
+	return call
+}
+
+// fstringParts splits an f-string into literal text and the names it
+// interpolates; other replacement fields make a pyOther part.
+func fstringParts(text string) []pyExpr {
+	var parts []pyExpr
+	var lit strings.Builder
+	flush := func() {
+		if lit.Len() > 0 {
+			parts = append(parts, pyExpr{kind: pyStr, text: lit.String()})
+			lit.Reset()
+		}
+	}
+	for i := 0; i < len(text); i++ {
+		switch {
+		case strings.HasPrefix(text[i:], "{{"), strings.HasPrefix(text[i:], "}}"):
+			lit.WriteByte(text[i])
+			i++
+		case text[i] == '{':
+			end := strings.IndexByte(text[i:], '}')
+			if end < 0 {
+				return []pyExpr{{kind: pyOther}}
+			}
+			field := strings.TrimSpace(text[i+1 : i+end])
+			flush()
+			if dotted := strings.ReplaceAll(field, ".", ""); dotted != "" && strings.IndexFunc(dotted, func(r rune) bool {
+				return !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+			}) < 0 {
+				parts = append(parts, pyExpr{kind: pyRef, text: field})
+			} else {
+				parts = append(parts, pyExpr{kind: pyOther})
+			}
+			i += end
+		default:
+			lit.WriteByte(text[i])
+		}
+	}
+	flush()
+	if len(parts) == 0 {
+		parts = append(parts, pyExpr{kind: pyStr})
+	}
+	return parts
+}
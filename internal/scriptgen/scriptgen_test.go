@@ -0,0 +1,404 @@
+package scriptgen
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bench-hub/internal/engine/jmeter"
+	"bench-hub/internal/scriptcheck"
+)
+
+const petstore = `openapi: 3.0.3
+info:
+  title: Petstore
+  version: "1.0"
+servers:
+  - url: https://petstore.example.com/v1
+security:
+  - bearer: []
+paths:
+  /pets:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          required: true
+          schema: {type: integer, default: 20}
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        schema: {type: string, format: uuid}
+    get:
+      security:
+        - key: []
+    delete:
+      deprecated: true
+components:
+  securitySchemes:
+    bearer: {type: http, scheme: bearer}
+    key: {type: apiKey, in: header, name: X-API-Key}
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name: {type: string, example: rex}
+        tag: {type: string, enum: [dog, cat]}
+`
+
+func TestFromOpenAPI(t *testing.T) {
+	plan, err := FromOpenAPI([]byte(petstore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Requests) != 3 {
+		t.Fatalf("expected 3 requests, got %+v", plan.Requests)
+	}
+
+	list := plan.Requests[0]
+	if list.Label != "GET /v1/pets" || list.Path != "/v1/pets?limit=20" || list.Weight != 3 {
+		t.Fatalf("unexpected list request %+v", list)
+	}
+	if len(list.Headers) != 1 || list.Headers[0].Value != "Bearer {{AUTH_TOKEN}}" {
+		t.Fatalf("expected bearer placeholder, got %+v", list.Headers)
+	}
+
+	create := plan.Requests[1]
+	if create.Method != "POST" || create.Weight != 1 || create.Body != `{"name":"rex","tag":"dog"}` {
+		t.Fatalf("unexpected create request %+v", create)
+	}
+
+	get := plan.Requests[2]
+	if get.Path != "/v1/pets/00000000-0000-0000-0000-000000000001" || get.Headers[0].Name != "X-API-Key" || get.Headers[0].Value != "{{API_KEY}}" {
+		t.Fatalf("unexpected get request %+v", get)
+	}
+	if len(plan.Variables) != 2 {
+		t.Fatalf("expected AUTH_TOKEN and API_KEY, got %+v", plan.Variables)
+	}
+
+	if _, err := FromOpenAPI([]byte(`swagger: "2.0"`)); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported for swagger 2, got %v", err)
+	}
+}
+
+const recording = `{"log": {"creator": {"name": "Firefox", "version": "120"}, "entries": [
+  {"request": {"method": "GET", "url": "https://shop.example.com/api/items/12", "headers": [
+    {"name": "Accept", "value": "application/json"},
+    {"name": "Authorization", "value": "Bearer secret"},
+    {"name": "Cookie", "value": "sid=1"}]}},
+  {"request": {"method": "GET", "url": "https://shop.example.com/api/items/13", "headers": []}},
+  {"request": {"method": "GET", "url": "https://shop.example.com/static/app.js", "headers": []}},
+  {"request": {"method": "GET", "url": "https://cdn.example.com/font", "headers": []}},
+  {"request": {"method": "POST", "url": "https://shop.example.com/api/cart", "headers": [
+    {"name": "Content-Type", "value": "application/json"}],
+    "postData": {"mimeType": "application/json", "text": "{\"item\": 12}"}}}
+]}}`
+
+func TestFromHAR(t *testing.T) {
+	plan, err := FromHAR([]byte(recording))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.BaseURL != "https://shop.example.com" || len(plan.Requests) != 2 {
+		t.Fatalf("unexpected plan %+v", plan)
+	}
+
+	items := plan.Requests[0]
+	if items.Label != "GET /api/items/{id}" || items.Path != "/api/items/12" || items.Weight != 2 {
+		t.Fatalf("unexpected grouped request %+v", items)
+	}
+	if len(items.Headers) != 2 || items.Headers[1].Value != "Bearer {{AUTH_TOKEN}}" {
+		t.Fatalf("expected accept and token placeholder, got %+v", items.Headers)
+	}
+	if cart := plan.Requests[1]; cart.Body != `{"item": 12}` || cart.Headers[0].Name != "Content-Type" {
+		t.Fatalf("unexpected cart request %+v", cart)
+	}
+}
+
+func TestLocust(t *testing.T) {
+	plan, err := FromHAR([]byte(recording))
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := Locust(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`AUTH_TOKEN = os.getenv("AUTH_TOKEN", "")`,
+		"wait_time = between(1, 3)",
+		"@task(2)\n    def get_api_items_id(self):",
+		`headers={"Accept": "application/json", "Authorization": "Bearer " + AUTH_TOKEN}`,
+		`data="{\"item\": 12}"`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Fatalf("script lacks %q:\n%s", want, script)
+		}
+	}
+	if strings.Contains(script, "secret") {
+		t.Fatalf("recorded credential leaked:\n%s", script)
+	}
+}
+
+func TestJMeter(t *testing.T) {
+	for name, source := range map[string]func([]byte) (*Plan, error){"openapi": FromOpenAPI, "har": FromHAR} {
+		data := petstore
+		if name == "har" {
+			data = recording
+		}
+		plan, err := source([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, params, err := JMeter(plan)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result := scriptcheck.Result(jmeter.Check(content, params)); !result.Valid || len(result.Issues) != 0 {
+			t.Fatalf("%s: generated plan should validate cleanly, got %+v", name, result.Issues)
+		}
+		if strings.Count(content, "<HTTPSamplerProxy ") != len(plan.Requests) || !strings.Contains(content, "ThroughputController") {
+			t.Fatalf("%s: expected weighted samplers:\n%s", name, content)
+		}
+		if strings.Contains(content, "health-template") {
+			t.Fatalf("%s: template name kept", name)
+		}
+	}
+}
+
+const shopPlan = `<?xml version="1.0" encoding="UTF-8"?>
+<jmeterTestPlan version="1.2">
+  <hashTree>
+    <TestPlan testclass="TestPlan" testname="shop" enabled="true">
+      <elementProp name="TestPlan.user_defined_variables" elementType="Arguments">
+        <collectionProp name="Arguments.arguments">
+          <elementProp name="base" elementType="Argument">
+            <stringProp name="Argument.name">base</stringProp>
+            <stringProp name="Argument.value">/api</stringProp>
+          </elementProp>
+        </collectionProp>
+      </elementProp>
+    </TestPlan>
+    <hashTree>
+      <ThreadGroup testclass="ThreadGroup" testname="users" enabled="true">
+        <stringProp name="ThreadGroup.num_threads">${__P(threads,1)}</stringProp>
+        <boolProp name="ThreadGroup.scheduler">true</boolProp>
+        <stringProp name="ThreadGroup.duration">120</stringProp>
+      </ThreadGroup>
+      <hashTree>
+        <HeaderManager testclass="HeaderManager" testname="auth" enabled="true">
+          <collectionProp name="HeaderManager.headers">
+            <elementProp name="" elementType="Header">
+              <stringProp name="Header.name">Authorization</stringProp>
+              <stringProp name="Header.value">Bearer ${__P(token,)}</stringProp>
+            </elementProp>
+          </collectionProp>
+        </HeaderManager>
+        <hashTree/>
+        <UniformRandomTimer testclass="UniformRandomTimer" testname="think" enabled="true">
+          <stringProp name="ConstantTimer.delay">${__P(delay_ms,500)}</stringProp>
+          <stringProp name="RandomTimer.range">1500</stringProp>
+        </UniformRandomTimer>
+        <hashTree/>
+        <HTTPSamplerProxy testclass="HTTPSamplerProxy" testname="list items" enabled="true">
+          <elementProp name="HTTPsampler.Arguments" elementType="Arguments">
+            <collectionProp name="Arguments.arguments">
+              <elementProp name="q" elementType="HTTPArgument">
+                <stringProp name="Argument.name">q</stringProp>
+                <stringProp name="Argument.value">red shoes</stringProp>
+              </elementProp>
+            </collectionProp>
+          </elementProp>
+          <stringProp name="HTTPSampler.path">${base}/items</stringProp>
+          <stringProp name="HTTPSampler.method">GET</stringProp>
+        </HTTPSamplerProxy>
+        <hashTree>
+          <ResponseAssertion testclass="ResponseAssertion" testname="is 200" enabled="true"/>
+          <hashTree/>
+        </hashTree>
+        <ThroughputController testclass="ThroughputController" testname="rare" enabled="true">
+          <intProp name="ThroughputController.style">1</intProp>
+          <FloatProperty>
+            <name>ThroughputController.percentThroughput</name>
+            <value>25.0</value>
+          </FloatProperty>
+        </ThroughputController>
+        <hashTree>
+          <HTTPSamplerProxy testclass="HTTPSamplerProxy" testname="checkout" enabled="true">
+            <boolProp name="HTTPSampler.postBodyRaw">true</boolProp>
+            <elementProp name="HTTPsampler.Arguments" elementType="Arguments">
+              <collectionProp name="Arguments.arguments">
+                <elementProp name="" elementType="HTTPArgument">
+                  <stringProp name="Argument.value">{"cart": "${cart_id}"}</stringProp>
+                </elementProp>
+              </collectionProp>
+            </elementProp>
+            <stringProp name="HTTPSampler.path">/api/checkout</stringProp>
+            <stringProp name="HTTPSampler.method">POST</stringProp>
+          </HTTPSamplerProxy>
+          <hashTree/>
+        </hashTree>
+        <IfController testclass="IfController" testname="maybe" enabled="true"/>
+        <hashTree/>
+        <ResultCollector testclass="ResultCollector" testname="results" enabled="true"/>
+        <hashTree/>
+      </hashTree>
+    </hashTree>
+  </hashTree>
+</jmeterTestPlan>`
+
+func TestFromJMX(t *testing.T) {
+	plan, err := FromJMX(shopPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Name != "shop" || plan.Duration != 120 || plan.WaitMin != 0.5 || plan.WaitMax != 2 {
+		t.Fatalf("unexpected plan %+v", plan)
+	}
+	if len(plan.Requests) != 2 {
+		t.Fatalf("expected 2 requests, got %+v", plan.Requests)
+	}
+	list, checkout := plan.Requests[0], plan.Requests[1]
+	if list.Label != "list items" || list.Path != "/api/items?q=red+shoes" || list.Weight != 4 {
+		t.Fatalf("unexpected list request %+v", list)
+	}
+	if len(list.Headers) != 1 || list.Headers[0].Value != "Bearer {{token}}" {
+		t.Fatalf("expected inherited auth header, got %+v", list.Headers)
+	}
+	if checkout.Weight != 1 || checkout.Body != `{"cart": "${cart_id}"}` {
+		t.Fatalf("unexpected checkout request %+v", checkout)
+	}
+	if len(plan.Variables) != 1 || plan.Variables[0].Name != "token" {
+		t.Fatalf("expected token variable, got %+v", plan.Variables)
+	}
+
+	var reported []string
+	for _, u := range plan.Untranslated {
+		reported = append(reported, u.Element)
+	}
+	want := []string{`ResponseAssertion "is 200"`, `HTTPSamplerProxy "checkout"`, `IfController "maybe"`}
+	if strings.Join(reported, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected %v reported, got %+v", want, plan.Untranslated)
+	}
+}
+
+const shopLocust = `"""Shop load test."""
+import os
+
+from locust import HttpUser, between, tag, task
+
+TOKEN = os.getenv("SHOP_TOKEN", "dev")
+PREFIX = "/api"
+
+
+class ShopUser(HttpUser):
+    wait_time = between(0.5, 2)
+    host = "https://shop.example.com"
+
+    def on_start(self):
+        self.client.post(PREFIX + "/login")
+
+    @tag("browse")
+    @task(3)
+    def list_items(self):
+        self.client.get(
+            f"{PREFIX}/items",  # trailing comment
+            params={"q": "red shoes"},
+            headers={"Authorization": f"Bearer {TOKEN}"},
+            name="list items",
+        )
+
+    @task
+    def checkout(self):
+        with self.client.post("/api/checkout", json={"cart": 1, "gift": True, "note": None}, catch_response=True) as resp:
+            if resp.status_code != 200:
+                resp.failure("checkout failed")
+        print("done")
+
+
+class Other(HttpUser):
+    pass
+`
+
+func TestFromLocust(t *testing.T) {
+	plan, err := FromLocust(shopLocust)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Name != "ShopUser" || plan.BaseURL != "https://shop.example.com" || plan.WaitMin != 0.5 || plan.WaitMax != 2 {
+		t.Fatalf("unexpected plan %+v", plan)
+	}
+	if len(plan.Requests) != 2 {
+		t.Fatalf("expected 2 requests, got %+v", plan.Requests)
+	}
+	list, checkout := plan.Requests[0], plan.Requests[1]
+	if list.Label != "list items" || list.Path != "/api/items?q=red+shoes" || list.Weight != 3 {
+		t.Fatalf("unexpected list request %+v", list)
+	}
+	if len(list.Headers) != 1 || list.Headers[0].Value != "Bearer {{SHOP_TOKEN}}" {
+		t.Fatalf("expected token placeholder, got %+v", list.Headers)
+	}
+	if checkout.Label != "POST /api/checkout" || checkout.Weight != 1 || checkout.Body != `{"cart": 1, "gift": true, "note": null}` {
+		t.Fatalf("unexpected checkout request %+v", checkout)
+	}
+	if len(plan.Variables) != 1 || plan.Variables[0].Name != "SHOP_TOKEN" || plan.Variables[0].Default != "dev" {
+		t.Fatalf("expected SHOP_TOKEN variable, got %+v", plan.Variables)
+	}
+
+	var lines []int
+	for _, u := range plan.Untranslated {
+		lines = append(lines, u.Line)
+	}
+	if fmt.Sprint(lines) != "[14 30 32 35]" {
+		t.Fatalf("expected on_start, response check, print and second class reported, got %+v", plan.Untranslated)
+	}
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	plan, err := FromLocust(shopLocust)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan.Untranslated = nil
+	content, params, err := JMeter(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := scriptcheck.Result(jmeter.Check(content, params)); !result.Valid || len(result.Issues) != 0 {
+		t.Fatalf("converted plan should validate cleanly, got %+v", result.Issues)
+	}
+
+	back, err := FromJMX(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(back.Untranslated) != 0 {
+		t.Fatalf("generated plan should convert fully, got %+v", back.Untranslated)
+	}
+	if back.WaitMin != plan.WaitMin || back.WaitMax != plan.WaitMax || !reflect.DeepEqual(back.Variables, plan.Variables) {
+		t.Fatalf("wait or variables changed: %+v", back)
+	}
+	for i := range plan.Requests {
+		if !reflect.DeepEqual(back.Requests[i], plan.Requests[i]) {
+			t.Fatalf("request %d changed:\n%+v\n%+v", i, plan.Requests[i], back.Requests[i])
+		}
+	}
+
+	script, err := Locust(back)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(script, `SHOP_TOKEN = os.getenv("SHOP_TOKEN", "dev")`) || !strings.Contains(script, "wait_time = between(0.5, 2)") {
+		t.Fatalf("unexpected script:\n%s", script)
+	}
+}
@@ -0,0 +1,168 @@
+// Package scriptparams validates script parameter declarations, resolves task
+// values against them and renders the result for the engines.
+package scriptparams
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"bench-hub/internal/model"
+)
+
+var (
+	ErrInvalidParameter = errors.New("invalid script parameter")
+	ErrInvalidValue     = errors.New("invalid parameter value")
+)
+
+const maxParameters = 64
+
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reserved are the JMeter properties the platform sets itself.
+var reserved = map[string]bool{
+	"target_host":      true,
+	"target_port":      true,
+	"target_protocol":  true,
+	"duration":         true,
+	"tpm":              true,
+	"threads":          true,
+	"rampup":           true,
+	"threads_schedule": true,
+}
+
+// Reserved reports whether the platform sets the property itself.
+func Reserved(name string) bool {
+	return reserved[name]
+}
+
+// Normalize checks parameter declarations and canonicalizes their types and
+// defaults.
+func Normalize(params []model.ScriptParameter) ([]model.ScriptParameter, error) {
+	if len(params) > maxParameters {
+		return nil, fmt.Errorf("%w: at most %d parameters", ErrInvalidParameter, maxParameters)
+	}
+	out := make([]model.ScriptParameter, 0, len(params))
+	seen := map[string]bool{}
+	for _, param := range params {
+		param.Name = strings.TrimSpace(param.Name)
+		if !namePattern.MatchString(param.Name) || reserved[param.Name] {
+			return nil, fmt.Errorf("%w: bad name %q", ErrInvalidParameter, param.Name)
+		}
+		if seen[param.Name] {
+			return nil, fmt.Errorf("%w: duplicate name %q", ErrInvalidParameter, param.Name)
+		}
+		seen[param.Name] = true
+
+		param.Type = strings.ToLower(strings.TrimSpace(param.Type))
+		if param.Type == "" {
+			param.Type = model.ParamTypeString
+		}
+		if param.Default != "" {
+			value, err := coerce(param.Type, param.Default)
+			if err != nil {
+				return nil, fmt.Errorf("%w: default of %s: %v", ErrInvalidParameter, param.Name, err)
+			}
+			param.Default = value
+		} else if _, err := coerce(param.Type, "0"); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidParameter, param.Name, err)
+		}
+		out = append(out, param)
+	}
+	return out, nil
+}
+
+// Resolve type-checks task values against the declarations and fills in
+// defaults. Values for undeclared names and missing required values are
+// rejected.
+func Resolve(params []model.ScriptParameter, values map[string]string) (map[string]string, error) {
+	declared := make(map[string]model.ScriptParameter, len(params))
+	for _, param := range params {
+		declared[param.Name] = param
+	}
+	for name := range values {
+		if _, ok := declared[name]; !ok {
+			return nil, fmt.Errorf("%w: unknown parameter %q", ErrInvalidValue, name)
+		}
+	}
+
+	resolved := make(map[string]string, len(params))
+	for _, param := range params {
+		value, ok := values[param.Name]
+		if !ok || value == "" {
+			if param.Required && param.Default == "" {
+				return nil, fmt.Errorf("%w: %s is required", ErrInvalidValue, param.Name)
+			}
+			if param.Default != "" {
+				resolved[param.Name] = param.Default
+			}
+			continue
+		}
+		value, err := coerce(param.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidValue, param.Name, err)
+		}
+		resolved[param.Name] = value
+	}
+	return resolved, nil
+}
+
+func coerce(kind, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch kind {
+	case model.ParamTypeString:
+		return value, nil
+	case model.ParamTypeInt:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("%q is not an integer", value)
+		}
+		return strconv.FormatInt(n, 10), nil
+	case model.ParamTypeFloat:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a number", value)
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	case model.ParamTypeBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a boolean", value)
+		}
+		return strconv.FormatBool(b), nil
+	default:
+		return "", fmt.Errorf("unknown type %q", kind)
+	}
+}
+
+// Env renders values as NAME=value environment entries in name order.
+func Env(values map[string]string) []string {
+	names := sortedNames(values)
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		out = append(out, name+"="+values[name])
+	}
+	return out
+}
+
+// JMeterArgs renders values as -Jname=value flags in name order.
+func JMeterArgs(values map[string]string) []string {
+	names := sortedNames(values)
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		out = append(out, "-J"+name+"="+values[name])
+	}
+	return out
+}
+
+func sortedNames(values map[string]string) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
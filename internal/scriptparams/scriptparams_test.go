@@ -0,0 +1,73 @@
+package scriptparams
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"bench-hub/internal/model"
+)
+
+func TestNormalize(t *testing.T) {
+	params, err := Normalize([]model.ScriptParameter{
+		{Name: " LOCUST_USER "},
+		{Name: "think_ms", Type: "INT", Default: " 250 "},
+		{Name: "verbose", Type: "bool", Default: "1"},
+	})
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if params[0].Name != "LOCUST_USER" || params[0].Type != model.ParamTypeString {
+		t.Fatalf("unexpected first parameter: %+v", params[0])
+	}
+	if params[1].Default != "250" || params[2].Default != "true" {
+		t.Fatalf("defaults not canonical: %+v", params)
+	}
+
+	invalid := [][]model.ScriptParameter{
+		{{Name: "1abc"}},
+		{{Name: "a-b"}},
+		{{Name: "duration"}},
+		{{Name: "x"}, {Name: "x"}},
+		{{Name: "x", Type: "date"}},
+		{{Name: "x", Type: "int", Default: "ten"}},
+	}
+	for _, params := range invalid {
+		if _, err := Normalize(params); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("%+v: expected ErrInvalidParameter, got %v", params, err)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	params := []model.ScriptParameter{
+		{Name: "LOCUST_USER", Type: model.ParamTypeString, Required: true},
+		{Name: "think_ms", Type: model.ParamTypeInt, Default: "250"},
+		{Name: "ratio", Type: model.ParamTypeFloat},
+	}
+
+	got, err := Resolve(params, map[string]string{"LOCUST_USER": "bench", "ratio": "0.50"})
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	want := map[string]string{"LOCUST_USER": "bench", "think_ms": "250", "ratio": "0.5"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if env := Env(got); !reflect.DeepEqual(env, []string{"LOCUST_USER=bench", "ratio=0.5", "think_ms=250"}) {
+		t.Fatalf("env: %v", env)
+	}
+	if args := JMeterArgs(map[string]string{"b": "2", "a": "1"}); !reflect.DeepEqual(args, []string{"-Ja=1", "-Jb=2"}) {
+		t.Fatalf("jmeter args: %v", args)
+	}
+
+	for _, values := range []map[string]string{
+		{},
+		{"LOCUST_USER": "x", "other": "1"},
+		{"LOCUST_USER": "x", "think_ms": "fast"},
+	} {
+		if _, err := Resolve(params, values); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("%v: expected ErrInvalidValue, got %v", values, err)
+		}
+	}
+}
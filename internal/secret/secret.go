@@ -0,0 +1,208 @@
+// Package secret encrypts stored secret values and scrubs them from engine
+// output.
+package secret
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+var ErrCiphertext = errors.New("malformed or tampered ciphertext")
+
+// Box seals values with AES-256-GCM under a key derived from the server's
+// secrets key.
+type Box struct {
+	aead cipher.AEAD
+}
+
+func NewBox(key string) (*Box, error) {
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Seal returns nonce || ciphertext. The additional data binds the value to
+// its owner, e.g. the secret name, so ciphertexts cannot be swapped.
+func (b *Box) Seal(plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return b.aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func (b *Box) Open(sealed, additional []byte) ([]byte, error) {
+	size := b.aead.NonceSize()
+	if len(sealed) < size {
+		return nil, ErrCiphertext
+	}
+	plaintext, err := b.aead.Open(nil, sealed[:size], sealed[size:], additional)
+	if err != nil {
+		return nil, ErrCiphertext
+	}
+	return plaintext, nil
+}
+
+// Mask replaces secret values in redacted output.
+const Mask = "******"
+
+// minRedactLen skips values so short that masking them would mangle
+// unrelated output.
+const minRedactLen = 4
+
+// Redactor masks a fixed set of secret values.
+type Redactor struct {
+	values  [][]byte
+	longest int
+}
+
+func NewRedactor(values []string) *Redactor {
+	r := &Redactor{}
+	for _, value := range values {
+		if len(value) >= minRedactLen {
+			r.values = append(r.values, []byte(value))
+			r.longest = max(r.longest, len(value))
+		}
+	}
+	// Longer values first so one that contains another is masked whole.
+	sort.Slice(r.values, func(i, j int) bool { return len(r.values[i]) > len(r.values[j]) })
+	return r
+}
+
+func (r *Redactor) Empty() bool {
+	return r == nil || len(r.values) == 0
+}
+
+func (r *Redactor) Bytes(data []byte) []byte {
+	data, _ = r.mask(data)
+	return data
+}
+
+// mask replaces the values in data and reports whether there were any.
+func (r *Redactor) mask(data []byte) ([]byte, bool) {
+	if r.Empty() {
+		return data, false
+	}
+	masked := false
+	for _, value := range r.values {
+		if bytes.Contains(data, value) {
+			data = bytes.ReplaceAll(data, value, []byte(Mask))
+			masked = true
+		}
+	}
+	return data, masked
+}
+
+// Writer masks values in everything written through it. Output is held back
+// only as far as needed to catch a value split across writes; Close flushes
+// the rest and closes w.
+func (r *Redactor) Writer(w io.WriteCloser) io.WriteCloser {
+	if r.Empty() {
+		return w
+	}
+	return &redactWriter{r: r, w: w}
+}
+
+type redactWriter struct {
+	r      *Redactor
+	w      io.WriteCloser
+	buf    []byte
+	masked bool
+}
+
+func (rw *redactWriter) Write(p []byte) (int, error) {
+	rw.buf = rw.maskBuf(append(rw.buf, p...))
+	// Keep a tail that could be the start of a value.
+	keep := min(len(rw.buf), rw.r.longest-1)
+	if cut := len(rw.buf) - keep; cut > 0 {
+		if _, err := rw.w.Write(rw.buf[:cut]); err != nil {
+			return 0, err
+		}
+		rw.buf = append(rw.buf[:0], rw.buf[cut:]...)
+	}
+	return len(p), nil
+}
+
+func (rw *redactWriter) maskBuf(data []byte) []byte {
+	data, masked := rw.r.mask(data)
+	rw.masked = rw.masked || masked
+	return data
+}
+
+func (rw *redactWriter) Close() error {
+	if len(rw.buf) > 0 {
+		if _, err := rw.w.Write(rw.maskBuf(rw.buf)); err != nil {
+			rw.w.Close()
+			return err
+		}
+	}
+	return rw.w.Close()
+}
+
+// Files masks values in the given files, e.g. the CSV, HTML and JTL reports
+// an engine wrote. Each file is streamed through a temporary file next to
+// it, which replaces it only when something was masked. Missing files are
+// skipped.
+func (r *Redactor) Files(paths ...string) error {
+	if r.Empty() {
+		return nil
+	}
+	for _, path := range paths {
+		if err := r.file(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Redactor) file(path string) error {
+	in, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.CreateTemp(filepath.Dir(path), ".redact-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+
+	rw := &redactWriter{r: r, w: out}
+	if _, err := io.Copy(rw, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := rw.Close(); err != nil {
+		return err
+	}
+	if !rw.masked {
+		return nil
+	}
+	if err := os.Chmod(out.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(out.Name(), path)
+}
@@ -0,0 +1,89 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type nopCloser struct{ *bytes.Buffer }
+
+func (nopCloser) Close() error { return nil }
+
+func TestBoxRoundTrip(t *testing.T) {
+	box, err := NewBox("server-key")
+	if err != nil {
+		t.Fatalf("new box: %v", err)
+	}
+	sealed, err := box.Seal([]byte("hunter2"), []byte("DB_PASS"))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("hunter2")) {
+		t.Fatalf("ciphertext contains plaintext")
+	}
+	plain, err := box.Open(sealed, []byte("DB_PASS"))
+	if err != nil || string(plain) != "hunter2" {
+		t.Fatalf("open: %q, %v", plain, err)
+	}
+
+	if _, err := box.Open(sealed, []byte("OTHER")); !errors.Is(err, ErrCiphertext) {
+		t.Fatalf("expected additional data mismatch to fail, got %v", err)
+	}
+	other, _ := NewBox("another-key")
+	if _, err := other.Open(sealed, []byte("DB_PASS")); !errors.Is(err, ErrCiphertext) {
+		t.Fatalf("expected wrong key to fail, got %v", err)
+	}
+}
+
+func TestRedactorWriterAcrossWrites(t *testing.T) {
+	r := NewRedactor([]string{"s3cr3t-token", "abc"})
+	var out bytes.Buffer
+	w := r.Writer(nopCloser{&out})
+	for _, chunk := range []string{"auth=s3c", "r3t-tok", "en ok\nabc s3cr3t-token"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if got, want := out.String(), "auth=****** ok\nabc ******"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestRedactorFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report_stats.csv")
+	// The value straddles io.Copy's 32KB chunks.
+	content := strings.Repeat("x", 32*1024-4) + "GET,/login?key=k3y-value,10\n"
+	if err := os.WriteFile(path, []byte(content), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	plain := filepath.Join(dir, "plain.csv")
+	if err := os.WriteFile(plain, []byte("nothing to mask"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.Stat(plain)
+
+	if err := NewRedactor([]string{"k3y-value"}).Files(path, plain, filepath.Join(dir, "missing.csv")); err != nil {
+		t.Fatalf("redact files: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if want := strings.Replace(content, "k3y-value", Mask, 1); string(data) != want {
+		t.Fatalf("unexpected content %q", data[len(data)-40:])
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o640 {
+		t.Fatalf("mode = %v, want 0640", info.Mode().Perm())
+	}
+	if after, _ := os.Stat(plain); !os.SameFile(before, after) {
+		t.Fatal("expected a file without values to be left in place")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Fatalf("expected no temporary files left, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/results"
+)
+
+const (
+	DefaultRegressionTolerancePct = 10.0
+
+	PresenceBoth    = "both"
+	PresenceAdded   = "added"
+	PresenceRemoved = "removed"
+)
+
+const (
+	directionLowerIsBetter  = -1
+	directionHigherIsBetter = 1
+)
+
+type CompareService struct {
+	metrics *MetricsService
+	runs    *RunService
+}
+
+func NewCompareService(metrics *MetricsService, runs *RunService) *CompareService {
+	return &CompareService{metrics: metrics, runs: runs}
+}
+
+func (s *CompareService) Compare(ctx context.Context, baseRunID, candidateRunID string, tolerancePct float64) (*model.RunComparison, error) {
+	baseRun, err := s.runs.Get(ctx, baseRunID)
+	if err != nil {
+		return nil, err
+	}
+	candidateRun, err := s.runs.Get(ctx, candidateRunID)
+	if err != nil {
+		return nil, err
+	}
+	base, err := s.metrics.ForRun(ctx, baseRun.ID)
+	if err != nil {
+		return nil, err
+	}
+	candidate, err := s.metrics.ForRun(ctx, candidateRun.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Locust reports the HTTP verb while JMeter only has labels, so mixed
+	// engine comparisons align on the endpoint name alone.
+	matchMethod := baseRun.Parameters.ScriptType == candidateRun.Parameters.ScriptType
+	comparison := CompareMetrics(base.Endpoints, candidate.Endpoints, tolerancePct, matchMethod)
+	comparison.BaseRunID = baseRun.ID
+	comparison.CandidateRunID = candidateRun.ID
+	return comparison, nil
+}
+
+func CompareMetrics(base, candidate []model.EndpointMetric, tolerancePct float64, matchMethod bool) *model.RunComparison {
+	key := func(metric model.EndpointMetric) string {
+		if matchMethod && metric.Name != results.AggregatedName {
+			return metric.Method + " " + metric.Name
+		}
+		return metric.Name
+	}
+
+	type pair struct {
+		base      *model.EndpointMetric
+		candidate *model.EndpointMetric
+	}
+	pairs := map[string]*pair{}
+	for i := range base {
+		pairs[key(base[i])] = &pair{base: &base[i]}
+	}
+	for i := range candidate {
+		k := key(candidate[i])
+		if p, ok := pairs[k]; ok {
+			p.candidate = &candidate[i]
+		} else {
+			pairs[k] = &pair{candidate: &candidate[i]}
+		}
+	}
+
+	keys := make([]string, 0, len(pairs))
+	for k := range pairs {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		// Keep the aggregated row last, like the engine reports do.
+		if (keys[i] == results.AggregatedName) != (keys[j] == results.AggregatedName) {
+			return keys[j] == results.AggregatedName
+		}
+		return keys[i] < keys[j]
+	})
+
+	comparison := &model.RunComparison{TolerancePct: tolerancePct}
+	for _, k := range keys {
+		p := pairs[k]
+		switch {
+		case p.base == nil:
+			comparison.Endpoints = append(comparison.Endpoints, model.EndpointComparison{
+				Method:   p.candidate.Method,
+				Name:     p.candidate.Name,
+				Presence: PresenceAdded,
+			})
+		case p.candidate == nil:
+			comparison.Endpoints = append(comparison.Endpoints, model.EndpointComparison{
+				Method:   p.base.Method,
+				Name:     p.base.Name,
+				Presence: PresenceRemoved,
+			})
+		default:
+			entry := compareEndpoint(*p.base, *p.candidate, tolerancePct)
+			if entry.Regression {
+				comparison.Regressions++
+			}
+			comparison.Endpoints = append(comparison.Endpoints, entry)
+		}
+	}
+	return comparison
+}
+
+func compareEndpoint(base, candidate model.EndpointMetric, tolerancePct float64) model.EndpointComparison {
+	entry := model.EndpointComparison{
+		Method:    candidate.Method,
+		Name:      candidate.Name,
+		Presence:  PresenceBoth,
+		P50Ms:     metricDelta(base.P50Ms, candidate.P50Ms, directionLowerIsBetter, tolerancePct),
+		P90Ms:     metricDelta(base.P90Ms, candidate.P90Ms, directionLowerIsBetter, tolerancePct),
+		P95Ms:     metricDelta(base.P95Ms, candidate.P95Ms, directionLowerIsBetter, tolerancePct),
+		P99Ms:     metricDelta(base.P99Ms, candidate.P99Ms, directionLowerIsBetter, tolerancePct),
+		AvgMs:     metricDelta(base.AvgMs, candidate.AvgMs, directionLowerIsBetter, tolerancePct),
+		RPS:       metricDelta(base.RPS, candidate.RPS, directionHigherIsBetter, tolerancePct),
+		Failures:  metricDelta(float64(base.FailureCount), float64(candidate.FailureCount), directionLowerIsBetter, tolerancePct),
+		ErrorRate: metricDelta(errorRate(base), errorRate(candidate), directionLowerIsBetter, tolerancePct),
+	}
+	for _, delta := range []*model.MetricDelta{entry.P50Ms, entry.P90Ms, entry.P95Ms, entry.P99Ms, entry.AvgMs, entry.RPS, entry.Failures, entry.ErrorRate} {
+		if delta.Regression {
+			entry.Regression = true
+		}
+	}
+	return entry
+}
+
+// metricDelta flags a regression when the value moved in the wrong direction
+// by more than tolerancePct. A metric that was zero and became worse is a
+// regression regardless of tolerance.
+func metricDelta(base, candidate float64, direction int, tolerancePct float64) *model.MetricDelta {
+	delta := &model.MetricDelta{
+		Base:      base,
+		Candidate: candidate,
+		Delta:     candidate - base,
+	}
+	worse := delta.Delta*float64(direction) < 0
+	if base != 0 {
+		pct := delta.Delta / base * 100
+		delta.DeltaPct = &pct
+		if pct < 0 {
+			pct = -pct
+		}
+		delta.Regression = worse && pct > tolerancePct
+	} else {
+		delta.Regression = worse
+	}
+	return delta
+}
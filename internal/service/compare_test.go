@@ -0,0 +1,49 @@
+package service
+
+import (
+	"testing"
+
+	"bench-hub/internal/model"
+)
+
+func TestCompareMetrics(t *testing.T) {
+	base := []model.EndpointMetric{
+		{Method: "GET", Name: "/items", RequestCount: 100, P95Ms: 200, RPS: 50},
+		{Method: "GET", Name: "/legacy", RequestCount: 10, P95Ms: 10, RPS: 1},
+		{Name: "Aggregated", RequestCount: 110, P95Ms: 190, RPS: 51},
+	}
+	candidate := []model.EndpointMetric{
+		{Method: "GET", Name: "/items", RequestCount: 100, FailureCount: 2, P95Ms: 260, RPS: 49},
+		{Method: "POST", Name: "/orders", RequestCount: 10, P95Ms: 40, RPS: 1},
+		{Name: "Aggregated", RequestCount: 110, FailureCount: 2, P95Ms: 195, RPS: 50},
+	}
+
+	comparison := CompareMetrics(base, candidate, 10, true)
+	if len(comparison.Endpoints) != 4 {
+		t.Fatalf("expected 4 endpoints, got %d", len(comparison.Endpoints))
+	}
+
+	items := comparison.Endpoints[0]
+	if items.Name != "/items" || items.Presence != PresenceBoth {
+		t.Fatalf("unexpected first endpoint %+v", items)
+	}
+	if !items.P95Ms.Regression || items.P95Ms.DeltaPct == nil || *items.P95Ms.DeltaPct != 30 {
+		t.Fatalf("expected p95 regression of 30%%, got %+v", items.P95Ms)
+	}
+	if items.RPS.Regression {
+		t.Fatalf("expected rps drop within tolerance, got %+v", items.RPS)
+	}
+	if !items.Failures.Regression {
+		t.Fatalf("expected new failures to be a regression")
+	}
+
+	if comparison.Endpoints[1].Presence != PresenceRemoved || comparison.Endpoints[2].Presence != PresenceAdded {
+		t.Fatalf("unexpected presence %+v", comparison.Endpoints)
+	}
+	if last := comparison.Endpoints[3]; last.Name != "Aggregated" || last.P95Ms.Regression {
+		t.Fatalf("unexpected aggregated comparison %+v", last)
+	}
+	if comparison.Regressions != 2 {
+		t.Fatalf("expected 2 regressed endpoints, got %d", comparison.Regressions)
+	}
+}
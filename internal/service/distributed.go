@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"bench-hub/internal/model"
+)
+
+// Roles of the runner jobs that make up a distributed Locust run.
+const (
+	runnerRoleMaster = "master"
+	runnerRoleWorker = "worker"
+)
+
+// pickWorkers chooses a runner for each requested Locust worker. A runner
+// may host several workers of the same run.
+func (r *TaskRunner) pickWorkers(ctx context.Context, task *model.Task, count int) ([]model.RunWorker, error) {
+	workers := make([]model.RunWorker, 0, count)
+	for i := 0; i < count; i++ {
+		node, err := r.pickNode(ctx, task)
+		if err == nil && !isRemoteNode(node) {
+			err = ErrNoRunnerAvailable
+		}
+		if err != nil {
+			r.releaseWorkerNodes(ctx, workers)
+			return nil, err
+		}
+		workers = append(workers, model.RunWorker{Node: node})
+	}
+	return workers, nil
+}
+
+func (r *TaskRunner) releaseWorkerNodes(ctx context.Context, workers []model.RunWorker) {
+	for _, worker := range workers {
+		r.releaseNodes(ctx, worker.Node)
+	}
+}
+
+// submitWorkers starts the worker jobs once the master is listening and
+// records their job ids on the run.
+func (r *TaskRunner) submitWorkers(ctx context.Context, master runnerRequest, run *model.TaskRun, masterPort int) error {
+	if masterPort == 0 {
+		return fmt.Errorf("runner did not report a master port")
+	}
+	masterURL, err := url.Parse(r.runnerBase(run))
+	if err != nil {
+		return err
+	}
+
+	for i := range run.Workers {
+		worker := master
+		worker.Role = runnerRoleWorker
+		worker.ExpectWorkers = 0
+		worker.ReportDir = workerReportDir(run.ReportDir, i)
+		worker.MasterHost = masterURL.Hostname()
+		worker.MasterPort = masterPort
+
+		job, err := r.postJob(run.Workers[i].Node, worker)
+		if err != nil {
+			return fmt.Errorf("worker %d: %w", i+1, err)
+		}
+		run.Workers[i].JobID = job.ID
+		if err := r.runs.Update(ctx, run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// workerStopWait bounds how long releaseWorkers waits for stopped workers to
+// report their files.
+const workerStopWait = 15 * time.Second
+
+// releaseWorkers stops workers that outlived their master and returns the
+// reports their runners returned, i.e. the logs the workers wrote on those
+// runners. Workers that do not finish within workerStopWait contribute none.
+func (r *TaskRunner) releaseWorkers(task *model.Task, run *model.TaskRun) []RunnerReport {
+	for _, worker := range run.Workers {
+		if worker.JobID == "" {
+			continue
+		}
+		if job, err := r.fetchJob(worker.Node, worker.JobID); err == nil {
+			if _, _, done := job.outcome(); done {
+				continue
+			}
+		}
+		if _, err := r.stopRemote(worker.Node, task.ID, worker.JobID); err != nil {
+			log.Printf("stop worker %s of run %s: %v", worker.JobID, run.ID, err)
+		}
+	}
+
+	deadline := time.Now().Add(workerStopWait)
+	var reports []RunnerReport
+	for i, worker := range run.Workers {
+		if worker.JobID == "" {
+			continue
+		}
+		job, err := r.awaitJob(worker.Node, worker.JobID, deadline)
+		if err != nil {
+			log.Printf("collect reports of worker %s of run %s: %v", worker.JobID, run.ID, err)
+			continue
+		}
+		for _, report := range job.Reports {
+			reports = append(reports, RunnerReport{
+				Name:     fmt.Sprintf("%s-worker%d-%s", task.Name, i+1, filepath.Base(report.FilePath)),
+				Type:     report.Type,
+				FilePath: report.FilePath,
+			})
+		}
+	}
+	return reports
+}
+
+// awaitJob polls a runner job until it finished or the deadline passed.
+func (r *TaskRunner) awaitJob(baseURL, jobID string, deadline time.Time) (*RunnerJob, error) {
+	for {
+		job, err := r.fetchJob(baseURL, jobID)
+		if err != nil {
+			return nil, err
+		}
+		if _, _, done := job.outcome(); done {
+			return job, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New("job still running")
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func workerReportDir(reportDir string, index int) string {
+	return fmt.Sprintf("%s_worker%d", reportDir, index+1)
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bench-hub/internal/model"
+)
+
+func TestReleaseWorkersRegistersReturnedReports(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get(runnerTokenHeader) != "runner-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if req.URL.Path != "/jobs/w1" {
+			http.NotFound(w, req)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(RunnerJob{
+			ID:      "w1",
+			Status:  TaskStatusFinished,
+			Reports: []RunnerReport{{Name: "smoke-run.log", Type: "log", FilePath: "task_1_worker1/run.log"}},
+		})
+	}))
+	defer server.Close()
+
+	runner := &TaskRunner{client: server.Client(), runnerToken: "runner-token"}
+	run := &model.TaskRun{ID: "run-1", ReportDir: "task_1", Workers: []model.RunWorker{
+		{Node: server.URL, JobID: "w1"},
+		// The runner no longer knows this worker, so it has no reports.
+		{Node: server.URL, JobID: "w2"},
+		{Node: server.URL},
+	}}
+
+	reports := runner.releaseWorkers(&model.Task{ID: "task-1", Name: "smoke"}, run)
+	if len(reports) != 1 {
+		t.Fatalf("expected only the returned report, got %+v", reports)
+	}
+	want := RunnerReport{Name: "smoke-worker1-run.log", Type: "log", FilePath: "task_1_worker1/run.log"}
+	if reports[0] != want {
+		t.Fatalf("report = %+v, want %+v", reports[0], want)
+	}
+}
@@ -9,4 +9,23 @@ var (
 	ErrStopped            = errors.New("stopped")
 	ErrInvalidScriptType  = errors.New("invalid script type")
 	ErrUnsupportedEngine  = errors.New("unsupported engine")
+	ErrInvalidSLARule     = errors.New("invalid sla rule")
+	ErrRunNotActive       = errors.New("run not active")
+	ErrRunNotQueued       = errors.New("run not queued")
+	ErrInvalidRunner      = errors.New("invalid runner")
+	ErrNoRunners          = errors.New("no healthy runners registered")
+	ErrNoRunnerAvailable  = errors.New("no runner available")
+	ErrInvalidSchedule    = errors.New("invalid schedule")
+	ErrInvalidLoadProfile = errors.New("invalid load profile")
+
+	ErrInvalidScriptParameter = errors.New("invalid script parameter")
+	ErrInvalidVariables       = errors.New("invalid task variables")
+	ErrScriptNotFound         = errors.New("script not found")
+	ErrInvalidSecret          = errors.New("invalid secret")
+	ErrSecretExists           = errors.New("secret already exists")
+	ErrScriptVersionNotFound  = errors.New("script version not found")
+	ErrInvalidBundle          = errors.New("invalid script bundle")
+	ErrInvalidScript          = errors.New("invalid script")
+	ErrInvalidSource          = errors.New("unsupported script source")
+	ErrInvalidConversion      = errors.New("script cannot be converted")
 )
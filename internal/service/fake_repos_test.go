@@ -107,3 +107,91 @@ func (r *fakeUserRepo) Count(ctx context.Context) (int, error) {
 
 	return len(r.users), nil
 }
+
+type fakeRunnerRepo struct {
+	mu      sync.Mutex
+	runners []model.Runner
+}
+
+func (r *fakeRunnerRepo) Register(ctx context.Context, runner *model.Runner) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runner.LastHeartbeatAt = time.Now()
+	r.runners = append(r.runners, *runner)
+	return nil
+}
+
+func (r *fakeRunnerRepo) Heartbeat(ctx context.Context, id string, activeJobs int, cpuPercent float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.runners {
+		if r.runners[i].ID == id {
+			r.runners[i].ActiveJobs = activeJobs
+			r.runners[i].CPUPercent = cpuPercent
+			r.runners[i].LastHeartbeatAt = time.Now()
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *fakeRunnerRepo) IncrementActive(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.runners {
+		if r.runners[i].ID == id {
+			r.runners[i].ActiveJobs++
+		}
+	}
+	return nil
+}
+
+func (r *fakeRunnerRepo) ReleaseActive(ctx context.Context, url string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.runners {
+		if r.runners[i].URL == url && r.runners[i].ActiveJobs > 0 {
+			r.runners[i].ActiveJobs--
+		}
+	}
+	return nil
+}
+
+func (r *fakeRunnerRepo) List(ctx context.Context) ([]model.Runner, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runners := append([]model.Runner(nil), r.runners...)
+	for i := range runners {
+		runners[i].HeartbeatAge = time.Since(runners[i].LastHeartbeatAt)
+	}
+	return runners, nil
+}
+
+type fakeInstanceRepo struct {
+	mu       sync.Mutex
+	lastSeen map[string]time.Time
+}
+
+func (r *fakeInstanceRepo) Heartbeat(ctx context.Context, node string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastSeen[node] = time.Now()
+	return nil
+}
+
+func (r *fakeInstanceRepo) LastHeartbeat(ctx context.Context, node string) (time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen, ok := r.lastSeen[node]
+	if !ok {
+		return 0, repository.ErrNotFound
+	}
+	return time.Since(seen), nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/repository"
+)
+
+const liveSubscriberBuffer = 16
+
+// LiveService fans out in-progress run snapshots to SSE subscribers. State
+// is kept in memory, so subscribers only see snapshots that reached this
+// API instance.
+type LiveService struct {
+	runs        repository.TaskRunRepository
+	mu          sync.Mutex
+	latest      map[string]model.LiveEvent
+	subscribers map[string]map[chan model.LiveEvent]struct{}
+}
+
+func NewLiveService(runs repository.TaskRunRepository) *LiveService {
+	return &LiveService{
+		runs:        runs,
+		latest:      make(map[string]model.LiveEvent),
+		subscribers: make(map[string]map[chan model.LiveEvent]struct{}),
+	}
+}
+
+// PublishSnapshot records a snapshot reported for a run that is still
+// running.
+func (s *LiveService) PublishSnapshot(ctx context.Context, runID string, sample model.MetricSample) error {
+	run, err := s.runs.GetByID(ctx, runID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return ErrNotFound
+		}
+		return err
+	}
+	if run.Status != TaskStatusRunning {
+		return ErrRunNotActive
+	}
+	s.Publish(model.LiveEvent{
+		Type:     model.LiveEventSnapshot,
+		RunID:    run.ID,
+		TaskID:   run.TaskID,
+		Snapshot: &sample,
+	})
+	return nil
+}
+
+func (s *LiveService) Publish(event model.LiveEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if event.Type == model.LiveEventFinished {
+		delete(s.latest, event.TaskID)
+	} else {
+		s.latest[event.TaskID] = event
+	}
+	for ch := range s.subscribers[event.TaskID] {
+		select {
+		case ch <- event:
+		default:
+			// Slow subscribers miss intermediate snapshots rather than
+			// blocking the publisher.
+		}
+	}
+}
+
+// Subscribe returns the event stream for a task and the latest snapshot of
+// its active run, if any. The returned func must be called to unsubscribe.
+func (s *LiveService) Subscribe(taskID string) (<-chan model.LiveEvent, *model.LiveEvent, func()) {
+	ch := make(chan model.LiveEvent, liveSubscriberBuffer)
+
+	s.mu.Lock()
+	if s.subscribers[taskID] == nil {
+		s.subscribers[taskID] = make(map[chan model.LiveEvent]struct{})
+	}
+	s.subscribers[taskID][ch] = struct{}{}
+	var latest *model.LiveEvent
+	if event, ok := s.latest[taskID]; ok {
+		latest = &event
+	}
+	s.mu.Unlock()
+
+	cancel := func() {
+		s.mu.Lock()
+		delete(s.subscribers[taskID], ch)
+		if len(s.subscribers[taskID]) == 0 {
+			delete(s.subscribers, taskID)
+		}
+		s.mu.Unlock()
+	}
+	return ch, latest, cancel
+}
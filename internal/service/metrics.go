@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+
+	"bench-hub/internal/engine"
+	"bench-hub/internal/model"
+	"bench-hub/internal/repository"
+)
+
+type MetricsService struct {
+	repo       repository.MetricRepository
+	runs       repository.TaskRunRepository
+	engines    *engine.Registry
+	reportsDir string
+}
+
+func NewMetricsService(repo repository.MetricRepository, runs repository.TaskRunRepository, engines *engine.Registry, reportsDir string) *MetricsService {
+	return &MetricsService{repo: repo, runs: runs, engines: engines, reportsDir: reportsDir}
+}
+
+// Ingest parses the result files left in the run's report directory with
+// the run's engine.
+func (s *MetricsService) Ingest(ctx context.Context, run *model.TaskRun) ([]model.EndpointMetric, error) {
+	eng, err := s.engines.Get(run.Parameters.ScriptType)
+	if err != nil {
+		return nil, err
+	}
+	metrics, samples, err := eng.Metrics(filepath.Join(s.reportsDir, run.ReportDir))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceForRun(ctx, run, metrics, samples); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+func (s *MetricsService) ForTask(ctx context.Context, taskID string) (*model.RunMetrics, error) {
+	runID, err := s.repo.LatestRunID(ctx, taskID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return s.ForRun(ctx, runID)
+}
+
+func (s *MetricsService) ForRun(ctx context.Context, runID string) (*model.RunMetrics, error) {
+	run, err := s.runs.GetByID(ctx, runID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	endpoints, err := s.repo.ListByRun(ctx, run.ID)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := s.repo.ListSamples(ctx, run.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.RunMetrics{
+		RunID:     run.ID,
+		TaskID:    run.TaskID,
+		ReportDir: run.ReportDir,
+		Endpoints: endpoints,
+		Samples:   samples,
+	}, nil
+}
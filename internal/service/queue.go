@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/repository"
+)
+
+// QueueLimits caps how many runs execute at once. Zero means unlimited.
+type QueueLimits struct {
+	MaxConcurrent int
+	MaxPerHost    int
+}
+
+// queueSlots tracks free capacity during one dispatch pass.
+type queueSlots struct {
+	limits QueueLimits
+	active int
+	byHost map[string]int
+}
+
+func newQueueSlots(limits QueueLimits, running []model.TaskRun, fallbackHost string) *queueSlots {
+	slots := &queueSlots{limits: limits, byHost: map[string]int{}}
+	for _, run := range running {
+		slots.take(targetHostKey(run.TargetHost, fallbackHost))
+	}
+	return slots
+}
+
+func (s *queueSlots) full() bool {
+	return s.limits.MaxConcurrent > 0 && s.active >= s.limits.MaxConcurrent
+}
+
+func (s *queueSlots) allow(host string) bool {
+	if s.full() {
+		return false
+	}
+	return s.limits.MaxPerHost <= 0 || s.byHost[host] < s.limits.MaxPerHost
+}
+
+func (s *queueSlots) take(host string) {
+	s.active++
+	s.byHost[host]++
+}
+
+// targetHostKey reduces a target to host[:port] so runs against the same
+// system share a per-host limit regardless of scheme or path.
+func targetHostKey(target, fallback string) string {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		target = strings.TrimSpace(fallback)
+	}
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+	parsed, err := url.Parse(target)
+	if err != nil || parsed.Host == "" {
+		return strings.ToLower(target)
+	}
+	return strings.ToLower(parsed.Host)
+}
+
+// StartDispatcher admits queued runs whenever capacity frees up, checking at
+// least every interval until ctx is done.
+func (r *TaskRunner) StartDispatcher(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := r.Dispatch(ctx); err != nil {
+				log.Printf("dispatch queued runs: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-r.wake:
+			}
+		}
+	}()
+}
+
+// notifyQueue asks the dispatcher for an early pass after a run was queued or
+// finished.
+func (r *TaskRunner) notifyQueue() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Dispatch starts queued runs in arrival order while the global and
+// per-target-host limits allow. Runs that cannot be placed yet, e.g. because
+// no matching runner is free, stay queued without blocking those behind them.
+func (r *TaskRunner) Dispatch(ctx context.Context) error {
+	unlock, ok, err := r.runs.LockQueue(ctx)
+	if err != nil || !ok {
+		return err
+	}
+	defer unlock()
+
+	queued, err := r.runs.ListByStatus(ctx, TaskStatusQueued)
+	if err != nil || len(queued) == 0 {
+		return err
+	}
+	running, err := r.runs.ListByStatus(ctx, TaskStatusRunning)
+	if err != nil {
+		return err
+	}
+
+	slots := newQueueSlots(r.limits, running, r.locustHost)
+	for i := range queued {
+		if slots.full() {
+			break
+		}
+		run := &queued[i]
+		host := targetHostKey(run.TargetHost, r.locustHost)
+		if !slots.allow(host) {
+			continue
+		}
+		started, err := r.start(ctx, run)
+		if err != nil {
+			log.Printf("start queued run %s: %v", run.ID, err)
+			continue
+		}
+		if started {
+			slots.take(host)
+		}
+	}
+	return nil
+}
+
+// start places a queued run and hands it to execute. It returns false when
+// the run has to keep waiting.
+func (r *TaskRunner) start(ctx context.Context, run *model.TaskRun) (bool, error) {
+	task, err := r.tasks.GetByID(ctx, run.TaskID)
+	if err != nil {
+		return false, err
+	}
+	// The run executes the version recorded when it was queued, even if the
+	// script changed since.
+	script, err := r.scriptAt(ctx, run.Parameters.ScriptID, run.Parameters.ScriptVersion)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			r.failQueued(ctx, task, run, "script not found")
+		}
+		return false, err
+	}
+
+	node, err := r.pickNode(ctx, task)
+	if errors.Is(err, ErrNoRunnerAvailable) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var workers []model.RunWorker
+	if run.Parameters.Workers > 0 {
+		if !isRemoteNode(node) {
+			// pickNode only falls back to this process when no runner is
+			// alive, so waiting would keep the run queued for good.
+			r.failQueued(ctx, task, run, RunExitNoRunner)
+			return false, nil
+		}
+		workers, err = r.pickWorkers(ctx, task, run.Parameters.Workers)
+		if err != nil {
+			// Not enough workers: the master's slot goes back too.
+			r.releaseNodes(ctx, node)
+			if errors.Is(err, ErrNoRunnerAvailable) {
+				return false, nil
+			}
+			return false, err
+		}
+	}
+
+	now := time.Now()
+	run.Status = TaskStatusRunning
+	run.RunnerNode = node
+	run.Workers = workers
+	run.StartedAt = &now
+	claimed, err := r.runs.Transition(ctx, run, TaskStatusQueued)
+	if err != nil || !claimed {
+		r.releaseNodes(ctx, node)
+		r.releaseWorkerNodes(ctx, workers)
+		return false, err
+	}
+	run.TaskName = &task.Name
+
+	task.Status = TaskStatusRunning
+	task.StartedAt = &now
+	task.FinishedAt = nil
+	if err := r.tasks.Update(ctx, task); err != nil {
+		log.Printf("mark task %s running: %v", task.ID, err)
+	}
+
+	go r.execute(task, script, run)
+	return true, nil
+}
+
+// failQueued closes a queued run that can never start.
+func (r *TaskRunner) failQueued(ctx context.Context, task *model.Task, run *model.TaskRun, reason string) {
+	now := time.Now()
+	run.Status = TaskStatusFailed
+	run.ExitReason = reason
+	run.FinishedAt = &now
+	if claimed, err := r.runs.Transition(ctx, run, TaskStatusQueued); err != nil || !claimed {
+		return
+	}
+	r.settleQueuedTask(ctx, task, run)
+}
+
+// Cancel removes a run from the queue before it starts.
+func (r *TaskRunner) Cancel(ctx context.Context, runID string) (*model.TaskRun, error) {
+	run, err := r.runs.GetByID(ctx, runID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if run.Status != TaskStatusQueued {
+		return nil, ErrRunNotQueued
+	}
+
+	now := time.Now()
+	run.Status = TaskStatusStopped
+	run.ExitReason = RunExitCancelled
+	run.FinishedAt = &now
+	claimed, err := r.runs.Transition(ctx, run, TaskStatusQueued)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrRunNotQueued
+	}
+
+	if task, err := r.tasks.GetByID(ctx, run.TaskID); err == nil {
+		r.settleQueuedTask(ctx, task, run)
+	}
+	return run, nil
+}
+
+// settleQueuedTask gives a task the final status of its run that left the
+// queue without starting.
+func (r *TaskRunner) settleQueuedTask(ctx context.Context, task *model.Task, run *model.TaskRun) {
+	if task.Status != TaskStatusQueued {
+		return
+	}
+	task.Status = run.Status
+	task.FinishedAt = run.FinishedAt
+	if err := r.tasks.Update(ctx, task); err != nil {
+		log.Printf("update task %s: %v", task.ID, err)
+	}
+	if r.live != nil {
+		r.live.Publish(model.LiveEvent{
+			Type:   model.LiveEventFinished,
+			RunID:  run.ID,
+			TaskID: task.ID,
+			Status: run.Status,
+		})
+	}
+}
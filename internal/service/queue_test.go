@@ -0,0 +1,46 @@
+package service
+
+import (
+	"testing"
+
+	"bench-hub/internal/model"
+)
+
+func TestTargetHostKey(t *testing.T) {
+	cases := []struct {
+		target, fallback, want string
+	}{
+		{"https://API.example.com/v1", "", "api.example.com"},
+		{"api.example.com:8443", "", "api.example.com:8443"},
+		{"", "http://localhost:8080", "localhost:8080"},
+	}
+	for _, tc := range cases {
+		if got := targetHostKey(tc.target, tc.fallback); got != tc.want {
+			t.Errorf("targetHostKey(%q, %q) = %q, want %q", tc.target, tc.fallback, got, tc.want)
+		}
+	}
+}
+
+func TestQueueSlots(t *testing.T) {
+	running := []model.TaskRun{
+		{TargetHost: "http://a.example.com"},
+		{TargetHost: "https://a.example.com/login"},
+	}
+	slots := newQueueSlots(QueueLimits{MaxConcurrent: 3, MaxPerHost: 2}, running, "")
+
+	if slots.allow("a.example.com") {
+		t.Fatalf("expected host a to be at its limit")
+	}
+	if !slots.allow("b.example.com") {
+		t.Fatalf("expected host b to have capacity")
+	}
+	slots.take("b.example.com")
+	if !slots.full() || slots.allow("c.example.com") {
+		t.Fatalf("expected global limit to be reached")
+	}
+
+	unlimited := newQueueSlots(QueueLimits{}, running, "")
+	if unlimited.full() || !unlimited.allow("a.example.com") {
+		t.Fatalf("expected zero limits to mean unlimited")
+	}
+}
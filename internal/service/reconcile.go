@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"path/filepath"
+	"time"
+
+	"bench-hub/internal/engine"
+	"bench-hub/internal/model"
+	"bench-hub/internal/repository"
+)
+
+const (
+	// reconcileGrace leaves freshly started runs alone while their engine or
+	// runner job is still being set up.
+	reconcileGrace = time.Minute
+	// lostRunnerThreshold is how many consecutive sweeps a runner may be
+	// unreachable before its runs are given up.
+	lostRunnerThreshold = 3
+	// instanceHeartbeatInterval is how often this API instance records that
+	// it is alive. An instance silent for reconcileGrace is considered gone.
+	instanceHeartbeatInterval = 15 * time.Second
+	// stopPollInterval is how often this instance looks for stop requests
+	// that other instances recorded for its local runs.
+	stopPollInterval = 2 * time.Second
+)
+
+type runState int
+
+const (
+	runAlive runState = iota
+	runLost
+	runUnknown
+	runDone
+)
+
+// StartReconciler sweeps for stale runs every interval until ctx is done.
+// It also heartbeats this instance, so other instances can give up its local
+// runs once it is gone.
+func (r *TaskRunner) StartReconciler(ctx context.Context, interval time.Duration) {
+	go r.heartbeat(ctx)
+	go r.watchStops(ctx)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.Reconcile(ctx); err != nil {
+					log.Printf("reconcile runs: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (r *TaskRunner) heartbeat(ctx context.Context) {
+	if r.instances == nil {
+		return
+	}
+	ticker := time.NewTicker(instanceHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		if err := r.instances.Heartbeat(ctx, localNodeName()); err != nil {
+			log.Printf("instance heartbeat: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchStops stops the local runs of tasks moved to stopping. A stop request
+// can reach any API instance; for a local run of another one it only records
+// the stopping status, which the owning instance picks up here.
+func (r *TaskRunner) watchStops(ctx context.Context) {
+	ticker := time.NewTicker(stopPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !r.hasLocalRuns() {
+			continue
+		}
+		tasks, err := r.tasks.ListByStatus(ctx, TaskStatusStopping)
+		if err != nil {
+			log.Printf("poll stop requests: %v", err)
+			continue
+		}
+		r.stopTasks(tasks)
+	}
+}
+
+// stopTasks stops the local runs of tasks that are not stopped yet.
+func (r *TaskRunner) stopTasks(tasks []model.Task) {
+	stopping := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		stopping[task.ID] = true
+	}
+	var runIDs []string
+	r.runningMu.Lock()
+	for runID, entry := range r.running {
+		if stopping[entry.taskID] && !entry.stopped {
+			runIDs = append(runIDs, runID)
+		}
+	}
+	r.runningMu.Unlock()
+	for _, runID := range runIDs {
+		log.Printf("stopping run %s as requested through another instance", runID)
+		r.stopLocal(runID)
+	}
+}
+
+// Reconcile checks every run and task still marked running against the
+// local process table or the runner that owns it. Runs whose engine is gone
+// are failed with RunExitLostRunner and keep whatever reports were written.
+func (r *TaskRunner) Reconcile(ctx context.Context) error {
+	runs, err := r.runs.ListByStatus(ctx, TaskStatusRunning)
+	if err != nil {
+		return err
+	}
+
+	active := make(map[string]bool, len(runs))
+	for i := range runs {
+		run := &runs[i]
+		state, job := r.checkRun(ctx, run)
+		switch state {
+		case runAlive, runUnknown:
+			active[run.TaskID] = true
+		case runDone:
+			task, err := r.tasks.GetByID(ctx, run.TaskID)
+			if err != nil {
+				log.Printf("reconcile run %s: %v", run.ID, err)
+				continue
+			}
+			status, exitReason, _ := job.outcome()
+			run.StopOutcome = job.StopOutcome
+			r.finalize(ctx, task, run, status, exitReason, job.Reports)
+		case runLost:
+			task, err := r.tasks.GetByID(ctx, run.TaskID)
+			if err != nil {
+				log.Printf("reconcile run %s: %v", run.ID, err)
+				continue
+			}
+			log.Printf("run %s of task %s lost its runner %s", run.ID, run.TaskID, run.RunnerNode)
+			r.finalize(ctx, task, run, TaskStatusFailed, RunExitLostRunner, r.partialReports(ctx, task, run))
+		}
+	}
+
+	// Tasks can also be left running or stopping without any open run, e.g.
+	// when they were started before runs were recorded.
+	if err := r.sweepTasks(ctx, TaskStatusRunning, TaskStatusFailed, active); err != nil {
+		return err
+	}
+	return r.sweepTasks(ctx, TaskStatusStopping, TaskStatusStopped, active)
+}
+
+// sweepTasks moves tasks left in status without an open run to final.
+func (r *TaskRunner) sweepTasks(ctx context.Context, status, final string, active map[string]bool) error {
+	tasks, err := r.tasks.ListByStatus(ctx, status)
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		task := &tasks[i]
+		if active[task.ID] || r.isTaskRunningLocally(task.ID) {
+			continue
+		}
+		if task.StartedAt != nil && time.Since(*task.StartedAt) < reconcileGrace {
+			continue
+		}
+		current, err := r.tasks.GetByID(ctx, task.ID)
+		if err != nil || current.Status != status {
+			continue
+		}
+		now := time.Now()
+		current.Status = final
+		current.FinishedAt = &now
+		if err := r.tasks.Update(ctx, current); err != nil {
+			log.Printf("reconcile task %s: %v", task.ID, err)
+		}
+	}
+	return nil
+}
+
+func (r *TaskRunner) checkRun(ctx context.Context, run *model.TaskRun) (runState, *RunnerJob) {
+	startedAt := run.CreatedAt
+	if run.StartedAt != nil {
+		startedAt = *run.StartedAt
+	}
+	fresh := time.Since(startedAt) < reconcileGrace
+
+	if run.RunnerJobID != "" {
+		job, err := r.fetchJob(r.runnerBase(run), run.RunnerJobID)
+		if errors.Is(err, ErrNotFound) {
+			r.clearUnreachable(run.ID)
+			return runLost, nil
+		}
+		if err != nil {
+			if r.markUnreachable(run.ID) >= lostRunnerThreshold {
+				r.clearUnreachable(run.ID)
+				return runLost, nil
+			}
+			return runUnknown, nil
+		}
+		r.clearUnreachable(run.ID)
+		if _, _, ok := job.outcome(); ok {
+			return runDone, job
+		}
+		return runAlive, nil
+	}
+
+	if fresh {
+		return runUnknown, nil
+	}
+	if run.RunnerNode == localNodeName() {
+		if r.isRunningLocally(run.ID) {
+			return runAlive, nil
+		}
+		return runLost, nil
+	}
+	if isRemoteNode(run.RunnerNode) {
+		// Dispatched to a runner but the job id was never recorded.
+		return runLost, nil
+	}
+	// Local run of another API instance; only that instance can tell while
+	// it is alive. Once it stops heartbeating, e.g. because its container was
+	// replaced under a new hostname, nothing will finish the run.
+	if r.instanceAlive(ctx, run.RunnerNode) {
+		return runUnknown, nil
+	}
+	return runLost, nil
+}
+
+func (r *TaskRunner) instanceAlive(ctx context.Context, node string) bool {
+	if r.instances == nil {
+		return true
+	}
+	since, err := r.instances.LastHeartbeat(ctx, node)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false
+	}
+	if err != nil {
+		log.Printf("instance %s heartbeat: %v", node, err)
+		return true
+	}
+	return since < reconcileGrace
+}
+
+func (r *TaskRunner) isRunningLocally(runID string) bool {
+	r.runningMu.Lock()
+	defer r.runningMu.Unlock()
+	return r.running[runID] != nil
+}
+
+func (r *TaskRunner) hasLocalRuns() bool {
+	r.runningMu.Lock()
+	defer r.runningMu.Unlock()
+	return len(r.running) > 0
+}
+
+func (r *TaskRunner) isTaskRunningLocally(taskID string) bool {
+	r.runningMu.Lock()
+	defer r.runningMu.Unlock()
+	for _, entry := range r.running {
+		if entry.taskID == taskID {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *TaskRunner) markUnreachable(runID string) int {
+	r.runningMu.Lock()
+	defer r.runningMu.Unlock()
+	r.unreachable[runID]++
+	return r.unreachable[runID]
+}
+
+func (r *TaskRunner) clearUnreachable(runID string) {
+	r.runningMu.Lock()
+	delete(r.unreachable, runID)
+	r.runningMu.Unlock()
+}
+
+// partialReports returns the result files present in the run's report
+// directory that are not yet registered as reports.
+func (r *TaskRunner) partialReports(ctx context.Context, task *model.Task, run *model.TaskRun) []RunnerReport {
+	if run.ReportDir == "" {
+		return nil
+	}
+	existing := map[string]bool{}
+	if reports, err := r.reports.ListByRun(ctx, run.ID); err == nil {
+		for _, report := range reports {
+			existing[report.FilePath] = true
+		}
+	}
+
+	// An engine this server lacks still leaves the run log worth keeping.
+	eng, _ := r.engines.Get(run.Parameters.ScriptType)
+	var out []RunnerReport
+	for _, report := range collectPartialReports(eng, r.reportsDir, run.ReportDir, task.Name) {
+		if !existing[report.FilePath] {
+			out = append(out, report)
+		}
+	}
+	return out
+}
+
+func collectPartialReports(eng engine.Engine, reportsDir, reportDir, taskName string) []RunnerReport {
+	job := &engine.Job{Dir: filepath.Join(reportsDir, filepath.Base(reportDir)), TaskName: taskName}
+	reports := engine.Collect(eng, job)
+	out := make([]RunnerReport, 0, len(reports))
+	for _, report := range reports {
+		out = append(out, RunnerReport{Name: report.Name, Type: report.Type, FilePath: report.File})
+	}
+	return out
+}
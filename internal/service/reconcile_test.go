@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bench-hub/internal/engine/locust"
+	"bench-hub/internal/model"
+	"bench-hub/internal/runlog"
+)
+
+func TestCollectPartialReports(t *testing.T) {
+	reportsDir := t.TempDir()
+	dir := filepath.Join(reportsDir, "task_1_20240101000000")
+	if err := os.MkdirAll(filepath.Join(dir, "html-report"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for _, name := range []string{runlog.FileName, "report_stats.csv"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	reports := collectPartialReports(locust.New("locust"), reportsDir, "task_1_20240101000000", "smoke")
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 reports, got %+v", reports)
+	}
+	if reports[0].Type != "log" || reports[0].FilePath != filepath.Join("task_1_20240101000000", runlog.FileName) {
+		t.Fatalf("unexpected log report %+v", reports[0])
+	}
+	if reports[1].Type != "csv" || reports[1].Name != "smoke-report_stats.csv" {
+		t.Fatalf("unexpected csv report %+v", reports[1])
+	}
+
+	// Without the run's engine only the log is kept.
+	if reports := collectPartialReports(nil, reportsDir, "task_1_20240101000000", "smoke"); len(reports) != 1 || reports[0].Type != "log" {
+		t.Fatalf("expected the log alone, got %+v", reports)
+	}
+}
+
+func TestCheckRunLocalInstances(t *testing.T) {
+	ctx := context.Background()
+	instances := &fakeInstanceRepo{lastSeen: map[string]time.Time{
+		"local:api-live": time.Now(),
+		"local:api-gone": time.Now().Add(-2 * reconcileGrace),
+	}}
+	runner := &TaskRunner{instances: instances, running: map[string]*runningJob{}}
+	started := time.Now().Add(-2 * reconcileGrace)
+
+	cases := []struct {
+		node string
+		want runState
+	}{
+		{"local:api-live", runUnknown},
+		// The instance stopped heartbeating, e.g. its container came back
+		// under a new hostname.
+		{"local:api-gone", runLost},
+		// Instances that never heartbeat are gone as well.
+		{"local:api-old", runLost},
+		{localNodeName(), runLost},
+	}
+	for _, tc := range cases {
+		run := &model.TaskRun{ID: "run-1", TaskID: "task-1", RunnerNode: tc.node, StartedAt: &started}
+		if state, _ := runner.checkRun(ctx, run); state != tc.want {
+			t.Fatalf("%s: state = %d, want %d", tc.node, state, tc.want)
+		}
+	}
+
+	fresh := time.Now()
+	run := &model.TaskRun{ID: "run-2", TaskID: "task-2", RunnerNode: "local:api-gone", StartedAt: &fresh}
+	if state, _ := runner.checkRun(ctx, run); state != runUnknown {
+		t.Fatalf("expected a fresh run to be left alone, got %d", state)
+	}
+}
+
+func TestLocalRunsKeyedByRunID(t *testing.T) {
+	ctx := context.Background()
+	runner := &TaskRunner{running: map[string]*runningJob{}}
+	started := time.Now().Add(-2 * reconcileGrace)
+	previous := &model.TaskRun{ID: "run-1", TaskID: "task-1", RunnerNode: localNodeName(), StartedAt: &started}
+	current := &model.TaskRun{ID: "run-2", TaskID: "task-1", RunnerNode: localNodeName(), StartedAt: &started}
+
+	cancelled := false
+	runner.setRunning(current, func() { cancelled = true })
+	if state, _ := runner.checkRun(ctx, previous); state != runLost {
+		t.Fatalf("expected another run of the task to be lost, got %d", state)
+	}
+	if state, _ := runner.checkRun(ctx, current); state != runAlive {
+		t.Fatalf("expected the running run to be alive, got %d", state)
+	}
+	if !runner.isTaskRunningLocally("task-1") {
+		t.Fatal("expected the task to be running locally")
+	}
+
+	if runner.stopLocal(previous.ID) || cancelled {
+		t.Fatal("expected stopping another run to leave the engine alone")
+	}
+	if !runner.stopLocal(current.ID) || !cancelled {
+		t.Fatal("expected the running engine to be cancelled")
+	}
+	if !runner.clearRunning(current.ID) || runner.isTaskRunningLocally("task-1") {
+		t.Fatal("expected the stopped run to be cleared")
+	}
+}
+
+func TestStopTasksStopsLocalRuns(t *testing.T) {
+	runner := &TaskRunner{running: map[string]*runningJob{}}
+	started := time.Now()
+	cancelled := map[string]int{}
+	for _, id := range []string{"1", "2"} {
+		run := &model.TaskRun{ID: "run-" + id, TaskID: "task-" + id, RunnerNode: localNodeName(), StartedAt: &started}
+		runner.setRunning(run, func() { cancelled[run.ID]++ })
+	}
+
+	// Another instance moved task-1 to stopping.
+	runner.stopTasks([]model.Task{{ID: "task-1"}, {ID: "task-3"}})
+	runner.stopTasks([]model.Task{{ID: "task-1"}})
+	if cancelled["run-1"] != 1 || cancelled["run-2"] != 0 {
+		t.Fatalf("expected only run-1 to be stopped once, got %v", cancelled)
+	}
+	if !runner.clearRunning("run-1") {
+		t.Fatal("expected run-1 to be recorded as stopped")
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/repository"
+	"bench-hub/internal/runlog"
+)
+
+const (
+	RunExitCompleted    = "completed"
+	RunExitStopped      = "stopped"
+	RunExitEngineFailed = "engine_failed"
+	RunExitRunnerError  = "runner_error"
+	RunExitLostRunner   = "lost_runner"
+	RunExitCancelled    = "cancelled"
+	RunExitNoRunner     = "no_runner"
+)
+
+const defaultLogReadLimit = 64 << 10
+
+type RunService struct {
+	runs       repository.TaskRunRepository
+	tasks      repository.TaskRepository
+	reports    repository.ReportRepository
+	reportsDir string
+}
+
+func NewRunService(runs repository.TaskRunRepository, tasks repository.TaskRepository, reports repository.ReportRepository, reportsDir string) *RunService {
+	return &RunService{runs: runs, tasks: tasks, reports: reports, reportsDir: reportsDir}
+}
+
+func (s *RunService) Get(ctx context.Context, id string) (*model.TaskRun, error) {
+	run, err := s.runs.GetByID(ctx, id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	reports, err := s.reports.ListByRun(ctx, run.ID)
+	if err != nil {
+		return nil, err
+	}
+	run.Reports = reports
+	if run.Status == TaskStatusQueued {
+		queue, err := s.Queue(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, queued := range queue {
+			if queued.ID == run.ID {
+				run.QueuePosition = queued.QueuePosition
+			}
+		}
+	}
+	return run, nil
+}
+
+// Queue lists the runs waiting to start in dispatch order.
+func (s *RunService) Queue(ctx context.Context) ([]model.TaskRun, error) {
+	runs, err := s.runs.ListByStatus(ctx, TaskStatusQueued)
+	if err != nil {
+		return nil, err
+	}
+	for i := range runs {
+		runs[i].QueuePosition = i + 1
+	}
+	return runs, nil
+}
+
+func (s *RunService) ListByTask(ctx context.Context, taskID string, limit, offset int) ([]model.TaskRun, error) {
+	if _, err := s.tasks.GetByID(ctx, taskID); err != nil {
+		if err == repository.ErrNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return s.runs.ListByTask(ctx, taskID, limit, offset)
+}
+
+// ReadLog returns a chunk of a run's engine output starting at offset. When
+// runID is empty the task's latest run is used.
+func (s *RunService) ReadLog(ctx context.Context, taskID, runID string, offset, limit int64) (*model.RunLog, error) {
+	var run *model.TaskRun
+	if runID != "" {
+		found, err := s.runs.GetByID(ctx, runID)
+		if err != nil {
+			if err == repository.ErrNotFound {
+				return nil, ErrNotFound
+			}
+			return nil, err
+		}
+		if found.TaskID != taskID {
+			return nil, ErrNotFound
+		}
+		run = found
+	} else {
+		runs, err := s.ListByTask(ctx, taskID, 1, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(runs) == 0 {
+			return nil, ErrNotFound
+		}
+		run = &runs[0]
+	}
+
+	if limit <= 0 {
+		limit = defaultLogReadLimit
+	}
+	out := &model.RunLog{
+		RunID:   run.ID,
+		Offset:  offset,
+		Running: run.Status == TaskStatusRunning,
+	}
+	data, next, size, err := runlog.Read(filepath.Join(s.reportsDir, run.ReportDir, runlog.FileName), offset, limit)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			out.NextOffset = offset
+			return out, nil
+		}
+		return nil, err
+	}
+	out.Content = string(data)
+	out.NextOffset = next
+	out.Size = size
+	return out, nil
+}
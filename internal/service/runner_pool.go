@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/repository"
+)
+
+// RunnerPoolService tracks the runners that registered themselves with the
+// API and picks one for each run.
+type RunnerPoolService struct {
+	repo             repository.RunnerRepository
+	heartbeatTimeout time.Duration
+}
+
+func NewRunnerPoolService(repo repository.RunnerRepository, heartbeatTimeout time.Duration) *RunnerPoolService {
+	return &RunnerPoolService{repo: repo, heartbeatTimeout: heartbeatTimeout}
+}
+
+func (s *RunnerPoolService) Register(ctx context.Context, runner *model.Runner) error {
+	runner.Name = strings.TrimSpace(runner.Name)
+	runner.URL = strings.TrimRight(strings.TrimSpace(runner.URL), "/")
+	if runner.Name == "" || !isRemoteNode(runner.URL) || runner.MaxJobs < 0 {
+		return ErrInvalidRunner
+	}
+	runner.Labels = NormalizeLabels(runner.Labels)
+	if err := s.repo.Register(ctx, runner); err != nil {
+		return err
+	}
+	runner.Healthy = true
+	return nil
+}
+
+func (s *RunnerPoolService) Heartbeat(ctx context.Context, id string, activeJobs int, cpuPercent float64) error {
+	if err := s.repo.Heartbeat(ctx, id, activeJobs, cpuPercent); err != nil {
+		if err == repository.ErrNotFound {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *RunnerPoolService) List(ctx context.Context) ([]model.Runner, error) {
+	runners, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The age comes from the database, so API servers with skewed clocks
+	// agree on it.
+	for i := range runners {
+		runners[i].Healthy = runners[i].HeartbeatAge <= s.heartbeatTimeout
+	}
+	return runners, nil
+}
+
+// HasHealthy reports whether any runner is alive, whatever its labels and
+// load.
+func (s *RunnerPoolService) HasHealthy(ctx context.Context) (bool, error) {
+	runners, err := s.List(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, runner := range runners {
+		if runner.Healthy {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Pick chooses the least loaded healthy runner carrying all the given
+// labels. It returns ErrNoRunners when no runner is alive at all, so callers
+// can fall back to a static runner, and ErrNoRunnerAvailable when runners
+// exist but none fits.
+func (s *RunnerPoolService) Pick(ctx context.Context, labels map[string]string) (*model.Runner, error) {
+	runners, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *model.Runner
+	healthy := 0
+	for i := range runners {
+		runner := &runners[i]
+		if !runner.Healthy {
+			continue
+		}
+		healthy++
+		if !MatchLabels(runner.Labels, labels) {
+			continue
+		}
+		if runner.MaxJobs > 0 && runner.ActiveJobs >= runner.MaxJobs {
+			continue
+		}
+		if best == nil || lessLoaded(runner, best) {
+			best = runner
+		}
+	}
+	if healthy == 0 {
+		return nil, ErrNoRunners
+	}
+	if best == nil {
+		return nil, ErrNoRunnerAvailable
+	}
+
+	// Count the job right away so back-to-back dispatches spread out before
+	// the runner's next heartbeat reports the real number.
+	if err := s.repo.IncrementActive(ctx, best.ID); err != nil {
+		return nil, err
+	}
+	best.ActiveJobs++
+	return best, nil
+}
+
+// Release gives back the job Pick counted on the runner at url when the run
+// did not start there after all.
+func (s *RunnerPoolService) Release(ctx context.Context, url string) error {
+	return s.repo.ReleaseActive(ctx, url)
+}
+
+func runnerLoad(runner *model.Runner) float64 {
+	if runner.MaxJobs > 0 {
+		return float64(runner.ActiveJobs) / float64(runner.MaxJobs)
+	}
+	return float64(runner.ActiveJobs)
+}
+
+func lessLoaded(a, b *model.Runner) bool {
+	if la, lb := runnerLoad(a), runnerLoad(b); la != lb {
+		return la < lb
+	}
+	return a.CPUPercent < b.CPUPercent
+}
+
+// NormalizeLabels trims keys and values and drops empty keys.
+func NormalizeLabels(labels map[string]string) map[string]string {
+	out := make(map[string]string, len(labels))
+	for key, value := range labels {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		out[key] = strings.TrimSpace(value)
+	}
+	return out
+}
+
+// MatchLabels reports whether have contains every key/value pair of want.
+func MatchLabels(have, want map[string]string) bool {
+	for key, value := range want {
+		if have[key] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bench-hub/internal/model"
+)
+
+func TestRunnerPoolPick(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	repo := &fakeRunnerRepo{runners: []model.Runner{
+		{ID: "busy", URL: "http://busy:8081", Labels: map[string]string{"region": "eu"}, MaxJobs: 2, ActiveJobs: 1, LastHeartbeatAt: now},
+		{ID: "idle", URL: "http://idle:8081", Labels: map[string]string{"region": "eu"}, MaxJobs: 2, LastHeartbeatAt: now},
+		{ID: "us", URL: "http://us:8081", Labels: map[string]string{"region": "us"}, LastHeartbeatAt: now},
+		{ID: "stale", URL: "http://stale:8081", Labels: map[string]string{"region": "ap"}, LastHeartbeatAt: now.Add(-time.Hour)},
+	}}
+	pool := NewRunnerPoolService(repo, 30*time.Second)
+
+	runner, err := pool.Pick(ctx, map[string]string{"region": "eu"})
+	if err != nil || runner.ID != "idle" {
+		t.Fatalf("expected idle runner, got %+v %v", runner, err)
+	}
+	// Both eu runners now carry one job each; two more picks fill them up.
+	if _, err := pool.Pick(ctx, map[string]string{"region": "eu"}); err != nil {
+		t.Fatalf("second pick: %v", err)
+	}
+	if _, err := pool.Pick(ctx, map[string]string{"region": "eu"}); err != nil {
+		t.Fatalf("third pick: %v", err)
+	}
+	if _, err := pool.Pick(ctx, map[string]string{"region": "eu"}); err != ErrNoRunnerAvailable {
+		t.Fatalf("expected full pool, got %v", err)
+	}
+	if _, err := pool.Pick(ctx, map[string]string{"region": "ap"}); err != ErrNoRunnerAvailable {
+		t.Fatalf("expected stale runner to be skipped, got %v", err)
+	}
+
+	empty := NewRunnerPoolService(&fakeRunnerRepo{}, 30*time.Second)
+	if _, err := empty.Pick(ctx, nil); err != ErrNoRunners {
+		t.Fatalf("expected ErrNoRunners, got %v", err)
+	}
+}
+
+func TestPickWorkersSpreadsAcrossRunners(t *testing.T) {
+	now := time.Now()
+	repo := &fakeRunnerRepo{runners: []model.Runner{
+		{ID: "a", URL: "http://a:8081", MaxJobs: 2, LastHeartbeatAt: now},
+		{ID: "b", URL: "http://b:8081", MaxJobs: 2, LastHeartbeatAt: now},
+	}}
+	runner := &TaskRunner{pool: NewRunnerPoolService(repo, 30*time.Second)}
+
+	workers, err := runner.pickWorkers(context.Background(), &model.Task{}, 4)
+	if err != nil {
+		t.Fatalf("pick workers: %v", err)
+	}
+	perNode := map[string]int{}
+	for _, worker := range workers {
+		perNode[worker.Node]++
+	}
+	if perNode["http://a:8081"] != 2 || perNode["http://b:8081"] != 2 {
+		t.Fatalf("expected workers spread evenly, got %v", perNode)
+	}
+
+	if _, err := runner.pickWorkers(context.Background(), &model.Task{}, 1); err != ErrNoRunnerAvailable {
+		t.Fatalf("expected full pool, got %v", err)
+	}
+}
+
+func TestPickWorkersReleasesOnShortage(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	repo := &fakeRunnerRepo{runners: []model.Runner{
+		{ID: "a", URL: "http://a:8081", MaxJobs: 1, LastHeartbeatAt: now},
+		{ID: "b", URL: "http://b:8081", MaxJobs: 1, LastHeartbeatAt: now},
+	}}
+	runner := &TaskRunner{pool: NewRunnerPoolService(repo, 30*time.Second)}
+
+	if _, err := runner.pickWorkers(ctx, &model.Task{}, 3); err != ErrNoRunnerAvailable {
+		t.Fatalf("expected a shortage, got %v", err)
+	}
+	runners, _ := repo.List(ctx)
+	for _, r := range runners {
+		if r.ActiveJobs != 0 {
+			t.Fatalf("runner %s kept %d jobs after the shortage", r.ID, r.ActiveJobs)
+		}
+	}
+}
+
+func TestCanDistribute(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	cases := []struct {
+		name      string
+		runners   []model.Runner
+		runnerURL string
+		want      bool
+	}{
+		{"empty pool", nil, "", false},
+		{"stale runners only", []model.Runner{{ID: "a", LastHeartbeatAt: now.Add(-time.Hour)}}, "", false},
+		// A full runner frees up later; the run waits for it.
+		{"busy runner", []model.Runner{{ID: "a", MaxJobs: 1, ActiveJobs: 1, LastHeartbeatAt: now}}, "", true},
+		{"static runner", nil, "http://runner:8081", true},
+	}
+	for _, tc := range cases {
+		runner := &TaskRunner{pool: NewRunnerPoolService(&fakeRunnerRepo{runners: tc.runners}, 30*time.Second), runnerURL: tc.runnerURL}
+		if ok, err := runner.canDistribute(ctx); err != nil || ok != tc.want {
+			t.Fatalf("%s: canDistribute = %v %v, want %v", tc.name, ok, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/repository"
+	"bench-hub/internal/schedule"
+)
+
+// minScheduleInterval keeps interval schedules from flooding the runners.
+const minScheduleInterval = 60
+
+// scheduleSkippedRunning is the last_error of a firing skipped because the
+// task was still running or queued.
+const scheduleSkippedRunning = "task already running"
+
+type ScheduleService struct {
+	repo   repository.ScheduleRepository
+	tasks  repository.TaskRepository
+	runner *TaskRunner
+}
+
+func NewScheduleService(repo repository.ScheduleRepository, tasks repository.TaskRepository, runner *TaskRunner) *ScheduleService {
+	return &ScheduleService{repo: repo, tasks: tasks, runner: runner}
+}
+
+// ScheduleInput carries the user-editable fields of a schedule.
+type ScheduleInput struct {
+	TaskID          string
+	Name            string
+	CronExpr        string
+	IntervalSeconds int
+	Timezone        string
+	TargetHost      *string
+	Enabled         bool
+}
+
+func (in ScheduleInput) apply(s *model.Schedule) error {
+	in.Name = strings.TrimSpace(in.Name)
+	in.CronExpr = strings.TrimSpace(in.CronExpr)
+	in.Timezone = strings.TrimSpace(in.Timezone)
+	if in.Timezone == "" {
+		in.Timezone = "UTC"
+	}
+	if in.Name == "" || in.TaskID == "" {
+		return ErrInvalidSchedule
+	}
+	if (in.CronExpr == "") == (in.IntervalSeconds == 0) {
+		return ErrInvalidSchedule
+	}
+	if in.IntervalSeconds != 0 && in.IntervalSeconds < minScheduleInterval {
+		return ErrInvalidSchedule
+	}
+
+	s.TaskID = in.TaskID
+	s.Name = in.Name
+	s.CronExpr = in.CronExpr
+	s.IntervalSeconds = in.IntervalSeconds
+	s.Timezone = in.Timezone
+	s.TargetHost = in.TargetHost
+	s.Enabled = in.Enabled
+	if _, _, err := scheduleSpec(s); err != nil {
+		return err
+	}
+	return nil
+}
+
+func scheduleSpec(s *model.Schedule) (schedule.Spec, *time.Location, error) {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, nil, ErrInvalidSchedule
+	}
+	if s.IntervalSeconds > 0 {
+		return schedule.Every(time.Duration(s.IntervalSeconds) * time.Second), loc, nil
+	}
+	cron, err := schedule.ParseCron(s.CronExpr)
+	if err != nil {
+		return nil, nil, ErrInvalidSchedule
+	}
+	return cron, loc, nil
+}
+
+// nextRunAt returns the first fire time after last that is still in the
+// future. Slots missed while no replica was running are skipped rather than
+// fired in a burst.
+func nextRunAt(s *model.Schedule, last, now time.Time) *time.Time {
+	if !s.Enabled {
+		return nil
+	}
+	spec, loc, err := scheduleSpec(s)
+	if err != nil {
+		return nil
+	}
+	next := spec.Next(last.In(loc))
+	if !next.IsZero() && !next.After(now) {
+		next = spec.Next(now.In(loc))
+	}
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+func (s *ScheduleService) Create(ctx context.Context, in ScheduleInput, createdBy string) (*model.Schedule, error) {
+	item := &model.Schedule{}
+	if err := in.apply(item); err != nil {
+		return nil, err
+	}
+	if err := s.checkTask(ctx, item.TaskID); err != nil {
+		return nil, err
+	}
+	if createdBy != "" {
+		item.CreatedBy = &createdBy
+	}
+	now := time.Now()
+	item.NextRunAt = nextRunAt(item, now, now)
+
+	if err := s.repo.Create(ctx, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *ScheduleService) Get(ctx context.Context, id string) (*model.Schedule, error) {
+	item, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *ScheduleService) List(ctx context.Context, taskID string, limit, offset int) ([]model.Schedule, error) {
+	return s.repo.List(ctx, taskID, limit, offset)
+}
+
+func (s *ScheduleService) Update(ctx context.Context, id string, in ScheduleInput) (*model.Schedule, error) {
+	item, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := in.apply(item); err != nil {
+		return nil, err
+	}
+	if err := s.checkTask(ctx, item.TaskID); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	item.NextRunAt = nextRunAt(item, now, now)
+
+	if err := s.repo.Update(ctx, item); err != nil {
+		if err == repository.ErrNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *ScheduleService) Delete(ctx context.Context, id string) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if err == repository.ErrNotFound {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *ScheduleService) checkTask(ctx context.Context, taskID string) error {
+	if _, err := s.tasks.GetByID(ctx, taskID); err != nil {
+		if err == repository.ErrNotFound {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Start fires due schedules every interval until ctx is done.
+func (s *ScheduleService) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Tick(ctx, time.Now()); err != nil {
+					log.Printf("schedule tick: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Tick triggers every schedule that is due. Each slot is claimed in the
+// database first, so with several API replicas only one of them fires it.
+func (s *ScheduleService) Tick(ctx context.Context, now time.Time) error {
+	due, err := s.repo.ListDue(ctx, now)
+	if err != nil {
+		return err
+	}
+	for i := range due {
+		item := &due[i]
+		expected := *item.NextRunAt
+		next := nextRunAt(item, expected, now)
+		var nextAt time.Time
+		if next != nil {
+			nextAt = *next
+		}
+		claimed, err := s.repo.Claim(ctx, item.ID, expected, nextAt)
+		if err != nil {
+			log.Printf("claim schedule %s: %v", item.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		s.fire(ctx, item)
+	}
+	return nil
+}
+
+func (s *ScheduleService) fire(ctx context.Context, item *model.Schedule) {
+	opts := RunOptions{ScheduleID: item.ID}
+	if item.TargetHost != nil {
+		opts.TargetHost = *item.TargetHost
+	}
+	var runID *string
+	lastError := ""
+	run, err := s.runner.Run(ctx, item.TaskID, opts)
+	switch {
+	case err != nil:
+		lastError = err.Error()
+		log.Printf("schedule %s: run task %s: %v", item.ID, item.TaskID, err)
+	case !startedBy(run, item):
+		// Run hands back the run the task already has open; this slot is
+		// skipped and last_run_id keeps pointing at the schedule's own run.
+		lastError = scheduleSkippedRunning
+		log.Printf("schedule %s: task %s already running, skipped", item.ID, item.TaskID)
+	default:
+		runID = &run.ID
+	}
+	if err := s.repo.RecordRun(ctx, item.ID, runID, lastError); err != nil {
+		log.Printf("record schedule %s run: %v", item.ID, err)
+	}
+}
+
+// startedBy reports whether run is a new run this firing of the schedule
+// started, rather than one already open for the task.
+func startedBy(run *model.TaskRun, item *model.Schedule) bool {
+	if run.ScheduleID == nil || *run.ScheduleID != item.ID {
+		return false
+	}
+	return item.LastRunID == nil || *item.LastRunID != run.ID
+}
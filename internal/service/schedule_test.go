@@ -0,0 +1,72 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"bench-hub/internal/model"
+)
+
+func TestScheduleInputValidation(t *testing.T) {
+	cases := []struct {
+		name  string
+		input ScheduleInput
+		ok    bool
+	}{
+		{"cron", ScheduleInput{TaskID: "t", Name: "nightly", CronExpr: "0 2 * * *", Timezone: "UTC"}, true},
+		{"interval", ScheduleInput{TaskID: "t", Name: "hourly", IntervalSeconds: 3600}, true},
+		{"both", ScheduleInput{TaskID: "t", Name: "x", CronExpr: "0 2 * * *", IntervalSeconds: 3600}, false},
+		{"neither", ScheduleInput{TaskID: "t", Name: "x"}, false},
+		{"short interval", ScheduleInput{TaskID: "t", Name: "x", IntervalSeconds: 10}, false},
+		{"bad cron", ScheduleInput{TaskID: "t", Name: "x", CronExpr: "nope"}, false},
+		{"bad timezone", ScheduleInput{TaskID: "t", Name: "x", CronExpr: "@daily", Timezone: "Mars/Olympus"}, false},
+	}
+	for _, tc := range cases {
+		err := tc.input.apply(&model.Schedule{})
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: unexpected result %v", tc.name, err)
+		}
+	}
+}
+
+func TestNextRunAtSkipsMissedSlots(t *testing.T) {
+	item := &model.Schedule{CronExpr: "0 2 * * *", Timezone: "UTC", Enabled: true}
+	last := time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+
+	next := nextRunAt(item, last, now)
+	if next == nil || !next.Equal(time.Date(2024, 3, 6, 2, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected next slot after now, got %v", next)
+	}
+
+	interval := &model.Schedule{IntervalSeconds: 600, Timezone: "UTC", Enabled: true}
+	next = nextRunAt(interval, now, now.Add(time.Minute))
+	if next == nil || !next.Equal(now.Add(10*time.Minute)) {
+		t.Fatalf("expected interval to keep its anchor, got %v", next)
+	}
+
+	item.Enabled = false
+	if next := nextRunAt(item, last, now); next != nil {
+		t.Fatalf("expected disabled schedule to have no next run, got %v", next)
+	}
+}
+
+func TestStartedBy(t *testing.T) {
+	scheduleID, otherID, previousRun := "schedule-1", "schedule-2", "run-1"
+	item := &model.Schedule{ID: scheduleID, LastRunID: &previousRun}
+	cases := []struct {
+		name string
+		run  *model.TaskRun
+		want bool
+	}{
+		{"new run", &model.TaskRun{ID: "run-2", ScheduleID: &scheduleID}, true},
+		{"started by hand", &model.TaskRun{ID: "run-2"}, false},
+		{"started by another schedule", &model.TaskRun{ID: "run-2", ScheduleID: &otherID}, false},
+		{"previous firing still running", &model.TaskRun{ID: previousRun, ScheduleID: &scheduleID}, false},
+	}
+	for _, tc := range cases {
+		if got := startedBy(tc.run, item); got != tc.want {
+			t.Fatalf("%s: startedBy = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
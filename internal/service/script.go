@@ -2,54 +2,173 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"strings"
 
+	"bench-hub/internal/bundle"
+	"bench-hub/internal/engine"
 	"bench-hub/internal/model"
 	"bench-hub/internal/repository"
+	"bench-hub/internal/scriptcheck"
+	"bench-hub/internal/scriptparams"
+	"bench-hub/internal/textdiff"
 )
 
+// ScriptService keeps scripts. The script types are those of the engine
+// registry.
 type ScriptService struct {
-	repo repository.ScriptRepository
+	repo    repository.ScriptRepository
+	engines *engine.Registry
+	checker *ScriptChecker
 }
 
-func NewScriptService(repo repository.ScriptRepository) *ScriptService {
-	return &ScriptService{repo: repo}
+func NewScriptService(repo repository.ScriptRepository, engines *engine.Registry, checker *ScriptChecker) *ScriptService {
+	return &ScriptService{repo: repo, engines: engines, checker: checker}
 }
 
-func normalizeScriptType(value string) (string, error) {
+func (s *ScriptService) normalizeScriptType(value string) (string, error) {
 	if value == "" {
 		return model.ScriptTypeLocust, nil
 	}
 
 	value = strings.ToLower(strings.TrimSpace(value))
-	switch value {
-	case model.ScriptTypeLocust, model.ScriptTypeJMeter:
-		return value, nil
-	default:
+	if !slices.Contains(s.engines.Names(), value) {
 		return "", ErrInvalidScriptType
 	}
+	return value, nil
 }
 
-func (s *ScriptService) Create(ctx context.Context, name, description, scriptType, content string) (*model.Script, error) {
-	kind, err := normalizeScriptType(scriptType)
+// Formats lists the script types scripts may have.
+func (s *ScriptService) Formats() []model.ScriptFormat {
+	return s.engines.Formats()
+}
+
+// TypeOf returns the script type an uploaded file runs with, or "" when
+// judging by its name is not possible.
+func (s *ScriptService) TypeOf(filename string) string {
+	return s.engines.TypeOf(filename)
+}
+
+// ScriptInput carries the user-editable fields of a script for Create and
+// Update. Message describes the change in the version it produces. A
+// non-nil Bundle uploads an archive whose Entrypoint becomes the content.
+type ScriptInput struct {
+	Name        string
+	Description string
+	Type        string
+	Content     string
+	Parameters  []model.ScriptParameter
+	Bundle      []byte
+	Entrypoint  string
+	Message     string
+}
+
+// newScript builds an unsaved script from the input.
+func (s *ScriptService) newScript(in ScriptInput) (*model.Script, error) {
+	kind, err := s.normalizeScriptType(in.Type)
 	if err != nil {
 		return nil, err
 	}
+	params, err := scriptparams.Normalize(in.Parameters)
+	if err != nil {
+		return nil, ErrInvalidScriptParameter
+	}
 
 	script := &model.Script{
-		Name:        name,
-		Description: description,
+		Name:        in.Name,
+		Description: in.Description,
 		Type:        kind,
-		Content:     content,
+		Content:     in.Content,
+		Parameters:  params,
+	}
+	if in.Bundle != nil {
+		hint := ""
+		if in.Type != "" {
+			hint = kind
+		}
+		if err := s.applyBundle(script, in.Bundle, in.Entrypoint, hint); err != nil {
+			return nil, err
+		}
+	}
+	return script, nil
+}
+
+// Validate checks a script without saving it.
+func (s *ScriptService) Validate(ctx context.Context, in ScriptInput) (*model.ScriptValidation, error) {
+	script, err := s.newScript(in)
+	if err != nil {
+		return nil, err
+	}
+	return s.check(ctx, script), nil
+}
+
+func (s *ScriptService) check(ctx context.Context, script *model.Script) *model.ScriptValidation {
+	if s.checker == nil {
+		return scriptcheck.Result(nil)
+	}
+	return s.checker.Check(ctx, script)
+}
+
+// ensureValid rejects a script whose validation has errors.
+func (s *ScriptService) ensureValid(ctx context.Context, script *model.Script) error {
+	if result := s.check(ctx, script); !result.Valid {
+		return &ScriptInvalidError{Validation: result}
+	}
+	return nil
+}
+
+func (s *ScriptService) Create(ctx context.Context, in ScriptInput, author string) (*model.Script, error) {
+	script, err := s.newScript(in)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureValid(ctx, script); err != nil {
+		return nil, err
 	}
 
-	if err := s.repo.Create(ctx, script); err != nil {
+	message := in.Message
+	if message == "" {
+		message = "initial version"
+	}
+	if err := s.repo.Create(ctx, script, newScriptVersion(message, author)); err != nil {
 		return nil, err
 	}
 
 	return script, nil
 }
 
+// applyBundle validates an uploaded archive and makes its entrypoint the
+// script's content. An empty scriptType lets the entrypoint decide.
+func (s *ScriptService) applyBundle(script *model.Script, data []byte, entrypoint, scriptType string) error {
+	files, err := bundle.Files(data)
+	if err != nil {
+		return ErrInvalidBundle
+	}
+	entry, err := s.engines.Entrypoint(files, entrypoint, scriptType)
+	if err != nil {
+		return ErrInvalidBundle
+	}
+	content, err := bundle.ReadFile(data, entry)
+	if err != nil {
+		return ErrInvalidBundle
+	}
+	script.Type = s.engines.TypeOf(entry)
+	script.Content = string(content)
+	script.Entrypoint = entry
+	script.Files = files
+	script.Bundle = data
+	return nil
+}
+
+func newScriptVersion(message, author string) *model.ScriptVersion {
+	version := &model.ScriptVersion{Message: message}
+	if author != "" {
+		version.Author = &author
+	}
+	return version
+}
+
 func (s *ScriptService) Get(ctx context.Context, id string) (*model.Script, error) {
 	script, err := s.repo.GetByID(ctx, id)
 	if err != nil {
@@ -65,7 +184,10 @@ func (s *ScriptService) List(ctx context.Context, limit, offset int) ([]model.Sc
 	return s.repo.List(ctx, limit, offset)
 }
 
-func (s *ScriptService) Update(ctx context.Context, id, name, description, scriptType, content string) (*model.Script, error) {
+// Update changes the non-empty fields; nil parameters keep the current
+// declarations. A change to the type, content or parameters records a new
+// version.
+func (s *ScriptService) Update(ctx context.Context, id string, in ScriptInput, author string) (*model.Script, error) {
 	script, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		if err == repository.ErrNotFound {
@@ -73,34 +195,144 @@ func (s *ScriptService) Update(ctx context.Context, id, name, description, scrip
 		}
 		return nil, err
 	}
+	before := *script
 
-	if name != "" {
-		script.Name = name
+	if in.Name != "" {
+		script.Name = in.Name
 	}
-	if description != "" {
-		script.Description = description
+	if in.Description != "" {
+		script.Description = in.Description
 	}
-	if scriptType != "" {
-		kind, err := normalizeScriptType(scriptType)
+	if in.Type != "" {
+		kind, err := s.normalizeScriptType(in.Type)
 		if err != nil {
 			return nil, err
 		}
 		script.Type = kind
 	}
-	if content != "" {
-		script.Content = content
+	if in.Content != "" {
+		script.Content = in.Content
+	}
+	if in.Parameters != nil {
+		params, err := scriptparams.Normalize(in.Parameters)
+		if err != nil {
+			return nil, ErrInvalidScriptParameter
+		}
+		script.Parameters = params
+	}
+	if in.Bundle != nil {
+		hint := ""
+		if in.Type != "" {
+			hint = script.Type
+		}
+		if err := s.applyBundle(script, in.Bundle, in.Entrypoint, hint); err != nil {
+			return nil, err
+		}
+	} else if script.Entrypoint != "" && s.engines.TypeOf(script.Entrypoint) != script.Type {
+		return nil, ErrInvalidBundle
+	}
+
+	var version *model.ScriptVersion
+	if script.Type != before.Type || script.Content != before.Content || !slices.Equal(script.Parameters, before.Parameters) || in.Bundle != nil {
+		if script.Entrypoint != "" && script.Bundle == nil {
+			// The engine check needs the helper modules too.
+			current, err := s.Version(ctx, id, before.Version)
+			if err != nil {
+				return nil, err
+			}
+			script.Bundle = current.Bundle
+		}
+		if err := s.ensureValid(ctx, script); err != nil {
+			return nil, err
+		}
+		version = newScriptVersion(in.Message, author)
+	}
+	if err := s.repo.Update(ctx, script, version); err != nil {
+		if err == repository.ErrNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return script, nil
+}
+
+// Versions lists a script's versions newest first, without content.
+func (s *ScriptService) Versions(ctx context.Context, id string) ([]model.ScriptVersion, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
 	}
+	return s.repo.ListVersions(ctx, id)
+}
 
-	if err := s.repo.Update(ctx, script); err != nil {
+func (s *ScriptService) Version(ctx context.Context, id string, version int) (*model.ScriptVersion, error) {
+	item, err := s.repo.GetVersion(ctx, id, version)
+	if err != nil {
 		if err == repository.ErrNotFound {
 			return nil, ErrNotFound
 		}
 		return nil, err
 	}
+	return item, nil
+}
+
+// Diff returns a unified diff of the content between two versions.
+func (s *ScriptService) Diff(ctx context.Context, id string, from, to int) (string, error) {
+	a, err := s.Version(ctx, id, from)
+	if err != nil {
+		return "", err
+	}
+	b, err := s.Version(ctx, id, to)
+	if err != nil {
+		return "", err
+	}
+	return textdiff.Unified(fmt.Sprintf("v%d", from), fmt.Sprintf("v%d", to), a.Content, b.Content, textdiff.DefaultContext), nil
+}
+
+// Restore makes an old version current again by recording it as a new
+// version; history is never rewritten.
+func (s *ScriptService) Restore(ctx context.Context, id string, version int, author string) (*model.Script, error) {
+	old, err := s.Version(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	script, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 
+	script.Type = old.Type
+	script.Content = old.Content
+	script.Parameters = old.Parameters
+	script.Entrypoint = old.Entrypoint
+	script.Files = old.Files
+	script.Bundle = old.Bundle
+	message := fmt.Sprintf("restore version %d", version)
+	if err := s.repo.Update(ctx, script, newScriptVersion(message, author)); err != nil {
+		if err == repository.ErrNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
 	return script, nil
 }
 
+// Bundle returns the archive of a bundle script's current version.
+func (s *ScriptService) Bundle(ctx context.Context, id string) (*model.Script, []byte, error) {
+	script, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if script.Entrypoint == "" {
+		return nil, nil, ErrNotFound
+	}
+	version, err := s.Version(ctx, id, script.Version)
+	if err != nil {
+		return nil, nil, err
+	}
+	return script, version.Bundle, nil
+}
+
 func (s *ScriptService) Delete(ctx context.Context, id string) error {
 	if err := s.repo.Delete(ctx, id); err != nil {
 		if err == repository.ErrNotFound {
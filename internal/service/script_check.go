@@ -0,0 +1,136 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"bench-hub/internal/engine"
+	"bench-hub/internal/model"
+	"bench-hub/internal/scriptcheck"
+)
+
+// ScriptInvalidError reports a script that failed validation on save.
+type ScriptInvalidError struct {
+	Validation *model.ScriptValidation
+}
+
+func (e *ScriptInvalidError) Error() string {
+	return ErrInvalidScript.Error()
+}
+
+func (e *ScriptInvalidError) Is(target error) bool {
+	return target == ErrInvalidScript
+}
+
+// ScriptChecker validates scripts with their engine's check. Checks that
+// run the engine itself are done on a healthy runner when there is one,
+// else with the local engine.
+type ScriptChecker struct {
+	pool      *RunnerPoolService
+	engines   *engine.Registry
+	runnerURL string
+	token     string
+	client    *http.Client
+}
+
+func NewScriptChecker(pool *RunnerPoolService, engines *engine.Registry, runnerURL, runnerToken string) *ScriptChecker {
+	return &ScriptChecker{
+		pool:      pool,
+		engines:   engines,
+		runnerURL: runnerURL,
+		token:     runnerToken,
+		client:    &http.Client{Timeout: 45 * time.Second},
+	}
+}
+
+func (c *ScriptChecker) Check(ctx context.Context, script *model.Script) *model.ScriptValidation {
+	eng, err := c.engines.Get(script.Type)
+	if err != nil {
+		return scriptcheck.Result(nil)
+	}
+	issues, err := c.check(ctx, eng, script)
+	if err != nil {
+		// Saving must not depend on an engine being reachable.
+		log.Printf("%s script check: %v", eng.Name(), err)
+		issues = []model.ScriptIssue{{Severity: model.IssueWarning, Message: fmt.Sprintf("engine check skipped: %s is not available", eng.Name())}}
+	}
+	return scriptcheck.Result(issues)
+}
+
+func (c *ScriptChecker) check(ctx context.Context, eng engine.Engine, script *model.Script) ([]model.ScriptIssue, error) {
+	if eng.Format().RunnerCheck {
+		if base := c.runnerBase(ctx); base != "" {
+			return c.onRunner(ctx, base, script)
+		}
+	}
+	return eng.Check(ctx, script)
+}
+
+type runnerValidateRequest struct {
+	ScriptType    string                  `json:"script_type"`
+	ScriptContent string                  `json:"script_content"`
+	ScriptBundle  []byte                  `json:"script_bundle,omitempty"`
+	Entrypoint    string                  `json:"entrypoint,omitempty"`
+	Parameters    []model.ScriptParameter `json:"parameters,omitempty"`
+}
+
+func (c *ScriptChecker) onRunner(ctx context.Context, base string, script *model.Script) ([]model.ScriptIssue, error) {
+	data, err := json.Marshal(runnerValidateRequest{
+		ScriptType:    script.Type,
+		ScriptContent: script.Content,
+		ScriptBundle:  script.Bundle,
+		Entrypoint:    script.Entrypoint,
+		Parameters:    script.Parameters,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/validate", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(runnerTokenHeader, c.token)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("runner status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Issues []model.ScriptIssue `json:"issues"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return body.Issues, nil
+}
+
+// runnerBase picks the least loaded healthy runner without counting a job
+// against it, falling back to the configured runner.
+func (c *ScriptChecker) runnerBase(ctx context.Context) string {
+	if c.pool != nil {
+		runners, err := c.pool.List(ctx)
+		if err == nil {
+			var best *model.Runner
+			for i := range runners {
+				runner := &runners[i]
+				if runner.Healthy && (best == nil || lessLoaded(runner, best)) {
+					best = runner
+				}
+			}
+			if best != nil {
+				return best.URL
+			}
+		}
+	}
+	return c.runnerURL
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/scriptgen"
+)
+
+// GeneratedScripts are the scripts Generate created; JMeter is nil unless
+// requested.
+type GeneratedScripts struct {
+	Locust *model.Script `json:"locust"`
+	JMeter *model.Script `json:"jmeter,omitempty"`
+}
+
+// Generate creates a Locust script, and optionally an equivalent JMeter
+// plan named "<name>-jmeter", from an OpenAPI 3 document or a HAR
+// recording. Both are validated before either is saved.
+func (s *ScriptService) Generate(ctx context.Context, name, description string, source []byte, withJMeter bool, author string) (*GeneratedScripts, error) {
+	plan, err := scriptgen.FromSource(source)
+	if err != nil {
+		if errors.Is(err, scriptgen.ErrUnsupported) || errors.Is(err, scriptgen.ErrEmpty) {
+			return nil, ErrInvalidSource
+		}
+		return nil, err
+	}
+	if name == "" {
+		name = plan.Name
+	}
+	message := "generated from " + plan.Source
+
+	content, err := scriptgen.Locust(plan)
+	if err != nil {
+		return nil, err
+	}
+	locust, err := s.newScript(ScriptInput{
+		Name:        name,
+		Description: description,
+		Type:        model.ScriptTypeLocust,
+		Content:     content,
+		Parameters:  plan.Variables,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureValid(ctx, locust); err != nil {
+		return nil, err
+	}
+
+	var jmeter *model.Script
+	if withJMeter {
+		content, params, err := scriptgen.JMeter(plan)
+		if err != nil {
+			return nil, err
+		}
+		jmeter, err = s.newScript(ScriptInput{
+			Name:        name + "-jmeter",
+			Description: description,
+			Type:        model.ScriptTypeJMeter,
+			Content:     content,
+			Parameters:  params,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := s.ensureValid(ctx, jmeter); err != nil {
+			return nil, err
+		}
+	}
+
+	out := &GeneratedScripts{Locust: locust, JMeter: jmeter}
+	if err := s.repo.Create(ctx, locust, newScriptVersion(message, author)); err != nil {
+		return nil, err
+	}
+	if jmeter != nil {
+		if err := s.repo.Create(ctx, jmeter, newScriptVersion(message, author)); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// convertible are the script types Convert translates between.
+var convertible = map[string]bool{
+	model.ScriptTypeLocust: true,
+	model.ScriptTypeJMeter: true,
+}
+
+// Convert translates a script between JMeter and Locust into a new script
+// named "<name>-<to>" and reports what the translation dropped. Parameters
+// the result reads keep the source's declarations.
+func (s *ScriptService) Convert(ctx context.Context, id, to, author string) (*model.ScriptConversion, error) {
+	source, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	to, err = s.normalizeScriptType(to)
+	if err != nil {
+		return nil, err
+	}
+	if to == source.Type || !convertible[to] || !convertible[source.Type] {
+		return nil, ErrInvalidConversion
+	}
+
+	var plan *scriptgen.Plan
+	if source.Type == model.ScriptTypeJMeter {
+		plan, err = scriptgen.FromJMX(source.Content)
+	} else {
+		plan, err = scriptgen.FromLocust(source.Content)
+	}
+	if err != nil {
+		if errors.Is(err, scriptgen.ErrUnsupported) || errors.Is(err, scriptgen.ErrEmpty) {
+			return nil, ErrInvalidConversion
+		}
+		return nil, err
+	}
+	plan.Source = fmt.Sprintf("%s script %q version %d", source.Type, source.Name, source.Version)
+	untranslated := plan.Untranslated
+	for _, file := range source.Files {
+		if file != source.Entrypoint {
+			untranslated = append(untranslated, model.UntranslatedElement{Element: file, Reason: "only the entrypoint of a bundle is converted"})
+		}
+	}
+
+	var content string
+	var params []model.ScriptParameter
+	if to == model.ScriptTypeLocust {
+		content, err = scriptgen.Locust(plan)
+		params = plan.Variables
+	} else {
+		content, params, err = scriptgen.JMeter(plan)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, param := range params {
+		if j := slices.IndexFunc(source.Parameters, func(p model.ScriptParameter) bool { return p.Name == param.Name }); j >= 0 {
+			params[i] = source.Parameters[j]
+		}
+	}
+
+	script, err := s.newScript(ScriptInput{
+		Name:        source.Name + "-" + to,
+		Description: fmt.Sprintf("converted from %s version %d", source.Name, source.Version),
+		Type:        to,
+		Content:     content,
+		Parameters:  params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureValid(ctx, script); err != nil {
+		return nil, err
+	}
+	message := fmt.Sprintf("converted from %s version %d", source.Name, source.Version)
+	if err := s.repo.Create(ctx, script, newScriptVersion(message, author)); err != nil {
+		return nil, err
+	}
+
+	if untranslated == nil {
+		untranslated = []model.UntranslatedElement{}
+	}
+	return &model.ScriptConversion{Script: script, Untranslated: untranslated}, nil
+}
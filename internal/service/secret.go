@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/repository"
+	"bench-hub/internal/secret"
+)
+
+const maxSecretBytes = 64 << 10
+
+// Secret names double as environment variable names in the engine process.
+var secretNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,127}$`)
+
+type SecretService struct {
+	repo repository.SecretRepository
+	box  *secret.Box
+}
+
+func NewSecretService(repo repository.SecretRepository, box *secret.Box) *SecretService {
+	return &SecretService{repo: repo, box: box}
+}
+
+func (s *SecretService) Create(ctx context.Context, name, description, value, createdBy string) (*model.Secret, error) {
+	name = strings.TrimSpace(name)
+	if !secretNamePattern.MatchString(name) || value == "" || len(value) > maxSecretBytes {
+		return nil, ErrInvalidSecret
+	}
+	ciphertext, err := s.box.Seal([]byte(value), []byte(name))
+	if err != nil {
+		return nil, err
+	}
+
+	item := &model.Secret{Name: name, Description: description}
+	if createdBy != "" {
+		item.CreatedBy = &createdBy
+	}
+	if err := s.repo.Create(ctx, item, ciphertext); err != nil {
+		if err == repository.ErrConflict {
+			return nil, ErrSecretExists
+		}
+		return nil, err
+	}
+	return item, nil
+}
+
+// Update replaces the description and, when value is non-empty, rotates the
+// stored value. The name is immutable because tasks reference it.
+func (s *SecretService) Update(ctx context.Context, id, description, value string) (*model.Secret, error) {
+	item, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if len(value) > maxSecretBytes {
+		return nil, ErrInvalidSecret
+	}
+
+	var ciphertext []byte
+	if value != "" {
+		if ciphertext, err = s.box.Seal([]byte(value), []byte(item.Name)); err != nil {
+			return nil, err
+		}
+	}
+	item.Description = description
+	if err := s.repo.Update(ctx, item, ciphertext); err != nil {
+		if err == repository.ErrNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *SecretService) List(ctx context.Context) ([]model.Secret, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *SecretService) Delete(ctx context.Context, id string) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if err == repository.ErrNotFound {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Check reports ErrInvalidSecret unless every name refers to a stored
+// secret.
+func (s *SecretService) Check(ctx context.Context, names []string) error {
+	found, err := s.repo.Ciphertexts(ctx, names)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, ok := found[name]; !ok {
+			return ErrInvalidSecret
+		}
+	}
+	return nil
+}
+
+// Resolve decrypts the named secrets for injection into a run.
+func (s *SecretService) Resolve(ctx context.Context, names []string) (map[string]string, error) {
+	sealed, err := s.repo.Ciphertexts(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		ciphertext, ok := sealed[name]
+		if !ok {
+			return nil, ErrInvalidSecret
+		}
+		plaintext, err := s.box.Open(ciphertext, []byte(name))
+		if err != nil {
+			return nil, err
+		}
+		values[name] = string(plaintext)
+	}
+	return values, nil
+}
+
+// NormalizeSecretRefs trims and deduplicates a task's secret names.
+func NormalizeSecretRefs(names []string) ([]string, error) {
+	out := make([]string, 0, len(names))
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if !secretNamePattern.MatchString(name) {
+			return nil, ErrInvalidSecret
+		}
+		if !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	return out, nil
+}
@@ -1,12 +1,19 @@
 package service
 
 type Services struct {
-	Auth     *AuthService
-	Users    *UserService
-	Scripts  *ScriptService
-	Tasks    *TaskService
-	Reports  *ReportService
-	Runner   *TaskRunner
-	Settings *SettingsService
-	Stats    *StatsService
+	Auth      *AuthService
+	Users     *UserService
+	Scripts   *ScriptService
+	Tasks     *TaskService
+	Reports   *ReportService
+	Runner    *TaskRunner
+	Pool      *RunnerPoolService
+	Schedules *ScheduleService
+	Secrets   *SecretService
+	Runs      *RunService
+	Metrics   *MetricsService
+	Compare   *CompareService
+	Live      *LiveService
+	Settings  *SettingsService
+	Stats     *StatsService
 }
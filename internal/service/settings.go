@@ -2,11 +2,16 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 
+	"bench-hub/internal/model"
 	"bench-hub/internal/repository"
 )
 
-const settingsKeyP95Baseline = "p95_baseline"
+const (
+	settingsKeyP95Baseline = "p95_baseline"
+	settingsKeySLARules    = "sla_rules"
+)
 
 // Default is used when not configured.
 const defaultP95Baseline = "P95 < 300ms"
@@ -36,3 +41,44 @@ func (s *SettingsService) SetP95Baseline(ctx context.Context, value string) erro
 	}
 	return s.repo.Set(ctx, settingsKeyP95Baseline, value)
 }
+
+// GetSLARules returns the global SLA rules. Until rules are saved explicitly
+// they are derived from the p95 baseline setting.
+func (s *SettingsService) GetSLARules(ctx context.Context) ([]model.SLARule, error) {
+	value, ok, err := s.repo.Get(ctx, settingsKeySLARules)
+	if err != nil {
+		return nil, err
+	}
+	if ok && value != "" {
+		var rules []model.SLARule
+		if err := json.Unmarshal([]byte(value), &rules); err != nil {
+			return nil, err
+		}
+		return rules, nil
+	}
+
+	baseline, err := s.GetP95Baseline(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := ParseBaseline(baseline)
+	if err != nil {
+		return []model.SLARule{}, nil
+	}
+	return rules, nil
+}
+
+func (s *SettingsService) SetSLARules(ctx context.Context, rules []model.SLARule) ([]model.SLARule, error) {
+	normalized, err := NormalizeSLARules(rules)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(normalized)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Set(ctx, settingsKeySLARules, string(data)); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"bench-hub/internal/model"
+	"bench-hub/internal/results"
+)
+
+var slaMetrics = map[string]func(model.EndpointMetric) float64{
+	"p50":        func(m model.EndpointMetric) float64 { return m.P50Ms },
+	"p90":        func(m model.EndpointMetric) float64 { return m.P90Ms },
+	"p95":        func(m model.EndpointMetric) float64 { return m.P95Ms },
+	"p99":        func(m model.EndpointMetric) float64 { return m.P99Ms },
+	"avg":        func(m model.EndpointMetric) float64 { return m.AvgMs },
+	"max":        func(m model.EndpointMetric) float64 { return m.MaxMs },
+	"rps":        func(m model.EndpointMetric) float64 { return m.RPS },
+	"failures":   func(m model.EndpointMetric) float64 { return float64(m.FailureCount) },
+	"error_rate": errorRate,
+}
+
+var slaComparators = map[string]func(actual, threshold float64) bool{
+	"<":  func(a, t float64) bool { return a < t },
+	"<=": func(a, t float64) bool { return a <= t },
+	">":  func(a, t float64) bool { return a > t },
+	">=": func(a, t float64) bool { return a >= t },
+}
+
+var baselinePattern = regexp.MustCompile(`(?i)^\s*([a-z0-9_]+)\s*(<=|>=|<|>)\s*([0-9]+(?:\.[0-9]+)?)\s*(ms|s|%)?\s*$`)
+
+// errorRate is expressed in percent so thresholds read like "error_rate < 1".
+func errorRate(m model.EndpointMetric) float64 {
+	if m.RequestCount == 0 {
+		return 0
+	}
+	return float64(m.FailureCount) / float64(m.RequestCount) * 100
+}
+
+func normalizeSLARule(rule model.SLARule) (model.SLARule, error) {
+	rule.Metric = strings.ToLower(strings.TrimSpace(rule.Metric))
+	rule.Comparator = strings.TrimSpace(rule.Comparator)
+	rule.Endpoint = strings.TrimSpace(rule.Endpoint)
+	if _, ok := slaMetrics[rule.Metric]; !ok {
+		return rule, ErrInvalidSLARule
+	}
+	if _, ok := slaComparators[rule.Comparator]; !ok {
+		return rule, ErrInvalidSLARule
+	}
+	if rule.Threshold < 0 {
+		return rule, ErrInvalidSLARule
+	}
+	return rule, nil
+}
+
+func NormalizeSLARules(rules []model.SLARule) ([]model.SLARule, error) {
+	out := make([]model.SLARule, 0, len(rules))
+	for _, rule := range rules {
+		normalized, err := normalizeSLARule(rule)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, normalized)
+	}
+	return out, nil
+}
+
+// ParseBaseline turns the free-form baseline setting, e.g. "P95 < 300ms" or
+// "p95 < 300ms, error_rate < 1%", into rules. Seconds are converted to ms.
+func ParseBaseline(value string) ([]model.SLARule, error) {
+	var rules []model.SLARule
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		match := baselinePattern.FindStringSubmatch(part)
+		if match == nil {
+			return nil, ErrInvalidSLARule
+		}
+		threshold, err := strconv.ParseFloat(match[3], 64)
+		if err != nil {
+			return nil, ErrInvalidSLARule
+		}
+		if strings.EqualFold(match[4], "s") {
+			threshold *= 1000
+		}
+		rule, err := normalizeSLARule(model.SLARule{Metric: match[1], Comparator: match[2], Threshold: threshold})
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// MergeSLARules lets task rules override global rules on the same metric
+// and endpoint.
+func MergeSLARules(global, task []model.SLARule) []model.SLARule {
+	key := func(rule model.SLARule) string { return rule.Metric + "|" + rule.Endpoint }
+	overridden := make(map[string]bool, len(task))
+	for _, rule := range task {
+		overridden[key(rule)] = true
+	}
+	merged := make([]model.SLARule, 0, len(global)+len(task))
+	for _, rule := range global {
+		if !overridden[key(rule)] {
+			merged = append(merged, rule)
+		}
+	}
+	return append(merged, task...)
+}
+
+// EvaluateSLA checks rules against a run's metrics. Rules without an
+// endpoint apply to the aggregated row.
+func EvaluateSLA(rules []model.SLARule, metrics []model.EndpointMetric) (string, []model.SLAResult) {
+	if len(rules) == 0 || len(metrics) == 0 {
+		return model.SLAVerdictSkipped, nil
+	}
+
+	byName := make(map[string]model.EndpointMetric, len(metrics))
+	for _, metric := range metrics {
+		byName[metric.Name] = metric
+		if metric.Method != "" {
+			byName[metric.Method+" "+metric.Name] = metric
+		}
+	}
+
+	verdict := model.SLAVerdictPassed
+	out := make([]model.SLAResult, 0, len(rules))
+	for _, rule := range rules {
+		endpoint := rule.Endpoint
+		if endpoint == "" {
+			endpoint = results.AggregatedName
+		}
+		result := model.SLAResult{Rule: rule}
+		metric, ok := byName[endpoint]
+		extract := slaMetrics[rule.Metric]
+		compare := slaComparators[rule.Comparator]
+		switch {
+		case !ok:
+			result.Message = fmt.Sprintf("endpoint %q not found", endpoint)
+		case extract == nil || compare == nil:
+			result.Message = "invalid rule"
+		default:
+			actual := extract(metric)
+			result.Actual = &actual
+			result.Passed = compare(actual, rule.Threshold)
+		}
+		if !result.Passed {
+			verdict = model.SLAVerdictFailed
+		}
+		out = append(out, result)
+	}
+	return verdict, out
+}
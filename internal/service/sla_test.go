@@ -0,0 +1,74 @@
+package service
+
+import (
+	"testing"
+
+	"bench-hub/internal/model"
+)
+
+func TestParseBaseline(t *testing.T) {
+	rules, err := ParseBaseline("P95 < 300ms, error_rate <= 1%; p99 < 1.5s")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("expected 3 rules, got %d", len(rules))
+	}
+	if rules[0].Metric != "p95" || rules[0].Comparator != "<" || rules[0].Threshold != 300 {
+		t.Fatalf("unexpected first rule %+v", rules[0])
+	}
+	if rules[2].Threshold != 1500 {
+		t.Fatalf("expected seconds converted to ms, got %v", rules[2].Threshold)
+	}
+
+	if _, err := ParseBaseline("fast please"); err != ErrInvalidSLARule {
+		t.Fatalf("expected invalid rule error, got %v", err)
+	}
+}
+
+func TestEvaluateSLA(t *testing.T) {
+	metrics := []model.EndpointMetric{
+		{Method: "GET", Name: "/login", RequestCount: 100, FailureCount: 5, P95Ms: 450, RPS: 20},
+		{Name: "Aggregated", RequestCount: 1000, FailureCount: 5, P95Ms: 250, RPS: 600},
+	}
+
+	verdict, out := EvaluateSLA([]model.SLARule{
+		{Metric: "p95", Comparator: "<", Threshold: 300},
+		{Metric: "rps", Comparator: ">", Threshold: 500},
+		{Metric: "error_rate", Comparator: "<", Threshold: 1},
+	}, metrics)
+	if verdict != model.SLAVerdictPassed {
+		t.Fatalf("expected pass, got %s: %+v", verdict, out)
+	}
+
+	verdict, out = EvaluateSLA([]model.SLARule{
+		{Metric: "p95", Comparator: "<", Threshold: 300, Endpoint: "GET /login"},
+		{Metric: "p95", Comparator: "<", Threshold: 300, Endpoint: "/missing"},
+	}, metrics)
+	if verdict != model.SLAVerdictFailed {
+		t.Fatalf("expected fail, got %s", verdict)
+	}
+	if out[0].Passed || out[0].Actual == nil || *out[0].Actual != 450 {
+		t.Fatalf("unexpected endpoint result %+v", out[0])
+	}
+	if out[1].Passed || out[1].Actual != nil {
+		t.Fatalf("expected missing endpoint to fail without value, got %+v", out[1])
+	}
+
+	if verdict, _ := EvaluateSLA(nil, metrics); verdict != model.SLAVerdictSkipped {
+		t.Fatalf("expected skipped without rules, got %s", verdict)
+	}
+}
+
+func TestMergeSLARules(t *testing.T) {
+	merged := MergeSLARules(
+		[]model.SLARule{{Metric: "p95", Comparator: "<", Threshold: 300}, {Metric: "error_rate", Comparator: "<", Threshold: 1}},
+		[]model.SLARule{{Metric: "p95", Comparator: "<", Threshold: 800}},
+	)
+	if len(merged) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(merged))
+	}
+	if merged[1].Metric != "p95" || merged[1].Threshold != 800 {
+		t.Fatalf("expected task rule to override global, got %+v", merged)
+	}
+}
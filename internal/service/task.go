@@ -2,38 +2,89 @@ package service
 
 import (
 	"context"
-	"time"
 
+	"bench-hub/internal/loadshape"
 	"bench-hub/internal/model"
 	"bench-hub/internal/repository"
+	"bench-hub/internal/scriptparams"
 )
 
 const (
 	TaskStatusCreated  = "created"
+	TaskStatusQueued   = "queued"
 	TaskStatusRunning  = "running"
+	TaskStatusStopping = "stopping"
 	TaskStatusStopped  = "stopped"
 	TaskStatusFinished = "finished"
 	TaskStatusFailed   = "failed"
 )
 
 type TaskService struct {
-	repo repository.TaskRepository
+	repo    repository.TaskRepository
+	scripts repository.ScriptRepository
+	secrets *SecretService
 }
 
-func NewTaskService(repo repository.TaskRepository) *TaskService {
-	return &TaskService{repo: repo}
+func NewTaskService(repo repository.TaskRepository, scripts repository.ScriptRepository, secrets *SecretService) *TaskService {
+	return &TaskService{repo: repo, scripts: scripts, secrets: secrets}
 }
 
-func (s *TaskService) Create(ctx context.Context, name, scriptID string, usersCount, spawnRate, durationSeconds int, targetHost *string, jmeterTPM *int) (*model.Task, error) {
-	task := &model.Task{
-		Name:            name,
-		ScriptID:        scriptID,
-		UsersCount:      usersCount,
-		SpawnRate:       spawnRate,
-		DurationSeconds: durationSeconds,
-		TargetHost:      targetHost,
-		JmeterTPM:       jmeterTPM,
-		Status:          TaskStatusCreated,
+// TaskInput carries the user-editable fields of a task for Create and
+// Update.
+type TaskInput struct {
+	Name            string
+	ScriptID        string
+	ScriptVersion   *int
+	UsersCount      int
+	SpawnRate       int
+	DurationSeconds int
+	TargetHost      *string
+	JmeterTPM       *int
+	Workers         int
+	LoadProfile     *model.LoadProfile
+	Variables       map[string]string
+	Secrets         []string
+	SLARules        []model.SLARule
+	RunnerLabels    map[string]string
+}
+
+func (in TaskInput) apply(task *model.Task) error {
+	rules, err := NormalizeSLARules(in.SLARules)
+	if err != nil {
+		return err
+	}
+	if _, err := loadshape.Stages(in.LoadProfile, in.UsersCount, in.SpawnRate, in.DurationSeconds); err != nil {
+		return ErrInvalidLoadProfile
+	}
+	secrets, err := NormalizeSecretRefs(in.Secrets)
+	if err != nil {
+		return err
+	}
+
+	task.Name = in.Name
+	task.ScriptID = in.ScriptID
+	task.ScriptVersion = in.ScriptVersion
+	task.UsersCount = in.UsersCount
+	task.SpawnRate = in.SpawnRate
+	task.DurationSeconds = in.DurationSeconds
+	task.TargetHost = in.TargetHost
+	task.JmeterTPM = in.JmeterTPM
+	task.Workers = in.Workers
+	task.LoadProfile = in.LoadProfile
+	task.Variables = in.Variables
+	task.Secrets = secrets
+	task.SLARules = rules
+	task.RunnerLabels = NormalizeLabels(in.RunnerLabels)
+	return nil
+}
+
+func (s *TaskService) Create(ctx context.Context, in TaskInput) (*model.Task, error) {
+	task := &model.Task{Status: TaskStatusCreated}
+	if err := in.apply(task); err != nil {
+		return nil, err
+	}
+	if err := s.checkReferences(ctx, task); err != nil {
+		return nil, err
 	}
 
 	if err := s.repo.Create(ctx, task); err != nil {
@@ -42,6 +93,47 @@ func (s *TaskService) Create(ctx context.Context, name, scriptID string, usersCo
 	return task, nil
 }
 
+// checkReferences rejects a pinned script version that does not exist, task
+// variables the script does not declare or whose values do not match the
+// declared types, and secrets that do not exist or would shadow a variable.
+func (s *TaskService) checkReferences(ctx context.Context, task *model.Task) error {
+	script, err := s.scripts.GetByID(ctx, task.ScriptID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return ErrScriptNotFound
+		}
+		return err
+	}
+	params := script.Parameters
+	if task.ScriptVersion != nil {
+		version, err := s.scripts.GetVersion(ctx, task.ScriptID, *task.ScriptVersion)
+		if err != nil {
+			if err == repository.ErrNotFound {
+				return ErrScriptVersionNotFound
+			}
+			return err
+		}
+		params = version.Parameters
+	}
+	if _, err := scriptparams.Resolve(params, task.Variables); err != nil {
+		return ErrInvalidVariables
+	}
+	if len(task.Secrets) == 0 {
+		return nil
+	}
+	for _, param := range params {
+		for _, name := range task.Secrets {
+			if param.Name == name {
+				return ErrInvalidSecret
+			}
+		}
+	}
+	if s.secrets == nil {
+		return ErrInvalidSecret
+	}
+	return s.secrets.Check(ctx, task.Secrets)
+}
+
 func (s *TaskService) Get(ctx context.Context, id string) (*model.Task, error) {
 	task, err := s.repo.GetByID(ctx, id)
 	if err != nil {
@@ -57,7 +149,7 @@ func (s *TaskService) List(ctx context.Context, limit, offset int) ([]model.Task
 	return s.repo.List(ctx, limit, offset)
 }
 
-func (s *TaskService) Update(ctx context.Context, id, name, scriptID string, usersCount, spawnRate, durationSeconds int, targetHost *string, jmeterTPM *int) (*model.Task, error) {
+func (s *TaskService) Update(ctx context.Context, id string, in TaskInput) (*model.Task, error) {
 	task, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		if err == repository.ErrNotFound {
@@ -66,44 +158,13 @@ func (s *TaskService) Update(ctx context.Context, id, name, scriptID string, use
 		return nil, err
 	}
 
-	task.Name = name
-	task.ScriptID = scriptID
-	task.UsersCount = usersCount
-	task.SpawnRate = spawnRate
-	task.DurationSeconds = durationSeconds
-	task.TargetHost = targetHost
-	task.JmeterTPM = jmeterTPM
-
-	if err := s.repo.Update(ctx, task); err != nil {
-		if err == repository.ErrNotFound {
-			return nil, ErrNotFound
-		}
+	if err := in.apply(task); err != nil {
 		return nil, err
 	}
-
-	return task, nil
-}
-
-func (s *TaskService) Stop(ctx context.Context, id string) (*model.Task, error) {
-	task, err := s.repo.GetByID(ctx, id)
-	if err != nil {
-		if err == repository.ErrNotFound {
-			return nil, ErrNotFound
-		}
+	if err := s.checkReferences(ctx, task); err != nil {
 		return nil, err
 	}
 
-	if task.Status == TaskStatusFinished || task.Status == TaskStatusFailed {
-		return task, nil
-	}
-
-	now := time.Now()
-	task.Status = TaskStatusStopped
-	if task.StartedAt == nil {
-		task.StartedAt = &now
-	}
-	task.FinishedAt = &now
-
 	if err := s.repo.Update(ctx, task); err != nil {
 		if err == repository.ErrNotFound {
 			return nil, ErrNotFound
@@ -6,33 +6,60 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 
+	"bench-hub/internal/engine"
+	"bench-hub/internal/loadshape"
 	"bench-hub/internal/model"
 	"bench-hub/internal/repository"
+	"bench-hub/internal/results"
+	"bench-hub/internal/scriptparams"
 )
 
 type TaskRunner struct {
 	tasks      repository.TaskRepository
 	scripts    repository.ScriptRepository
+	runs       repository.TaskRunRepository
 	reports    repository.ReportRepository
+	instances  repository.InstanceRepository
+	metrics    *MetricsService
+	settings   *SettingsService
+	live       *LiveService
+	pool       *RunnerPoolService
+	secrets    *SecretService
 	reportsDir string
-	locustBin  string
+	engines    *engine.Registry
 	locustHost string
 	runnerURL  string
-	runningMu  sync.Mutex
-	running    map[string]*runningCommand
+	// runnerToken is the shared token runners require on every request.
+	runnerToken string
+	logMaxSize  int64
+	stopGrace   time.Duration
+	client      *http.Client
+	runningMu   sync.Mutex
+	running     map[string]*runningJob
+	// unreachable counts consecutive failed runner checks per run.
+	unreachable map[string]int
+	limits      QueueLimits
+	wake        chan struct{}
 }
 
-type runningCommand struct {
-	cmd     *exec.Cmd
+const (
+	liveSnapshotInterval = 5 * time.Second
+	jobPollInterval      = 5 * time.Second
+)
+
+// runningJob is a local run in progress, keyed by run ID; cancel stops its
+// engine.
+type runningJob struct {
+	taskID  string
+	cancel  context.CancelFunc
 	stopped bool
 }
 
@@ -46,20 +73,41 @@ func pickTargetHost(input string, fallback *string) string {
 	return ""
 }
 
-func NewTaskRunner(tasks repository.TaskRepository, scripts repository.ScriptRepository, reports repository.ReportRepository, reportsDir, locustBin, locustHost, runnerURL string) *TaskRunner {
+func NewTaskRunner(tasks repository.TaskRepository, scripts repository.ScriptRepository, runs repository.TaskRunRepository, reports repository.ReportRepository, instances repository.InstanceRepository, metrics *MetricsService, settings *SettingsService, live *LiveService, pool *RunnerPoolService, secrets *SecretService, engines *engine.Registry, reportsDir, locustHost, runnerURL, runnerToken string, logMaxSize int64, stopGrace time.Duration, limits QueueLimits) *TaskRunner {
 	return &TaskRunner{
-		tasks:      tasks,
-		scripts:    scripts,
-		reports:    reports,
-		reportsDir: reportsDir,
-		locustBin:  locustBin,
-		locustHost: locustHost,
-		runnerURL:  runnerURL,
-		running:    make(map[string]*runningCommand),
+		tasks:       tasks,
+		scripts:     scripts,
+		runs:        runs,
+		reports:     reports,
+		instances:   instances,
+		metrics:     metrics,
+		settings:    settings,
+		live:        live,
+		pool:        pool,
+		secrets:     secrets,
+		reportsDir:  reportsDir,
+		engines:     engines,
+		locustHost:  locustHost,
+		runnerURL:   runnerURL,
+		runnerToken: runnerToken,
+		logMaxSize:  logMaxSize,
+		stopGrace:   stopGrace,
+		limits:      limits,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		running:     make(map[string]*runningJob),
+		unreachable: make(map[string]int),
+		wake:        make(chan struct{}, 1),
 	}
 }
 
-func (r *TaskRunner) Run(ctx context.Context, taskID, targetHost string) (*model.Task, error) {
+// RunOptions describes who or what started a run.
+type RunOptions struct {
+	TargetHost  string
+	TriggeredBy string
+	ScheduleID  string
+}
+
+func (r *TaskRunner) Run(ctx context.Context, taskID string, opts RunOptions) (*model.TaskRun, error) {
 	task, err := r.tasks.GetByID(ctx, taskID)
 	if err != nil {
 		if err == repository.ErrNotFound {
@@ -68,29 +116,191 @@ func (r *TaskRunner) Run(ctx context.Context, taskID, targetHost string) (*model
 		return nil, err
 	}
 
-	if task.Status == TaskStatusRunning {
-		return task, nil
+	if task.Status == TaskStatusRunning || task.Status == TaskStatusStopping || task.Status == TaskStatusQueued {
+		if run, err := r.latestRun(ctx, task.ID); err == nil && (run.Status == TaskStatusRunning || run.Status == TaskStatusQueued) {
+			return run, nil
+		}
 	}
 
-	script, err := r.scripts.GetByID(ctx, task.ScriptID)
+	var pinned int
+	if task.ScriptVersion != nil {
+		pinned = *task.ScriptVersion
+	}
+	script, err := r.scriptAt(ctx, task.ScriptID, pinned)
 	if err != nil {
 		if err == repository.ErrNotFound {
 			return nil, ErrNotFound
 		}
 		return nil, err
 	}
+	if task.Workers > 0 {
+		if script.Type != "" && script.Type != model.ScriptTypeLocust {
+			return nil, ErrUnsupportedEngine
+		}
+		ok, err := r.canDistribute(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrNoRunnerAvailable
+		}
+	}
+
+	stages, err := loadshape.Stages(task.LoadProfile, task.UsersCount, task.SpawnRate, task.DurationSeconds)
+	if err != nil {
+		return nil, ErrInvalidLoadProfile
+	}
+	variables, err := scriptparams.Resolve(script.Parameters, task.Variables)
+	if err != nil {
+		return nil, ErrInvalidVariables
+	}
+	params := model.RunParameters{
+		ScriptID:        script.ID,
+		ScriptType:      script.Type,
+		ScriptVersion:   script.Version,
+		UsersCount:      task.UsersCount,
+		SpawnRate:       task.SpawnRate,
+		DurationSeconds: task.DurationSeconds,
+		JmeterTPM:       task.JmeterTPM,
+		Workers:         task.Workers,
+		Stages:          stages,
+		Variables:       variables,
+		Secrets:         task.Secrets,
+	}
+	if len(stages) > 0 {
+		params.UsersCount = loadshape.Peak(stages)
+		params.DurationSeconds = loadshape.Duration(stages)
+	}
 
+	// The run waits in the queue; Dispatch picks its node and starts it once
+	// the concurrency limits allow.
 	now := time.Now()
-	task.Status = TaskStatusRunning
-	task.StartedAt = &now
+	run := &model.TaskRun{
+		TaskID:     task.ID,
+		Status:     TaskStatusQueued,
+		Parameters: params,
+		TargetHost: pickTargetHost(opts.TargetHost, task.TargetHost),
+		ReportDir:  fmt.Sprintf("task_%s_%s", task.ID, now.Format("20060102150405")),
+	}
+	if opts.TriggeredBy != "" {
+		run.TriggeredBy = &opts.TriggeredBy
+	}
+	if opts.ScheduleID != "" {
+		run.ScheduleID = &opts.ScheduleID
+	}
+	if err := r.runs.Create(ctx, run); err != nil {
+		return nil, err
+	}
+	run.TaskName = &task.Name
+
+	task.Status = TaskStatusQueued
 	task.FinishedAt = nil
 	if err := r.tasks.Update(ctx, task); err != nil {
 		return nil, err
 	}
 
-	go r.execute(task, script, pickTargetHost(targetHost, task.TargetHost))
+	r.notifyQueue()
+	return run, nil
+}
 
-	return task, nil
+// scriptAt returns the script as of the given version, or as it is now when
+// version is 0, with its bundle archive loaded.
+func (r *TaskRunner) scriptAt(ctx context.Context, id string, version int) (*model.Script, error) {
+	script, err := r.scripts.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 {
+		version = script.Version
+	}
+	if version == script.Version && script.Entrypoint == "" {
+		return script, nil
+	}
+	snapshot, err := r.scripts.GetVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	script.Type = snapshot.Type
+	script.Content = snapshot.Content
+	script.Parameters = snapshot.Parameters
+	script.Entrypoint = snapshot.Entrypoint
+	script.Files = snapshot.Files
+	script.Bundle = snapshot.Bundle
+	script.Version = snapshot.Version
+	return script, nil
+}
+
+func (r *TaskRunner) latestRun(ctx context.Context, taskID string) (*model.TaskRun, error) {
+	runs, err := r.runs.ListByTask(ctx, taskID, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return &runs[0], nil
+}
+
+// pickNode decides where a run executes: a registered runner matching the
+// task's labels, else the statically configured runner, else this process.
+func (r *TaskRunner) pickNode(ctx context.Context, task *model.Task) (string, error) {
+	if r.pool != nil {
+		runner, err := r.pool.Pick(ctx, task.RunnerLabels)
+		if err == nil {
+			return runner.URL, nil
+		}
+		if !errors.Is(err, ErrNoRunners) {
+			return "", err
+		}
+	}
+	if len(task.RunnerLabels) > 0 {
+		return "", ErrNoRunnerAvailable
+	}
+	return r.nodeName(), nil
+}
+
+// canDistribute reports whether a distributed run has runners to go to: the
+// static runner or a healthy one in the pool. Busy runners still count; the
+// run waits in the queue for them.
+func (r *TaskRunner) canDistribute(ctx context.Context) (bool, error) {
+	if r.runnerURL != "" {
+		return true, nil
+	}
+	if r.pool == nil {
+		return false, nil
+	}
+	return r.pool.HasHealthy(ctx)
+}
+
+// releaseNodes gives back the runner slots pickNode took for nodes a run
+// does not start on.
+func (r *TaskRunner) releaseNodes(ctx context.Context, nodes ...string) {
+	if r.pool == nil {
+		return
+	}
+	for _, node := range nodes {
+		if !isRemoteNode(node) {
+			continue
+		}
+		if err := r.pool.Release(ctx, node); err != nil {
+			log.Printf("release runner %s: %v", node, err)
+		}
+	}
+}
+
+func (r *TaskRunner) nodeName() string {
+	if r.runnerURL != "" {
+		return r.runnerURL
+	}
+	return localNodeName()
+}
+
+func localNodeName() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "local"
+	}
+	return "local:" + hostname
 }
 
 func (r *TaskRunner) Stop(ctx context.Context, taskID string) (*model.Task, error) {
@@ -102,24 +312,62 @@ func (r *TaskRunner) Stop(ctx context.Context, taskID string) (*model.Task, erro
 		return nil, err
 	}
 
-	if task.Status == TaskStatusFinished || task.Status == TaskStatusFailed || task.Status == TaskStatusStopped {
+	if task.Status == TaskStatusFinished || task.Status == TaskStatusFailed || task.Status == TaskStatusStopped || task.Status == TaskStatusStopping {
 		return task, nil
 	}
 
-	if r.runnerURL != "" {
-		if err := r.stopRemote(taskID); err != nil {
+	run, err := r.latestRun(ctx, task.ID)
+	if err != nil && err != repository.ErrNotFound {
+		return nil, err
+	}
+	if run != nil && run.Status == TaskStatusQueued {
+		if _, err := r.Cancel(ctx, run.ID); err != nil && !errors.Is(err, ErrRunNotQueued) {
 			return nil, err
 		}
-	} else {
-		_ = r.stopLocal(taskID)
+		return r.tasks.GetByID(ctx, task.ID)
+	}
+	if run != nil && run.Status != TaskStatusRunning {
+		run = nil
+	}
+
+	// When the engine is still alive it finishes the run itself once it has
+	// exited, so reports and metrics are collected. Otherwise nothing else will
+	// close the run and it is finalized here.
+	signalled := false
+	switch {
+	case run != nil && isRemoteNode(run.RunnerNode):
+		signalled, err = r.stopRemote(r.runnerBase(run), task.ID, run.RunnerJobID)
+	case run == nil && r.runnerURL != "":
+		signalled, err = r.stopRemote(r.runnerURL, task.ID, "")
+	case run != nil && run.RunnerNode == localNodeName():
+		signalled = r.stopLocal(run.ID)
+	case run != nil:
+		// A local run of another API instance. The task going to stopping
+		// asks that instance to stop it; see watchStops.
+		signalled = r.instanceAlive(ctx, run.RunnerNode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if run != nil && !signalled {
+		run.StopOutcome = engine.StopExited
+		r.finalize(ctx, task, run, TaskStatusStopped, RunExitStopped, nil)
+		return r.tasks.GetByID(ctx, task.ID)
 	}
 
 	now := time.Now()
-	task.Status = TaskStatusStopped
 	if task.StartedAt == nil {
 		task.StartedAt = &now
 	}
-	task.FinishedAt = &now
+	if run != nil {
+		// The engine is flushing its reports; finalize sets the final
+		// status once it has exited.
+		task.Status = TaskStatusStopping
+	} else {
+		task.Status = TaskStatusStopped
+		task.FinishedAt = &now
+	}
 	if err := r.tasks.Update(ctx, task); err != nil {
 		if err == repository.ErrNotFound {
 			return nil, ErrNotFound
@@ -130,112 +378,217 @@ func (r *TaskRunner) Stop(ctx context.Context, taskID string) (*model.Task, erro
 	return task, nil
 }
 
-func (r *TaskRunner) setRunning(taskID string, cmd *exec.Cmd) {
+func (r *TaskRunner) setRunning(run *model.TaskRun, cancel context.CancelFunc) {
 	r.runningMu.Lock()
-	r.running[taskID] = &runningCommand{cmd: cmd}
+	r.running[run.ID] = &runningJob{taskID: run.TaskID, cancel: cancel}
 	r.runningMu.Unlock()
 }
 
-func (r *TaskRunner) clearRunning(taskID string) (stopped bool) {
+func (r *TaskRunner) clearRunning(runID string) (stopped bool) {
 	r.runningMu.Lock()
-	entry := r.running[taskID]
+	entry := r.running[runID]
 	if entry != nil {
 		stopped = entry.stopped
-		delete(r.running, taskID)
+		delete(r.running, runID)
 	}
 	r.runningMu.Unlock()
 	return stopped
 }
 
-func (r *TaskRunner) markStopped(taskID string) *exec.Cmd {
+func (r *TaskRunner) stopLocal(runID string) bool {
 	r.runningMu.Lock()
-	entry := r.running[taskID]
-	var cmd *exec.Cmd
+	entry := r.running[runID]
 	if entry != nil {
 		entry.stopped = true
-		cmd = entry.cmd
 	}
 	r.runningMu.Unlock()
-	return cmd
+	if entry == nil {
+		return false
+	}
+	entry.cancel()
+	return true
 }
 
-func (r *TaskRunner) stopLocal(taskID string) error {
-	cmd := r.markStopped(taskID)
-	if cmd == nil || cmd.Process == nil {
-		return nil
+// runnerTokenHeader carries the shared runner token, in both directions.
+const runnerTokenHeader = "X-Runner-Token"
+
+// runnerRequest builds a request to a runner with the shared token and, when
+// there is a body, the JSON content type.
+func (r *TaskRunner) runnerRequest(method, url string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
 	}
-	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
-		_ = cmd.Process.Kill()
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
-	return nil
+	req.Header.Set(runnerTokenHeader, r.runnerToken)
+	return req, nil
 }
 
-func (r *TaskRunner) stopRemote(taskID string) error {
-	client := &http.Client{Timeout: 10 * time.Second}
-	body, err := json.Marshal(map[string]string{"task_id": taskID})
+// stopRemote asks the runner to stop a job and reports whether the runner
+// had anything to stop.
+func (r *TaskRunner) stopRemote(baseURL, taskID, jobID string) (bool, error) {
+	body, err := json.Marshal(map[string]string{"task_id": taskID, "job_id": jobID})
 	if err != nil {
-		return err
+		return false, err
 	}
-	req, err := http.NewRequest(http.MethodPost, r.runnerURL+"/stop", bytes.NewReader(body))
+	req, err := r.runnerRequest(http.MethodPost, baseURL+"/stop", body)
 	if err != nil {
-		return err
+		return false, err
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := client.Do(req)
+	resp, err := r.client.Do(req)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil
+		return false, nil
 	}
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("runner stop status %d", resp.StatusCode)
+		return false, fmt.Errorf("runner stop status %d", resp.StatusCode)
 	}
-	return nil
+	return true, nil
 }
 
-func (r *TaskRunner) execute(task *model.Task, script *model.Script, targetHost string) {
+func (r *TaskRunner) execute(task *model.Task, script *model.Script, run *model.TaskRun) {
 	runCtx := context.Background()
-	finishTime := time.Now()
-	status := TaskStatusFinished
 
-	if r.runnerURL != "" {
-		reports, runStatus, err := r.runRemote(task, script, targetHost)
-		if err != nil {
-			status = TaskStatusFailed
-		} else {
-			if runStatus == "failed" {
-				status = TaskStatusFailed
-			} else if runStatus == "stopped" {
-				status = TaskStatusStopped
+	if isRemoteNode(run.RunnerNode) {
+		if err := r.submitRemote(runCtx, task, script, run); err != nil {
+			if run.RunnerJobID != "" {
+				_, _ = r.stopRemote(r.runnerBase(run), task.ID, run.RunnerJobID)
+			} else {
+				r.releaseNodes(runCtx, run.RunnerNode)
 			}
-			for _, report := range reports {
-				_ = r.reports.Create(runCtx, &model.Report{
-					TaskID:   &task.ID,
-					Name:     report.Name,
-					Type:     report.Type,
-					FilePath: report.FilePath,
-				})
+			for _, worker := range run.Workers {
+				if worker.JobID == "" {
+					r.releaseNodes(runCtx, worker.Node)
+				}
 			}
+			r.finalize(runCtx, task, run, TaskStatusFailed, RunExitRunnerError+": "+err.Error(), nil)
+			return
 		}
-	} else if err := r.runLocal(task, script, targetHost); err != nil {
+		r.track(task, run)
+		return
+	}
+
+	status := TaskStatusFinished
+	exitReason := RunExitCompleted
+	if err := r.runLocal(task, script, run); err != nil {
 		if errors.Is(err, ErrStopped) {
 			status = TaskStatusStopped
+			exitReason = RunExitStopped
 		} else {
 			status = TaskStatusFailed
+			exitReason = RunExitEngineFailed + ": " + err.Error()
 		}
 	}
+	r.finalize(runCtx, task, run, status, exitReason, nil)
+}
+
+// finalize closes a run exactly once: whichever caller claims it first
+// records the reports, ingests metrics, evaluates SLA rules and notifies
+// live subscribers. Later callers are no-ops.
+func (r *TaskRunner) finalize(ctx context.Context, task *model.Task, run *model.TaskRun, status, exitReason string, reports []RunnerReport) {
+	finishTime := time.Now()
+	run.Status = status
+	run.ExitReason = exitReason
+	run.FinishedAt = &finishTime
+	claimed, err := r.runs.Transition(ctx, run, TaskStatusRunning)
+	if err != nil {
+		log.Printf("finish run %s: %v", run.ID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	defer r.notifyQueue()
+	reports = append(reports, r.releaseWorkers(task, run)...)
+
+	for _, report := range reports {
+		r.createReport(ctx, task, run, report.Name, report.Type, report.FilePath)
+	}
+
+	metrics := r.ingestMetrics(ctx, run)
+	run.SLAVerdict, run.SLAResults = r.evaluateSLA(ctx, task, metrics)
+	_ = r.runs.Update(ctx, run)
 
 	task.Status = status
+	task.SLAVerdict = run.SLAVerdict
 	task.FinishedAt = &finishTime
-	_ = r.tasks.Update(runCtx, task)
+	_ = r.tasks.Update(ctx, task)
+
+	if r.live != nil {
+		r.live.Publish(model.LiveEvent{
+			Type:   model.LiveEventFinished,
+			RunID:  run.ID,
+			TaskID: task.ID,
+			Status: status,
+		})
+	}
+}
+
+// followLive tails the run's result files while the engine runs locally and
+// publishes snapshots until the returned func is called.
+func (r *TaskRunner) followLive(run *model.TaskRun, eng engine.Engine, reportDir string) func() {
+	if r.live == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		tailer := eng.Tail(reportDir)
+		ticker := time.NewTicker(liveSnapshotInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				sample, err := tailer.Poll()
+				if err != nil || sample == nil {
+					continue
+				}
+				r.live.Publish(model.LiveEvent{
+					Type:     model.LiveEventSnapshot,
+					RunID:    run.ID,
+					TaskID:   run.TaskID,
+					Snapshot: sample,
+				})
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+func (r *TaskRunner) evaluateSLA(ctx context.Context, task *model.Task, metrics []model.EndpointMetric) (string, []model.SLAResult) {
+	var global []model.SLARule
+	if r.settings != nil {
+		rules, err := r.settings.GetSLARules(ctx)
+		if err != nil {
+			log.Printf("load sla rules: %v", err)
+		}
+		global = rules
+	}
+	return EvaluateSLA(MergeSLARules(global, task.SLARules), metrics)
+}
+
+func (r *TaskRunner) createReport(ctx context.Context, task *model.Task, run *model.TaskRun, name, reportType, filePath string) {
+	_ = r.reports.Create(ctx, &model.Report{
+		TaskID:   &task.ID,
+		RunID:    &run.ID,
+		Name:     name,
+		Type:     reportType,
+		FilePath: filePath,
+	})
 }
 
 type runnerRequest struct {
 	TaskID          string `json:"task_id"`
+	RunID           string `json:"run_id"`
+	ReportDir       string `json:"report_dir"`
 	TaskName        string `json:"task_name"`
 	UsersCount      int    `json:"users_count"`
 	SpawnRate       int    `json:"spawn_rate"`
@@ -244,128 +597,343 @@ type runnerRequest struct {
 	JmeterTPM       *int   `json:"jmeter_tpm"`
 	ScriptType      string `json:"script_type"`
 	ScriptContent   string `json:"script_content"`
+	ScriptBundle    []byte `json:"script_bundle,omitempty"`
+	Entrypoint      string `json:"entrypoint,omitempty"`
+	Role            string `json:"role,omitempty"`
+	ExpectWorkers   int    `json:"expect_workers,omitempty"`
+	MasterHost      string `json:"master_host,omitempty"`
+	MasterPort      int    `json:"master_port,omitempty"`
+
+	Stages    []model.LoadStage `json:"stages,omitempty"`
+	Variables map[string]string `json:"variables,omitempty"`
+	// Secrets carries decrypted values to the runner; never log it.
+	Secrets map[string]string `json:"secrets,omitempty"`
 }
 
-type runnerReport struct {
+type RunnerReport struct {
 	Name     string `json:"name"`
 	Type     string `json:"type"`
 	FilePath string `json:"file_path"`
 }
 
-type runnerResponse struct {
-	Status  string         `json:"status"`
-	Reports []runnerReport `json:"reports"`
+// RunnerJob is the state of an asynchronous job as reported by a runner,
+// either in answer to GET /jobs/{id} or in its completion callback.
+type RunnerJob struct {
+	ID          string         `json:"job_id"`
+	RunID       string         `json:"run_id"`
+	Status      string         `json:"status"`
+	StopOutcome string         `json:"stop_outcome,omitempty"`
+	MasterPort  int            `json:"master_port,omitempty"`
+	Reports     []RunnerReport `json:"reports"`
 }
 
-func (r *TaskRunner) runRemote(task *model.Task, script *model.Script, targetHost string) ([]runnerReport, string, error) {
-	client := &http.Client{Timeout: time.Duration(task.DurationSeconds+30) * time.Second}
+// outcome maps a terminal job status to the run status and exit reason. ok
+// is false while the job is still running.
+func (j RunnerJob) outcome() (status, exitReason string, ok bool) {
+	switch j.Status {
+	case TaskStatusFinished:
+		return TaskStatusFinished, RunExitCompleted, true
+	case TaskStatusFailed:
+		return TaskStatusFailed, RunExitEngineFailed, true
+	case TaskStatusStopped:
+		return TaskStatusStopped, RunExitStopped, true
+	default:
+		return "", "", false
+	}
+}
+
+// submitRemote hands the run to the runner, which accepts it immediately and
+// executes it in the background. The job id is stored so tracking survives
+// an API restart.
+func (r *TaskRunner) submitRemote(ctx context.Context, task *model.Task, script *model.Script, run *model.TaskRun) error {
+	secrets, err := r.secretValues(ctx, run)
+	if err != nil {
+		return err
+	}
 	reqBody := runnerRequest{
 		TaskID:          task.ID,
+		RunID:           run.ID,
+		ReportDir:       run.ReportDir,
 		TaskName:        task.Name,
-		UsersCount:      task.UsersCount,
-		SpawnRate:       task.SpawnRate,
-		DurationSeconds: task.DurationSeconds,
-		TargetHost:      targetHost,
-		JmeterTPM:       task.JmeterTPM,
+		UsersCount:      run.Parameters.UsersCount,
+		SpawnRate:       run.Parameters.SpawnRate,
+		DurationSeconds: run.Parameters.DurationSeconds,
+		TargetHost:      run.TargetHost,
+		JmeterTPM:       run.Parameters.JmeterTPM,
 		ScriptType:      script.Type,
 		ScriptContent:   script.Content,
+		ScriptBundle:    script.Bundle,
+		Entrypoint:      script.Entrypoint,
+		Stages:          run.Parameters.Stages,
+		Variables:       run.Parameters.Variables,
+		Secrets:         secrets,
+	}
+
+	if len(run.Workers) > 0 {
+		reqBody.Role = runnerRoleMaster
+		reqBody.ExpectWorkers = len(run.Workers)
 	}
 
+	job, err := r.postJob(r.runnerBase(run), reqBody)
+	if err != nil {
+		return err
+	}
+	run.RunnerJobID = job.ID
+	if err := r.runs.Update(ctx, run); err != nil {
+		return err
+	}
+
+	if len(run.Workers) > 0 {
+		return r.submitWorkers(ctx, reqBody, run, job.MasterPort)
+	}
+	return nil
+}
+
+func (r *TaskRunner) postJob(baseURL string, reqBody runnerRequest) (*RunnerJob, error) {
 	data, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
-	resp, err := client.Post(r.runnerURL+"/run", "application/json", bytes.NewReader(data))
+	req, err := r.runnerRequest(http.MethodPost, baseURL+"/jobs", data)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(req)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, fmt.Errorf("runner status %d", resp.StatusCode)
+	}
+
+	var job RunnerJob
+	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
+		return nil, err
+	}
+	if job.ID == "" {
+		return nil, errors.New("runner returned no job id")
+	}
+	return &job, nil
+}
+
+func (r *TaskRunner) fetchJob(baseURL, jobID string) (*RunnerJob, error) {
+	req, err := r.runnerRequest(http.MethodGet, baseURL+"/jobs/"+jobID, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, "", fmt.Errorf("runner status %d", resp.StatusCode)
+		return nil, fmt.Errorf("runner status %d", resp.StatusCode)
 	}
 
-	var out runnerResponse
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return nil, "", err
+	var job RunnerJob
+	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
+		return nil, err
 	}
+	return &job, nil
+}
 
-	return out.Reports, out.Status, nil
+// track polls the runner until the job reaches a terminal state or the run
+// has been closed by other means, e.g. the completion callback.
+func (r *TaskRunner) track(task *model.Task, run *model.TaskRun) {
+	ctx := context.Background()
+	baseURL := r.runnerBase(run)
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		current, err := r.runs.GetByID(ctx, run.ID)
+		if err == nil && current.Status != TaskStatusRunning {
+			return
+		}
+
+		job, err := r.fetchJob(baseURL, run.RunnerJobID)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				r.finalize(ctx, task, run, TaskStatusFailed, RunExitLostRunner, r.partialReports(ctx, task, run))
+				return
+			}
+			log.Printf("poll job %s for run %s: %v", run.RunnerJobID, run.ID, err)
+			continue
+		}
+		if status, exitReason, ok := job.outcome(); ok {
+			run.StopOutcome = job.StopOutcome
+			r.finalize(ctx, task, run, status, exitReason, job.Reports)
+			return
+		}
+	}
 }
 
-func (r *TaskRunner) runLocal(task *model.Task, script *model.Script, targetHost string) error {
-	if script.Type == "" || script.Type == model.ScriptTypeLocust {
-		return r.runLocust(task, script, targetHost)
+// CompleteRemote handles a runner's completion callback. It is safe to call
+// more than once and after polling already closed the run.
+func (r *TaskRunner) CompleteRemote(ctx context.Context, jobID string, job RunnerJob) error {
+	run, err := r.runs.GetByID(ctx, job.RunID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return ErrNotFound
+		}
+		return err
 	}
-	if script.Type == model.ScriptTypeJMeter {
-		return ErrUnsupportedEngine
+	if run.RunnerJobID != jobID {
+		return ErrNotFound
+	}
+	if run.Status != TaskStatusRunning {
+		return nil
 	}
-	return ErrInvalidScriptType
-}
 
-func (r *TaskRunner) runLocust(task *model.Task, script *model.Script, targetHost string) error {
-	timestamp := time.Now().Format("20060102150405")
-	reportDir := filepath.Join(r.reportsDir, fmt.Sprintf("task_%s_%s", task.ID, timestamp))
-	if err := os.MkdirAll(reportDir, 0o755); err != nil {
+	status, exitReason, ok := job.outcome()
+	if !ok {
+		return ErrRunNotActive
+	}
+
+	task, err := r.tasks.GetByID(ctx, run.TaskID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return ErrNotFound
+		}
 		return err
 	}
+	run.StopOutcome = job.StopOutcome
+	r.finalize(ctx, task, run, status, exitReason, job.Reports)
+	return nil
+}
 
-	scriptPath := filepath.Join(reportDir, "locustfile.py")
-	if err := os.WriteFile(scriptPath, []byte(script.Content), 0o644); err != nil {
+// Resume picks up tracking of remote jobs that were in flight when the API
+// last stopped.
+func (r *TaskRunner) Resume(ctx context.Context) error {
+	runs, err := r.runs.ListByStatus(ctx, TaskStatusRunning)
+	if err != nil {
 		return err
 	}
+	for i := range runs {
+		run := &runs[i]
+		if run.RunnerJobID == "" {
+			continue
+		}
+		task, err := r.tasks.GetByID(ctx, run.TaskID)
+		if err != nil {
+			log.Printf("resume run %s: %v", run.ID, err)
+			continue
+		}
+		go r.track(task, run)
+	}
+	return nil
+}
 
-	csvPrefix := filepath.Join(reportDir, "report")
-	htmlPath := filepath.Join(reportDir, "report.html")
+func isRemoteNode(node string) bool {
+	return strings.HasPrefix(node, "http://") || strings.HasPrefix(node, "https://")
+}
+
+// runnerBase returns the runner a run was dispatched to, falling back to the
+// configured runner for runs recorded without one.
+func (r *TaskRunner) runnerBase(run *model.TaskRun) string {
+	if isRemoteNode(run.RunnerNode) {
+		return strings.TrimRight(run.RunnerNode, "/")
+	}
+	return strings.TrimRight(r.runnerURL, "/")
+}
 
+// runLocal runs the script with the embedded engines. Stop cancels the
+// run's context; the engine still writes its results before returning.
+func (r *TaskRunner) runLocal(task *model.Task, script *model.Script, run *model.TaskRun) error {
+	eng, err := r.engines.Get(script.Type)
+	if err != nil {
+		return ErrUnsupportedEngine
+	}
+	reportDir, err := filepath.Abs(filepath.Join(r.reportsDir, run.ReportDir))
+	if err != nil {
+		return err
+	}
+	secrets, err := r.secretValues(context.Background(), run)
+	if err != nil {
+		return err
+	}
 	host := r.locustHost
-	if targetHost != "" {
-		host = targetHost
-	}
-
-	cmd := exec.Command(
-		r.locustBin,
-		"-f", scriptPath,
-		"--headless",
-		"-u", fmt.Sprintf("%d", task.UsersCount),
-		"-r", fmt.Sprintf("%d", task.SpawnRate),
-		"--run-time", fmt.Sprintf("%ds", task.DurationSeconds),
-		"--host", host,
-		"--csv", csvPrefix,
-		"--html", htmlPath,
-	)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	r.setRunning(task.ID, cmd)
-	cmdErr := cmd.Run()
-	stopped := r.clearRunning(task.ID)
-
-	if cmdErr != nil && !stopped {
-		return cmdErr
-	}
-
-	csvStats := csvPrefix + "_stats.csv"
-	csvFile := filepath.Base(csvStats)
-	htmlFile := filepath.Base(htmlPath)
-
-	relativeDir := filepath.Base(reportDir)
-	_ = r.reports.Create(context.Background(), &model.Report{
-		TaskID:   &task.ID,
-		Name:     fmt.Sprintf("%s-%s", task.Name, htmlFile),
-		Type:     "html",
-		FilePath: filepath.Join(relativeDir, htmlFile),
-	})
-	_ = r.reports.Create(context.Background(), &model.Report{
-		TaskID:   &task.ID,
-		Name:     fmt.Sprintf("%s-%s", task.Name, csvFile),
-		Type:     "csv",
-		FilePath: filepath.Join(relativeDir, csvFile),
-	})
+	if run.TargetHost != "" {
+		host = run.TargetHost
+	}
+
+	job := &engine.Job{
+		Dir:         reportDir,
+		TaskName:    task.Name,
+		Script:      script.Content,
+		Bundle:      script.Bundle,
+		Entrypoint:  script.Entrypoint,
+		TargetHost:  host,
+		Users:       run.Parameters.UsersCount,
+		SpawnRate:   run.Parameters.SpawnRate,
+		Duration:    run.Parameters.DurationSeconds,
+		JMeterTPM:   run.Parameters.JmeterTPM,
+		Stages:      run.Parameters.Stages,
+		Variables:   run.Parameters.Variables,
+		Secrets:     secrets,
+		LogMaxBytes: r.logMaxSize,
+		StopGrace:   r.stopGrace,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.setRunning(run, cancel)
+	stopLive := r.followLive(run, eng, reportDir)
+	result, err := engine.Execute(ctx, eng, job)
+	stopLive()
+	stopped := r.clearRunning(run.ID)
+	if err != nil {
+		return err
+	}
+	for _, report := range result.Reports {
+		r.createReport(context.Background(), task, run, report.Name, report.Type, report.File)
+	}
 
-	if stopped {
+	switch {
+	case stopped:
+		// A stop that came after the engine finished finds nothing to stop.
+		run.StopOutcome = result.StopOutcome
+		if run.StopOutcome == "" {
+			run.StopOutcome = engine.StopExited
+		}
 		return ErrStopped
+	case result.Failed && result.Err != nil:
+		return result.Err
+	case result.Failed:
+		return engine.ErrRequestsFailed
 	}
 	return nil
 }
+
+// secretValues decrypts the secrets a run injects.
+func (r *TaskRunner) secretValues(ctx context.Context, run *model.TaskRun) (map[string]string, error) {
+	if len(run.Parameters.Secrets) == 0 {
+		return nil, nil
+	}
+	if r.secrets == nil {
+		return nil, ErrInvalidSecret
+	}
+	values, err := r.secrets.Resolve(ctx, run.Parameters.Secrets)
+	if err != nil {
+		return nil, fmt.Errorf("resolve secrets: %w", err)
+	}
+	return values, nil
+}
+
+func (r *TaskRunner) ingestMetrics(ctx context.Context, run *model.TaskRun) []model.EndpointMetric {
+	if r.metrics == nil || run.ReportDir == "" {
+		return nil
+	}
+	metrics, err := r.metrics.Ingest(ctx, run)
+	if err != nil && !errors.Is(err, results.ErrNoResults) {
+		log.Printf("ingest metrics for run %s: %v", run.ID, err)
+	}
+	return metrics
+}
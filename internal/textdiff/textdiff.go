@@ -0,0 +1,152 @@
+// Package textdiff renders line-based unified diffs between two texts.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around a change.
+const DefaultContext = 3
+
+type edit struct {
+	kind byte // ' ', '-' or '+'
+	line string
+	a, b int // positions in a and b before this edit
+}
+
+// Unified returns a unified diff from a to b labelled with the given names,
+// or "" when the texts are equal.
+func Unified(fromName, toName, a, b string, context int) string {
+	if a == b {
+		return ""
+	}
+	edits := diff(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for _, hunk := range hunks(edits, context) {
+		writeHunk(&out, edits[hunk[0]:hunk[1]])
+	}
+	return out.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diff computes a shortest edit script with Myers' algorithm.
+func diff(a, b []string) []edit {
+	n, m := len(a), len(b)
+	limit := n + m
+	if limit == 0 {
+		return nil
+	}
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, offset)
+			}
+		}
+	}
+	return nil
+}
+
+func backtrack(a, b []string, trace [][]int, offset int) []edit {
+	x, y := len(a), len(b)
+	var edits []edit
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{kind: ' ', line: a[x], a: x, b: y})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			edits = append(edits, edit{kind: '+', line: b[prevY], a: prevX, b: prevY})
+		} else {
+			edits = append(edits, edit{kind: '-', line: a[prevX], a: prevX, b: prevY})
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// hunks groups changes with their surrounding context, merging groups whose
+// context would overlap. Each hunk is a [start, end) range of edits.
+func hunks(edits []edit, context int) [][2]int {
+	var out [][2]int
+	for i, e := range edits {
+		if e.kind == ' ' {
+			continue
+		}
+		start := max(0, i-context)
+		end := min(len(edits), i+context+1)
+		if n := len(out); n > 0 && start <= out[n-1][1] {
+			out[n-1][1] = end
+			continue
+		}
+		out = append(out, [2]int{start, end})
+	}
+	return out
+}
+
+func writeHunk(out *strings.Builder, edits []edit) {
+	aStart, bStart := edits[0].a+1, edits[0].b+1
+	var aLen, bLen int
+	for _, e := range edits {
+		if e.kind != '+' {
+			aLen++
+		}
+		if e.kind != '-' {
+			bLen++
+		}
+	}
+	// An empty range starts at the line before it, as in diff -u.
+	if aLen == 0 {
+		aStart--
+	}
+	if bLen == 0 {
+		bStart--
+	}
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+	for _, e := range edits {
+		out.WriteByte(e.kind)
+		out.WriteString(e.line)
+		out.WriteByte('\n')
+	}
+}
@@ -0,0 +1,28 @@
+package textdiff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	b := "a\nb\nC\nd\ne\nf\ng\nh\ni\nj\nk\n"
+
+	got := Unified("v1", "v2", a, b, 1)
+	want := "--- v1\n+++ v2\n" +
+		"@@ -2,3 +2,3 @@\n b\n-c\n+C\n d\n" +
+		"@@ -10,1 +10,2 @@\n j\n+k\n"
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := Unified("v1", "v2", a, a, DefaultContext); got != "" {
+		t.Fatalf("expected empty diff for equal texts, got %q", got)
+	}
+}
+
+func TestUnifiedFromEmpty(t *testing.T) {
+	got := Unified("v1", "v2", "", "x\ny\n", DefaultContext)
+	want := "--- v1\n+++ v2\n@@ -0,0 +1,2 @@\n+x\n+y\n"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,10 @@
+// Package locust embeds the sample plans shipped in this directory so the
+// server can build on them.
+package locust
+
+import _ "embed"
+
+// JMeterTemplate is the JMeter plan generated plans are based on.
+//
+//go:embed jmeter-template.jmx
+var JMeterTemplate string
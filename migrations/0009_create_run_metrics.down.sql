@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS run_metric_samples;
+DROP TABLE IF EXISTS run_metrics;
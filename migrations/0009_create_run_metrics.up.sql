@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS run_metrics (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id uuid NOT NULL REFERENCES locust_tasks(id) ON DELETE CASCADE,
+    report_dir varchar(255) NOT NULL,
+    method varchar(32) NOT NULL DEFAULT '',
+    name text NOT NULL,
+    request_count bigint NOT NULL DEFAULT 0,
+    failure_count bigint NOT NULL DEFAULT 0,
+    avg_ms double precision NOT NULL DEFAULT 0,
+    min_ms double precision NOT NULL DEFAULT 0,
+    max_ms double precision NOT NULL DEFAULT 0,
+    p50_ms double precision NOT NULL DEFAULT 0,
+    p90_ms double precision NOT NULL DEFAULT 0,
+    p95_ms double precision NOT NULL DEFAULT 0,
+    p99_ms double precision NOT NULL DEFAULT 0,
+    rps double precision NOT NULL DEFAULT 0,
+    failures_per_sec double precision NOT NULL DEFAULT 0,
+    created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_run_metrics_task_id ON run_metrics (task_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_run_metrics_report_dir ON run_metrics (report_dir);
+
+CREATE TABLE IF NOT EXISTS run_metric_samples (
+    id bigserial PRIMARY KEY,
+    task_id uuid NOT NULL REFERENCES locust_tasks(id) ON DELETE CASCADE,
+    report_dir varchar(255) NOT NULL,
+    sampled_at timestamp NOT NULL,
+    user_count integer NOT NULL DEFAULT 0,
+    rps double precision NOT NULL DEFAULT 0,
+    failures_per_sec double precision NOT NULL DEFAULT 0,
+    p50_ms double precision NOT NULL DEFAULT 0,
+    p95_ms double precision NOT NULL DEFAULT 0,
+    total_requests bigint NOT NULL DEFAULT 0,
+    total_failures bigint NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_run_metric_samples_report_dir ON run_metric_samples (report_dir, sampled_at);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_run_metric_samples_run_id;
+DROP INDEX IF EXISTS idx_run_metrics_run_id;
+ALTER TABLE run_metric_samples DROP COLUMN IF EXISTS run_id;
+ALTER TABLE run_metrics DROP COLUMN IF EXISTS run_id;
+DROP INDEX IF EXISTS idx_locust_reports_run_id;
+ALTER TABLE locust_reports DROP COLUMN IF EXISTS run_id;
+DROP TABLE IF EXISTS task_runs;
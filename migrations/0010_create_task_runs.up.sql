@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS task_runs (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id uuid NOT NULL REFERENCES locust_tasks(id) ON DELETE CASCADE,
+    status varchar(32) NOT NULL,
+    parameters jsonb NOT NULL DEFAULT '{}'::jsonb,
+    target_host varchar(255) NOT NULL DEFAULT '',
+    report_dir varchar(255) NOT NULL DEFAULT '',
+    runner_node varchar(255) NOT NULL DEFAULT '',
+    exit_reason text NOT NULL DEFAULT '',
+    triggered_by uuid REFERENCES users(id) ON DELETE SET NULL,
+    created_at timestamp NOT NULL DEFAULT now(),
+    started_at timestamp,
+    finished_at timestamp
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_runs_task_id ON task_runs (task_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_task_runs_status ON task_runs (status);
+
+ALTER TABLE locust_reports
+ADD COLUMN IF NOT EXISTS run_id uuid REFERENCES task_runs(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_locust_reports_run_id ON locust_reports (run_id);
+
+ALTER TABLE run_metrics
+ADD COLUMN IF NOT EXISTS run_id uuid REFERENCES task_runs(id) ON DELETE CASCADE;
+
+ALTER TABLE run_metric_samples
+ADD COLUMN IF NOT EXISTS run_id uuid REFERENCES task_runs(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_run_metrics_run_id ON run_metrics (run_id);
+CREATE INDEX IF NOT EXISTS idx_run_metric_samples_run_id ON run_metric_samples (run_id, sampled_at);
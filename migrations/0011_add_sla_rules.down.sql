@@ -0,0 +1,7 @@
+ALTER TABLE task_runs
+DROP COLUMN IF EXISTS sla_results,
+DROP COLUMN IF EXISTS sla_verdict;
+
+ALTER TABLE locust_tasks
+DROP COLUMN IF EXISTS sla_verdict,
+DROP COLUMN IF EXISTS sla_rules;
@@ -0,0 +1,7 @@
+ALTER TABLE locust_tasks
+ADD COLUMN IF NOT EXISTS sla_rules jsonb NOT NULL DEFAULT '[]'::jsonb,
+ADD COLUMN IF NOT EXISTS sla_verdict varchar(16) NOT NULL DEFAULT '';
+
+ALTER TABLE task_runs
+ADD COLUMN IF NOT EXISTS sla_verdict varchar(16) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS sla_results jsonb NOT NULL DEFAULT '[]'::jsonb;
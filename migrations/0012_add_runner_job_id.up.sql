@@ -0,0 +1,2 @@
+ALTER TABLE task_runs
+ADD COLUMN IF NOT EXISTS runner_job_id varchar(64) NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+ALTER TABLE locust_tasks
+DROP COLUMN IF EXISTS runner_labels;
+
+DROP TABLE IF EXISTS runners;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS runners (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name varchar(128) NOT NULL UNIQUE,
+    url varchar(255) NOT NULL,
+    labels jsonb NOT NULL DEFAULT '{}'::jsonb,
+    max_jobs integer NOT NULL DEFAULT 0,
+    active_jobs integer NOT NULL DEFAULT 0,
+    cpu_percent double precision NOT NULL DEFAULT 0,
+    last_heartbeat_at timestamp NOT NULL DEFAULT now(),
+    created_at timestamp NOT NULL DEFAULT now()
+);
+
+ALTER TABLE locust_tasks
+ADD COLUMN IF NOT EXISTS runner_labels jsonb NOT NULL DEFAULT '{}'::jsonb;
@@ -0,0 +1,5 @@
+ALTER TABLE task_runs
+DROP COLUMN IF EXISTS workers;
+
+ALTER TABLE locust_tasks
+DROP COLUMN IF EXISTS workers;
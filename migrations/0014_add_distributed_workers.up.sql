@@ -0,0 +1,5 @@
+ALTER TABLE locust_tasks
+ADD COLUMN IF NOT EXISTS workers integer NOT NULL DEFAULT 0;
+
+ALTER TABLE task_runs
+ADD COLUMN IF NOT EXISTS workers jsonb NOT NULL DEFAULT '[]'::jsonb;
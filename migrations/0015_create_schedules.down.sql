@@ -0,0 +1,4 @@
+ALTER TABLE task_runs
+DROP COLUMN IF EXISTS schedule_id;
+
+DROP TABLE IF EXISTS schedules;
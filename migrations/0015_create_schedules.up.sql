@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS schedules (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id uuid NOT NULL REFERENCES locust_tasks(id) ON DELETE CASCADE,
+    name varchar(128) NOT NULL,
+    cron_expr varchar(128) NOT NULL DEFAULT '',
+    interval_seconds integer NOT NULL DEFAULT 0,
+    timezone varchar(64) NOT NULL DEFAULT 'UTC',
+    target_host varchar(255),
+    enabled boolean NOT NULL DEFAULT true,
+    next_run_at timestamptz,
+    last_run_at timestamptz,
+    last_run_id uuid,
+    last_error text NOT NULL DEFAULT '',
+    created_by uuid REFERENCES users(id) ON DELETE SET NULL,
+    created_at timestamp NOT NULL DEFAULT now(),
+    updated_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedules_due ON schedules (next_run_at) WHERE enabled;
+
+ALTER TABLE task_runs
+ADD COLUMN IF NOT EXISTS schedule_id uuid REFERENCES schedules(id) ON DELETE SET NULL;
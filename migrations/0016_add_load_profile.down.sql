@@ -0,0 +1,2 @@
+ALTER TABLE locust_tasks
+DROP COLUMN IF EXISTS load_profile;
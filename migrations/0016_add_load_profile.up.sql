@@ -0,0 +1,2 @@
+ALTER TABLE locust_tasks
+ADD COLUMN IF NOT EXISTS load_profile jsonb;
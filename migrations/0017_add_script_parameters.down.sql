@@ -0,0 +1,5 @@
+ALTER TABLE locust_tasks
+DROP COLUMN IF EXISTS variables;
+
+ALTER TABLE locust_scripts
+DROP COLUMN IF EXISTS parameters;
@@ -0,0 +1,5 @@
+ALTER TABLE locust_scripts
+ADD COLUMN IF NOT EXISTS parameters jsonb NOT NULL DEFAULT '[]'::jsonb;
+
+ALTER TABLE locust_tasks
+ADD COLUMN IF NOT EXISTS variables jsonb NOT NULL DEFAULT '{}'::jsonb;
@@ -0,0 +1,4 @@
+ALTER TABLE locust_tasks
+DROP COLUMN IF EXISTS secrets;
+
+DROP TABLE IF EXISTS secrets;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS secrets (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name varchar(128) NOT NULL UNIQUE,
+    description text NOT NULL DEFAULT '',
+    ciphertext bytea NOT NULL,
+    created_by uuid REFERENCES users(id) ON DELETE SET NULL,
+    created_at timestamp NOT NULL DEFAULT now(),
+    updated_at timestamp NOT NULL DEFAULT now()
+);
+
+ALTER TABLE locust_tasks
+ADD COLUMN IF NOT EXISTS secrets jsonb NOT NULL DEFAULT '[]'::jsonb;
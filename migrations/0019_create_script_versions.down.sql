@@ -0,0 +1,7 @@
+ALTER TABLE locust_tasks
+DROP COLUMN IF EXISTS script_version;
+
+ALTER TABLE locust_scripts
+DROP COLUMN IF EXISTS version;
+
+DROP TABLE IF EXISTS script_versions;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS script_versions (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    script_id uuid NOT NULL REFERENCES locust_scripts(id) ON DELETE CASCADE,
+    version integer NOT NULL,
+    script_type varchar(20) NOT NULL,
+    content text NOT NULL,
+    parameters jsonb NOT NULL DEFAULT '[]'::jsonb,
+    message text NOT NULL DEFAULT '',
+    author uuid REFERENCES users(id) ON DELETE SET NULL,
+    created_at timestamp NOT NULL DEFAULT now(),
+    UNIQUE (script_id, version)
+);
+
+ALTER TABLE locust_scripts
+ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+INSERT INTO script_versions (script_id, version, script_type, content, parameters, message, created_at)
+SELECT id, 1, script_type, content, parameters, 'initial version', updated_at
+FROM locust_scripts
+ON CONFLICT (script_id, version) DO NOTHING;
+
+ALTER TABLE locust_tasks
+ADD COLUMN IF NOT EXISTS script_version integer;
@@ -0,0 +1,8 @@
+ALTER TABLE script_versions
+DROP COLUMN IF EXISTS bundle,
+DROP COLUMN IF EXISTS files,
+DROP COLUMN IF EXISTS entrypoint;
+
+ALTER TABLE locust_scripts
+DROP COLUMN IF EXISTS files,
+DROP COLUMN IF EXISTS entrypoint;
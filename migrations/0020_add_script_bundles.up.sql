@@ -0,0 +1,8 @@
+ALTER TABLE locust_scripts
+ADD COLUMN IF NOT EXISTS entrypoint text NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS files jsonb NOT NULL DEFAULT '[]'::jsonb;
+
+ALTER TABLE script_versions
+ADD COLUMN IF NOT EXISTS entrypoint text NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS files jsonb NOT NULL DEFAULT '[]'::jsonb,
+ADD COLUMN IF NOT EXISTS bundle bytea;
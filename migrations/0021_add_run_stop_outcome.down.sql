@@ -0,0 +1,2 @@
+ALTER TABLE task_runs
+DROP COLUMN IF EXISTS stop_outcome;
@@ -0,0 +1,2 @@
+ALTER TABLE task_runs
+ADD COLUMN IF NOT EXISTS stop_outcome varchar(32) NOT NULL DEFAULT '';
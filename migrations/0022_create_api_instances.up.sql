@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS api_instances (
+    node varchar(255) PRIMARY KEY,
+    last_heartbeat_at timestamp NOT NULL DEFAULT now()
+);